@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/blef"
+)
+
+var (
+	applyOutputFile string
+	applyDryRun     bool
+)
+
+var applyCmd = &cobra.Command{
+	Use:   "apply [patch-file] [blef-file]",
+	Short: "Apply a BLEF patch to a BLEF file",
+	Long: `Apply a BLEF patch to a BLEF file.
+
+A patch is a JSON document listing typed operations (add, update or remove a
+book, collection or entry) addressed by ID. Create one with
+"blef-cli diff old.blef.json new.blef.json --format patch".
+
+Every operation checks its preconditions: added elements must not exist yet,
+updated and removed ones must exist and still hold the values recorded in the
+patch. If any operation fails, nothing is written. The result is also
+validated before it is written.
+
+By default the BLEF file is updated in place.
+
+Examples:
+  blef-cli apply changes.patch.json library.blef.json
+  blef-cli apply changes.patch.json library.blef.json -o updated.blef.json
+  blef-cli apply changes.patch.json library.blef.json --dry-run`,
+	Args: cobra.ExactArgs(2),
+	Run:  runApply,
+}
+
+func init() {
+	rootCmd.AddCommand(applyCmd)
+
+	applyCmd.Flags().StringVarP(&applyOutputFile, "output", "o", "", "Output file path (default: update the BLEF file in place)")
+	applyCmd.Flags().BoolVar(&applyDryRun, "dry-run", false, "Check that the patch applies without writing anything")
+}
+
+func runApply(cmd *cobra.Command, args []string) {
+	patchFile, inputFile := args[0], args[1]
+	if applyOutputFile == "" {
+		applyOutputFile = inputFile
+	}
+
+	patch, err := blef.LoadPatch(patchFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error reading patch: %v\n", err)
+		os.Exit(1)
+	}
+	doc, err := blef.LoadFromFile(inputFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error reading BLEF file: %v\n", err)
+		os.Exit(1)
+	}
+	warnUnknownFields(inputFile, doc)
+
+	fmt.Printf("🩹 Applying %s to %s (%d operations)\n", patchFile, inputFile, len(patch.Operations))
+
+	if err := blef.ApplyPatch(doc, patch); err != nil {
+		var conflict *blef.PatchConflictError
+		if errors.As(err, &conflict) {
+			fmt.Fprintf(os.Stderr, "❌ Conflict at operation %d (%s): %v\n", conflict.Index+1, conflict.Operation, conflict.Err)
+			fmt.Fprintln(os.Stderr, "   Nothing was written")
+		} else {
+			fmt.Fprintf(os.Stderr, "❌ Patch cannot be applied: %v\n", err)
+		}
+		os.Exit(1)
+	}
+
+	if errs := blef.ValidateDocument(doc); len(errs) > 0 {
+		fmt.Fprintf(os.Stderr, "❌ Patched document has %d validation error(s):\n", len(errs))
+		for _, err := range errs {
+			fmt.Fprintf(os.Stderr, "  • %s\n", err)
+		}
+		os.Exit(1)
+	}
+
+	if applyDryRun {
+		fmt.Println("✅ Patch applies cleanly (dry run, nothing written)")
+		return
+	}
+
+	fmt.Printf("💾 Writing to %s...\n", applyOutputFile)
+	if err := doc.SaveToFile(applyOutputFile); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error writing file: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("✅ Patch applied!")
+}
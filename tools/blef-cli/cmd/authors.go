@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/blef"
+)
+
+var (
+	authorsAll         bool
+	authorsFix         bool
+	authorsSortNames   bool
+	authorsIdentifiers bool
+	authorsOutputFile  string
+)
+
+var authorsCmd = &cobra.Command{
+	Use:   "authors [blef-file]",
+	Short: "Find and normalize the spellings of author names",
+	Long: `Group the authors of a BLEF file into identities and normalize their names.
+
+Importers write the same person in many ways: "Rowling, J.K.", "J. K. Rowling"
+and "JK Rowling". Names are parsed in either "First Last" or "Last, First"
+order, with particles such as de, van or von, and grouped by family name and
+given names, initials matching the names they start with: "J. K. Rowling" and
+"Joanne Rowling" are one author, "John Smith" and "Jane Smith" are two. The
+canonical name of an author is its most used spelling, preferring full given
+names and diacritics on ties.
+
+By default, the authors with several spellings are listed. With --fix, every
+author is renamed to its canonical name and the file is written. --sort-names
+also records the sort name ("Saint-Exupéry, Antoine de") in the author's
+identifiers under "sort_name", and --identifiers records the identity key
+under "name_key" and copies identifiers such as wikidata or viaf known for
+one spelling to every spelling.
+
+By default the BLEF file is updated in place.
+
+Examples:
+  blef-cli authors library.blef.json
+  blef-cli authors library.blef.json --all
+  blef-cli authors library.blef.json --fix --sort-names -o normalized.blef.json`,
+	Args: cobra.ExactArgs(1),
+	Run:  runAuthors,
+}
+
+func init() {
+	rootCmd.AddCommand(authorsCmd)
+
+	authorsCmd.Flags().BoolVar(&authorsAll, "all", false, "List every author, not only those with several spellings")
+	authorsCmd.Flags().BoolVar(&authorsFix, "fix", false, "Rename authors to their canonical name and write the file")
+	authorsCmd.Flags().BoolVar(&authorsSortNames, "sort-names", false, "With --fix, record sort names in author identifiers")
+	authorsCmd.Flags().BoolVar(&authorsIdentifiers, "identifiers", false, "With --fix, record identity keys and share identifiers across spellings")
+	authorsCmd.Flags().StringVarP(&authorsOutputFile, "output", "o", "", "Output file path (default: update the BLEF file in place)")
+}
+
+func runAuthors(cmd *cobra.Command, args []string) {
+	inputFile := args[0]
+	if authorsOutputFile == "" {
+		authorsOutputFile = inputFile
+	}
+
+	doc, err := blef.LoadFromFile(inputFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error reading BLEF file: %v\n", err)
+		os.Exit(1)
+	}
+	warnUnknownFields(inputFile, doc)
+
+	identities := blef.ResolveAuthors(doc)
+	variants := 0
+	for _, identity := range identities {
+		if len(identity.Variants) > 1 {
+			variants++
+		}
+	}
+	fmt.Printf("👤 %d author(s), %d with several spellings\n", len(identities), variants)
+
+	for _, identity := range identities {
+		if len(identity.Variants) < 2 && !authorsAll {
+			continue
+		}
+		fmt.Printf("\n  %s (%d book(s))\n", identity.SortName, len(identity.BookIDs))
+		if len(identity.Variants) > 1 {
+			fmt.Printf("     spellings: %s\n", strings.Join(identity.Variants, " | "))
+		}
+	}
+
+	if !authorsFix {
+		if variants > 0 {
+			fmt.Println("\n💡 Run with --fix to rename authors to their canonical name")
+		}
+		return
+	}
+
+	changes := blef.NormalizeAuthors(doc, blef.AuthorOptions{
+		SortNames:   authorsSortNames,
+		Identifiers: authorsIdentifiers,
+	})
+	if len(changes) == 0 && !authorsSortNames && !authorsIdentifiers {
+		fmt.Println("\n✅ Author names are already normalized")
+		return
+	}
+
+	if len(changes) > 0 {
+		fmt.Printf("\n✏️  %d author(s) renamed:\n", len(changes))
+		for _, change := range changes {
+			fmt.Printf("  • %s\n", change)
+		}
+	}
+
+	fmt.Printf("\n💾 Writing to %s...\n", authorsOutputFile)
+	if err := doc.SaveToFile(authorsOutputFile); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error writing file: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("✅ Authors normalized!")
+}
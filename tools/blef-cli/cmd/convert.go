@@ -15,6 +15,7 @@ var (
 	outputFile   string
 	formatName   string
 	skipValidate bool
+	dateOrder    string
 )
 
 var convertCmd = &cobra.Command{
@@ -30,10 +31,16 @@ Supported formats (auto-detected):
 The tool will attempt to auto-detect the CSV format. If detection fails,
 you will be prompted to manually map columns to BLEF fields.
 
+Numeric dates such as 05/03/2024 are read day first for Babelio and month
+first for Goodreads. For other CSV files they are only read when day and
+month cannot be confused, unless --date-order is given. Dates that cannot be
+read, and read dates without a day, are left out and listed with their row.
+
 Examples:
   blef-cli convert books.csv
   blef-cli convert books.csv -o my-library.blef.json
   blef-cli convert books.csv -f goodreads
+  blef-cli convert books.csv --date-order day-first
   blef-cli convert books.csv --no-validate`,
 	Args: cobra.ExactArgs(1),
 	Run:  runConvert,
@@ -45,11 +52,18 @@ func init() {
 	convertCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output file path (default: input.blef.json)")
 	convertCmd.Flags().StringVarP(&formatName, "format", "f", "", "Force format (goodreads, babelio)")
 	convertCmd.Flags().BoolVar(&skipValidate, "no-validate", false, "Skip validation after conversion")
+	convertCmd.Flags().StringVar(&dateOrder, "date-order", "", "Order of numeric dates: day-first or month-first (default: from the format)")
 }
 
 func runConvert(cmd *cobra.Command, args []string) {
 	inputFile := args[0]
 
+	order, err := blef.ParseDateOrder(dateOrder)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+
 	// Determine output file
 	if outputFile == "" {
 		base := strings.TrimSuffix(inputFile, filepath.Ext(inputFile))
@@ -102,6 +116,9 @@ func runConvert(cmd *cobra.Command, args []string) {
 
 	// Create mapper
 	mapper := csv.NewMapper(data, format)
+	if dateOrder != "" {
+		mapper.Dates.Order = order
+	}
 
 	// If no format or manual mapping requested, do interactive mapping
 	if format == nil {
@@ -124,6 +141,15 @@ func runConvert(cmd *cobra.Command, args []string) {
 		len(doc.Books), len(doc.Collections), len(doc.Entries))
 	fmt.Println("")
 
+	if len(mapper.Dropped) > 0 {
+		fmt.Printf("⚠️  %d value(s) could not be converted and were left out:\n", len(mapper.Dropped))
+		for _, dropped := range mapper.Dropped {
+			fmt.Printf("  • %s\n", dropped)
+		}
+		fmt.Println("💡 Numeric dates are read with --date-order (day-first or month-first)")
+		fmt.Println("")
+	}
+
 	// Validate before writing (unless skipped)
 	if !skipValidate {
 		fmt.Println("🔍 Validating BLEF document...")
@@ -142,13 +168,7 @@ func runConvert(cmd *cobra.Command, args []string) {
 
 	// Write to file
 	fmt.Printf("💾 Writing to %s...\n", outputFile)
-	jsonData, err := doc.ToJSON()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "❌ Error generating JSON: %v\n", err)
-		os.Exit(1)
-	}
-
-	if err := os.WriteFile(outputFile, jsonData, 0644); err != nil {
+	if err := doc.SaveToFile(outputFile); err != nil {
 		fmt.Fprintf(os.Stderr, "❌ Error writing file: %v\n", err)
 		os.Exit(1)
 	}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/blef"
+)
+
+var (
+	decryptOutputFile     string
+	decryptPassphraseFile string
+)
+
+var decryptCmd = &cobra.Command{
+	Use:   "decrypt [blef-file]",
+	Short: "Decrypt the entry fields encrypted by blef-cli encrypt",
+	Long: `Decrypt the envelopes written by "blef-cli encrypt" and restore the
+encrypted fields of every entry.
+
+The passphrase is read from --passphrase-file, from the BLEF_PASSPHRASE
+environment variable, or asked for. Nothing is written if any envelope
+cannot be decrypted. By default the BLEF file is updated in place.
+
+Examples:
+  blef-cli decrypt library.blef.json
+  blef-cli decrypt backup.blef.json -o library.blef.json`,
+	Args: cobra.ExactArgs(1),
+	Run:  runDecrypt,
+}
+
+func init() {
+	rootCmd.AddCommand(decryptCmd)
+
+	decryptCmd.Flags().StringVarP(&decryptOutputFile, "output", "o", "", "Output file path (default: update the BLEF file in place)")
+	decryptCmd.Flags().StringVar(&decryptPassphraseFile, "passphrase-file", "", "Read the passphrase from a file")
+}
+
+func runDecrypt(cmd *cobra.Command, args []string) {
+	inputFile := args[0]
+	if decryptOutputFile == "" {
+		decryptOutputFile = inputFile
+	}
+
+	doc, err := blef.LoadFromFile(inputFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error reading BLEF file: %v\n", err)
+		os.Exit(1)
+	}
+	warnUnknownFields(inputFile, doc)
+	if !blef.IsEncrypted(doc) {
+		fmt.Printf("✅ %s has no encrypted fields\n", inputFile)
+		return
+	}
+
+	passphrase := readPassphrase(decryptPassphraseFile, false)
+
+	fmt.Printf("🔓 Decrypting %s\n", inputFile)
+	count, err := blef.DecryptDocument(doc, passphrase)
+	if err != nil {
+		if errors.Is(err, blef.ErrWrongPassphrase) {
+			fmt.Fprintln(os.Stderr, "❌ Wrong passphrase, or the file was modified")
+		} else {
+			fmt.Fprintf(os.Stderr, "❌ Decryption failed: %v\n", err)
+		}
+		os.Exit(1)
+	}
+
+	fmt.Printf("💾 Writing to %s...\n", decryptOutputFile)
+	if err := doc.SaveToFile(decryptOutputFile); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error writing file: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✅ Decrypted %d entries\n", count)
+}
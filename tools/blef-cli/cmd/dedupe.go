@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/spf13/cobra"
+	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/blef"
+)
+
+var (
+	dedupeOutputFile    string
+	dedupeMinConfidence float64
+	dedupeAuto          bool
+	dedupeThreshold     float64
+	dedupeDryRun        bool
+)
+
+var dedupeCmd = &cobra.Command{
+	Use:   "dedupe [blef-file]",
+	Short: "Find and merge duplicate books",
+	Long: `Find books that are the same work under different IDs and merge them.
+
+Duplicates appear when books without an ISBN-13 get random IDs on import, or
+when several editions of a work were added. Books are clustered when they
+share an identifier (ISBNs in either form, ASIN, Open Library, Wikidata,
+Goodreads...) or when their titles and authors are similar. Titles are
+compared without case, diacritics, punctuation, leading articles and
+bracketed suffixes such as "(Harry Potter, #4)".
+
+Each cluster has a confidence from 0 to 1. By default every cluster is
+reviewed interactively: pick the book to keep, or skip the cluster. With
+--auto, clusters at or above --threshold are merged without asking and the
+others are listed.
+
+Merged books keep the fields of the chosen book, filled with the fields of
+its duplicates, and list the IDs of the duplicates in their metadata under
+"duplicate_ids". Entries are merged like in "blef-cli merge".
+
+By default the BLEF file is updated in place.
+
+Examples:
+  blef-cli dedupe library.blef.json
+  blef-cli dedupe library.blef.json --dry-run
+  blef-cli dedupe library.blef.json --auto --threshold 0.9 -o deduped.blef.json`,
+	Args: cobra.ExactArgs(1),
+	Run:  runDedupe,
+}
+
+func init() {
+	rootCmd.AddCommand(dedupeCmd)
+
+	dedupeCmd.Flags().StringVarP(&dedupeOutputFile, "output", "o", "", "Output file path (default: update the BLEF file in place)")
+	dedupeCmd.Flags().Float64Var(&dedupeMinConfidence, "min-confidence", blef.DefaultDuplicateOptions().MinConfidence, "Lowest confidence for books to be reported as duplicates")
+	dedupeCmd.Flags().BoolVar(&dedupeAuto, "auto", false, "Merge clusters at or above --threshold without asking")
+	dedupeCmd.Flags().Float64Var(&dedupeThreshold, "threshold", 0.95, "Lowest confidence for --auto to merge a cluster")
+	dedupeCmd.Flags().BoolVar(&dedupeDryRun, "dry-run", false, "List duplicate clusters without merging anything")
+}
+
+func runDedupe(cmd *cobra.Command, args []string) {
+	inputFile := args[0]
+	if dedupeOutputFile == "" {
+		dedupeOutputFile = inputFile
+	}
+
+	doc, err := blef.LoadFromFile(inputFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error reading BLEF file: %v\n", err)
+		os.Exit(1)
+	}
+	warnUnknownFields(inputFile, doc)
+
+	fmt.Printf("🔍 Looking for duplicates among %d books...\n", len(doc.Books))
+
+	clusters := blef.FindDuplicates(doc, blef.DuplicateOptions{MinConfidence: dedupeMinConfidence})
+	if len(clusters) == 0 {
+		fmt.Println("✅ No duplicates found")
+		return
+	}
+	fmt.Printf("📚 %d cluster(s) of duplicates found\n", len(clusters))
+
+	var selected []blef.DuplicateCluster
+	for i, cluster := range clusters {
+		fmt.Printf("\n[%d/%d] Confidence %.0f%%\n", i+1, len(clusters), cluster.Confidence*100)
+		printDuplicateCluster(doc, cluster)
+
+		switch {
+		case dedupeDryRun:
+			continue
+		case dedupeAuto:
+			if cluster.Confidence >= dedupeThreshold {
+				fmt.Println("  🔀 Merging")
+				selected = append(selected, cluster)
+			} else {
+				fmt.Println("  ⏭️  Below threshold, skipped")
+			}
+			continue
+		}
+
+		keep, stop, err := askDuplicateKeeper(doc, cluster)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Review cancelled: %v\n", err)
+			os.Exit(1)
+		}
+		if stop {
+			break
+		}
+		if keep != "" {
+			selected = append(selected, reorderCluster(cluster, keep))
+		}
+	}
+
+	if dedupeDryRun {
+		fmt.Println("\n✅ Dry run, nothing written")
+		return
+	}
+	if len(selected) == 0 {
+		fmt.Println("\n✅ Nothing merged")
+		return
+	}
+
+	// Book fields come from the book chosen to be kept
+	opts := blef.DefaultMergeOptions()
+	opts.Fields[blef.MergeFieldBook] = blef.PreferLeft
+
+	result, err := blef.MergeDuplicates(doc, selected, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error merging duplicates: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(result.Conflicts) > 0 {
+		fmt.Printf("\n⚠️  %d conflict(s) resolved:\n", len(result.Conflicts))
+		for _, conflict := range result.Conflicts {
+			fmt.Printf("  • %s\n", conflict)
+		}
+	}
+
+	merged := result.Document
+	if errs := blef.ValidateDocument(merged); len(errs) > 0 {
+		fmt.Printf("\n⚠️  Deduplicated document has %d validation error(s):\n", len(errs))
+		for _, err := range errs {
+			fmt.Printf("  • %s\n", err)
+		}
+	}
+
+	fmt.Printf("\n💾 Writing to %s...\n", dedupeOutputFile)
+	if err := merged.SaveToFile(dedupeOutputFile); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error writing file: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ Merged %d duplicate book(s) in %d cluster(s), %d books left\n",
+		result.BooksMatched, len(selected), len(merged.Books))
+}
+
+func printDuplicateCluster(doc *blef.BLEFDocument, cluster blef.DuplicateCluster) {
+	for _, id := range cluster.BookIDs {
+		fmt.Printf("  📖 %s\n", describeDuplicate(doc, id))
+	}
+	for _, link := range cluster.Links {
+		fmt.Printf("     %s ↔ %s: %s (%.0f%%)\n", link.Left, link.Right, link.Reason, link.Score*100)
+	}
+}
+
+// describeDuplicate summarizes a book to tell duplicates apart
+func describeDuplicate(doc *blef.BLEFDocument, id string) string {
+	book := doc.GetBookByID(id)
+	var authors []string
+	for _, author := range book.Authors {
+		authors = append(authors, author.Name)
+	}
+
+	description := fmt.Sprintf("%s (%s)", book.Title, id)
+	if len(authors) > 0 {
+		description += " by " + strings.Join(authors, ", ")
+	}
+	if book.Edition != nil && book.Edition.Publisher != "" {
+		description += ", " + book.Edition.Publisher
+	}
+	if book.Edition != nil && !book.Edition.PublishedDate.IsZero() {
+		description += ", " + book.Edition.PublishedDate.String()
+	}
+	if entries := doc.GetEntriesForBook(id); len(entries) > 0 {
+		description += fmt.Sprintf(" [%s]", entries[0].UserData.Status)
+	}
+	return description
+}
+
+// askDuplicateKeeper asks which book of a cluster to keep. It returns an
+// empty ID when the cluster is skipped, and stop when the review is over.
+func askDuplicateKeeper(doc *blef.BLEFDocument, cluster blef.DuplicateCluster) (keep string, stop bool, err error) {
+	const skip, quit = "Skip this cluster", "Stop reviewing"
+
+	options := make([]string, 0, len(cluster.BookIDs)+2)
+	for _, id := range cluster.BookIDs {
+		options = append(options, "Keep "+describeDuplicate(doc, id))
+	}
+	options = append(options, skip, quit)
+
+	var answer int
+	prompt := &survey.Select{
+		Message: "Merge into:",
+		Options: options,
+	}
+	if err := survey.AskOne(prompt, &answer); err != nil {
+		return "", false, err
+	}
+
+	switch options[answer] {
+	case skip:
+		return "", false, nil
+	case quit:
+		return "", true, nil
+	default:
+		return cluster.BookIDs[answer], false, nil
+	}
+}
+
+// reorderCluster moves the book to keep to the front of the cluster
+func reorderCluster(cluster blef.DuplicateCluster, keep string) blef.DuplicateCluster {
+	ids := []string{keep}
+	for _, id := range cluster.BookIDs {
+		if id != keep {
+			ids = append(ids, id)
+		}
+	}
+	cluster.BookIDs = ids
+	return cluster
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/blef"
+)
+
+var (
+	diffFormat   string
+	diffExitCode bool
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff [old-file] [new-file]",
+	Short: "Show what changed between two BLEF files",
+	Long: `Compare two BLEF files and list their semantic differences.
+
+Books, collections and entries are matched by ID, so reordering or
+reformatting a file is not a change. The diff lists:
+- Books and collections added or removed
+- Metadata fields changed per book or collection
+- Status transitions and rating changes
+- Books added to or removed from collections
+- Loans started or returned
+- Other changes to entries (review, tags, read dates...)
+
+Formats:
+  text     - one block per book or collection (default)
+  json     - summary and typed change records
+  markdown - document suitable for a changelog or a pull request comment
+  patch    - BLEF patch turning the old file into the new one, for "blef-cli apply"
+
+Examples:
+  blef-cli diff last-month.blef.json library.blef.json
+  blef-cli diff old.blef.json new.blef.json --format markdown > CHANGES.md
+  blef-cli diff old.blef.json new.blef.json --format patch > changes.patch.json
+  blef-cli diff old.blef.json new.blef.json --exit-code`,
+	Args: cobra.ExactArgs(2),
+	Run:  runDiff,
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().StringVar(&diffFormat, "format", "text", "Output format (text, json, markdown, patch)")
+	diffCmd.Flags().BoolVar(&diffExitCode, "exit-code", false, "Exit with status 1 if the files differ")
+}
+
+func runDiff(cmd *cobra.Command, args []string) {
+	switch diffFormat {
+	case "text", "json", "markdown", "patch":
+	default:
+		fmt.Fprintf(os.Stderr, "❌ Unknown diff format: %s (supported: text, json, markdown, patch)\n", diffFormat)
+		os.Exit(1)
+	}
+
+	oldDoc, err := blef.LoadFromFile(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error reading %s: %v\n", args[0], err)
+		os.Exit(1)
+	}
+	warnUnknownFields(args[0], oldDoc)
+	newDoc, err := blef.LoadFromFile(args[1])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error reading %s: %v\n", args[1], err)
+		os.Exit(1)
+	}
+	warnUnknownFields(args[1], newDoc)
+
+	diff := blef.DiffDocuments(oldDoc, newDoc)
+
+	switch diffFormat {
+	case "patch":
+		var patch *blef.Patch
+		if patch, err = blef.MakePatch(oldDoc, newDoc); err != nil {
+			break
+		}
+		var data []byte
+		if data, err = patch.ToJSON(); err == nil {
+			_, err = fmt.Println(string(data))
+		}
+	case "json":
+		err = diff.WriteJSON(os.Stdout)
+	case "markdown":
+		err = diff.WriteMarkdown(os.Stdout)
+	default:
+		fmt.Printf("🔍 Comparing %s → %s\n\n", args[0], args[1])
+		if diff.Empty() {
+			fmt.Println("✅ No changes")
+			break
+		}
+		err = diff.WriteText(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error writing diff: %v\n", err)
+		os.Exit(1)
+	}
+
+	if diffExitCode && !diff.Empty() {
+		os.Exit(1)
+	}
+}
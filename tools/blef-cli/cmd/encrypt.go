@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/spf13/cobra"
+	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/blef"
+)
+
+// passphraseEnv is the environment variable read for the passphrase
+const passphraseEnv = "BLEF_PASSPHRASE"
+
+var (
+	encryptFields         []string
+	encryptOutputFile     string
+	encryptPassphraseFile string
+)
+
+var encryptCmd = &cobra.Command{
+	Use:   "encrypt [blef-file]",
+	Short: "Encrypt sensitive entry fields with a passphrase",
+	Long: `Encrypt selected fields of every entry with a passphrase.
+
+The rest of the document stays readable: only the chosen fields are removed
+from each entry and stored, encrypted with AES-256-GCM, in an envelope under
+the entry's "metadata.encrypted". The key is derived from the passphrase with
+PBKDF2-SHA256. Use "blef-cli decrypt" to restore the fields.
+
+Fields are paths relative to the entry. The default fields are
+user_data.private_notes and ownership.loaned; user_data.review is another
+common choice. Only optional fields can be encrypted: book_id,
+collection_ids, user_data, user_data.status and metadata are refused.
+
+The passphrase is read from --passphrase-file, from the BLEF_PASSPHRASE
+environment variable, or asked for. By default the BLEF file is updated in
+place.
+
+Examples:
+  blef-cli encrypt library.blef.json
+  blef-cli encrypt library.blef.json --field user_data.private_notes --field user_data.review
+  blef-cli encrypt library.blef.json --passphrase-file ~/.blef-passphrase -o backup.blef.json`,
+	Args: cobra.ExactArgs(1),
+	Run:  runEncrypt,
+}
+
+func init() {
+	rootCmd.AddCommand(encryptCmd)
+
+	encryptCmd.Flags().StringSliceVar(&encryptFields, "field", blef.DefaultEncryptedFields, "Entry fields to encrypt (repeatable or comma-separated)")
+	encryptCmd.Flags().StringVarP(&encryptOutputFile, "output", "o", "", "Output file path (default: update the BLEF file in place)")
+	encryptCmd.Flags().StringVar(&encryptPassphraseFile, "passphrase-file", "", "Read the passphrase from a file")
+}
+
+func runEncrypt(cmd *cobra.Command, args []string) {
+	inputFile := args[0]
+	if encryptOutputFile == "" {
+		encryptOutputFile = inputFile
+	}
+
+	doc, err := blef.LoadFromFile(inputFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error reading BLEF file: %v\n", err)
+		os.Exit(1)
+	}
+	warnUnknownFields(inputFile, doc)
+	if blef.IsEncrypted(doc) {
+		fmt.Fprintf(os.Stderr, "❌ %s is already encrypted\n", inputFile)
+		os.Exit(1)
+	}
+
+	passphrase := readPassphrase(encryptPassphraseFile, true)
+
+	fmt.Printf("🔐 Encrypting %s in %s\n", strings.Join(encryptFields, ", "), inputFile)
+	count, err := blef.EncryptDocument(doc, passphrase, blef.EncryptionOptions{Fields: encryptFields})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Encryption failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("💾 Writing to %s...\n", encryptOutputFile)
+	if err := doc.SaveToFile(encryptOutputFile); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error writing file: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✅ Encrypted %d of %d entries\n", count, len(doc.Entries))
+}
+
+// readPassphrase returns the passphrase from a file, from the environment or
+// from a prompt, asked twice when confirm is set. It exits on error.
+func readPassphrase(file string, confirm bool) string {
+	var passphrase string
+	switch {
+	case file != "":
+		data, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error reading passphrase file: %v\n", err)
+			os.Exit(1)
+		}
+		passphrase = strings.TrimRight(string(data), "\r\n")
+	case os.Getenv(passphraseEnv) != "":
+		passphrase = os.Getenv(passphraseEnv)
+	default:
+		if err := survey.AskOne(&survey.Password{Message: "Passphrase:"}, &passphrase); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			os.Exit(1)
+		}
+		if confirm {
+			var again string
+			if err := survey.AskOne(&survey.Password{Message: "Confirm passphrase:"}, &again); err != nil {
+				fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+				os.Exit(1)
+			}
+			if again != passphrase {
+				fmt.Fprintln(os.Stderr, "❌ Passphrases do not match")
+				os.Exit(1)
+			}
+		}
+	}
+
+	if passphrase == "" {
+		fmt.Fprintln(os.Stderr, "❌ Passphrase is empty")
+		os.Exit(1)
+	}
+	return passphrase
+}
@@ -14,6 +14,8 @@ import (
 var (
 	exportFormat     string
 	exportOutputFile string
+	exportStream     bool
+	exportRedact     []string
 )
 
 var exportCmd = &cobra.Command{
@@ -27,10 +29,17 @@ Supported export formats:
 
 The exported CSV can be imported back into the respective platform.
 
+Use --stream for very large files: the BLEF file is read in two passes
+without loading the whole document.
+
+Use --redact to remove sensitive data first, with the profiles of
+"blef-cli redact". The pseudonym key is read from BLEF_PSEUDONYM_KEY.
+
 Examples:
   blef-cli export library.blef.json -f goodreads
   blef-cli export library.blef.json -f babelio -o export.csv
-  blef-cli export library.blef.json -f goodreads -o goodreads_import.csv`,
+  blef-cli export library.blef.json -f goodreads -o goodreads_import.csv
+  blef-cli export library.blef.json -f babelio --redact share`,
 	Args: cobra.ExactArgs(1),
 	Run:  runExport,
 }
@@ -40,6 +49,8 @@ func init() {
 
 	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", "", "Export format (goodreads, babelio) [required]")
 	exportCmd.Flags().StringVarP(&exportOutputFile, "output", "o", "", "Output CSV file path (default: input-format.csv)")
+	exportCmd.Flags().BoolVar(&exportStream, "stream", false, "Export without loading the whole document (for very large files)")
+	exportCmd.Flags().StringSliceVar(&exportRedact, "redact", nil, "Redaction profiles to apply before exporting (see blef-cli redact)")
 	_ = exportCmd.MarkFlagRequired("format")
 }
 
@@ -57,15 +68,6 @@ func runExport(cmd *cobra.Command, args []string) {
 	fmt.Printf("Output: %s\n", exportOutputFile)
 	fmt.Printf("Format: %s\n\n", exportFormat)
 
-	// Load BLEF document
-	fmt.Println("📖 Reading BLEF file...")
-	doc, err := blef.LoadFromFile(inputFile)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "❌ Error reading BLEF file: %v\n", err)
-		os.Exit(1)
-	}
-	fmt.Printf("✅ Loaded %d books, %d entries\n\n", len(doc.Books), len(doc.Entries))
-
 	// Get export format
 	format := csv.DefaultRegistry.GetByName(strings.ToLower(exportFormat))
 	if format == nil {
@@ -81,19 +83,37 @@ func runExport(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
+	if exportStream {
+		if len(exportRedact) > 0 {
+			fmt.Fprintln(os.Stderr, "❌ --redact cannot be used with --stream")
+			os.Exit(1)
+		}
+		runExportStream(inputFile, format)
+		return
+	}
+
+	// Load BLEF document
+	fmt.Println("📖 Reading BLEF file...")
+	doc, err := blef.LoadFromFile(inputFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error reading BLEF file: %v\n", err)
+		os.Exit(1)
+	}
+	warnUnknownFields(inputFile, doc)
+	fmt.Printf("✅ Loaded %d books, %d entries\n\n", len(doc.Books), len(doc.Entries))
+
+	if len(exportRedact) > 0 {
+		applyRedactions(doc, exportRedact, "", os.Stdout)
+		fmt.Println()
+	}
+
 	// Create exporter
 	exporter := csv.NewExporter(doc, format)
 
 	// Show export stats
 	stats := exporter.GetExportStats()
 	fmt.Println("📊 Export preview:")
-	fmt.Printf("  Total books:   %d\n", stats.TotalBooks)
-	fmt.Printf("  Total entries: %d\n", stats.TotalEntries)
-	fmt.Printf("  Will export:   %d rows\n", stats.Exported)
-	if stats.Skipped > 0 {
-		fmt.Printf("  ⚠️  Skipped:    %d entries (missing book data)\n", stats.Skipped)
-	}
-	fmt.Println()
+	printExportStats(stats)
 
 	// Export to file
 	fmt.Printf("💾 Writing to %s...\n", exportOutputFile)
@@ -106,3 +126,27 @@ func runExport(cmd *cobra.Command, args []string) {
 	fmt.Printf("\nYour CSV file is ready to import into %s.\n", format.Description())
 }
 
+func runExportStream(inputFile string, format csv.CSVFormat) {
+	fmt.Printf("💾 Streaming to %s...\n", exportOutputFile)
+	stats, err := csv.StreamExport(inputFile, exportOutputFile, format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Export failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("\n📊 Export summary:")
+	printExportStats(stats)
+
+	fmt.Println("✅ Export complete!")
+	fmt.Printf("\nYour CSV file is ready to import into %s.\n", format.Description())
+}
+
+func printExportStats(stats csv.ExportStats) {
+	fmt.Printf("  Total books:   %d\n", stats.TotalBooks)
+	fmt.Printf("  Total entries: %d\n", stats.TotalEntries)
+	fmt.Printf("  Will export:   %d rows\n", stats.Exported)
+	if stats.Skipped > 0 {
+		fmt.Printf("  ⚠️  Skipped:    %d entries (missing book data)\n", stats.Skipped)
+	}
+	fmt.Println()
+}
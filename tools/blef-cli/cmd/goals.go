@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/blef"
+	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/stats"
+)
+
+var (
+	goalsFile       string
+	goalsAdd        []string
+	goalsClear      bool
+	goalsDate       string
+	goalsFormat     string
+	goalsOutputFile string
+)
+
+var goalsCmd = &cobra.Command{
+	Use:   "goals [blef-file]",
+	Short: "Track reading goals",
+	Long: `Show the progress of yearly or monthly reading goals.
+
+Goals are read from the "goals" key of the user metadata, or from a sidecar
+file given with --goals-file ({"goals": [...]}). --add stores a goal in the
+user metadata, written as:
+
+  <target> books|pages [yearly|monthly|YYYY|YYYY-MM] [language=<code>] [subject=<name>]
+
+e.g. "50 books 2025", "1000 pages monthly" or "12 books language=fr".
+Without a year or month, a goal applies to the current one. Subjects of
+several words are written with underscores: "subject=Science_Fiction".
+
+Progress is measured with the books finished during the period of the goal.
+For each goal, the percentage complete, the day the target is reached at the
+current pace and the pace needed to reach it in time are shown.
+
+Examples:
+  blef-cli goals library.blef.json
+  blef-cli goals library.blef.json --add "52 books 2025" --add "5 books 2025 language=fr"
+  blef-cli goals library.blef.json --goals-file goals.json --format json`,
+	Args: cobra.ExactArgs(1),
+	Run:  runGoals,
+}
+
+func init() {
+	rootCmd.AddCommand(goalsCmd)
+
+	goalsCmd.Flags().StringVar(&goalsFile, "goals-file", "", "Read the goals from a sidecar file instead of the user metadata")
+	goalsCmd.Flags().StringArrayVar(&goalsAdd, "add", nil, "Add a goal to the user metadata (repeatable)")
+	goalsCmd.Flags().BoolVar(&goalsClear, "clear", false, "Remove the goals from the user metadata")
+	goalsCmd.Flags().StringVar(&goalsDate, "date", "", "Measure progress at this date, YYYY-MM-DD (default: today)")
+	goalsCmd.Flags().StringVar(&goalsFormat, "format", "table", "Output format (table, json)")
+	goalsCmd.Flags().StringVarP(&goalsOutputFile, "output", "o", "", "With --add or --clear, output file path (default: update the BLEF file in place)")
+}
+
+func runGoals(cmd *cobra.Command, args []string) {
+	inputFile := args[0]
+	if goalsFormat != "table" && goalsFormat != "json" {
+		fmt.Fprintf(os.Stderr, "❌ Unknown goals format: %s (supported: table, json)\n", goalsFormat)
+		os.Exit(1)
+	}
+
+	now := time.Now()
+	if goalsDate != "" {
+		var err error
+		if now, err = time.Parse("2006-01-02", goalsDate); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Invalid date %q: expected YYYY-MM-DD\n", goalsDate)
+			os.Exit(1)
+		}
+	}
+
+	doc, err := blef.LoadFromFile(inputFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error reading BLEF file: %v\n", err)
+		os.Exit(1)
+	}
+	warnUnknownFields(inputFile, doc)
+
+	var goals []stats.Goal
+	if goalsFile != "" {
+		goals, err = stats.LoadGoalsFile(goalsFile)
+	} else {
+		goals, err = stats.LoadGoals(doc)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	if goalsClear || len(goalsAdd) > 0 {
+		if goalsFile != "" {
+			fmt.Fprintln(os.Stderr, "❌ --add and --clear cannot be used with --goals-file")
+			os.Exit(1)
+		}
+		goals = updateGoals(doc, goals, inputFile)
+	}
+
+	if len(goals) == 0 {
+		fmt.Println("🎯 No reading goals yet. Add one with --add, e.g. --add \"50 books\"")
+		return
+	}
+
+	progress := stats.EvaluateGoals(doc, goals, now)
+	if goalsFormat == "json" {
+		data, err := json.MarshalIndent(progress, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error writing goals: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	fmt.Printf("🎯 Reading goals on %s\n", now.Format("2006-01-02"))
+	for _, p := range progress {
+		printGoalProgress(p)
+	}
+}
+
+// updateGoals applies --clear and --add to the goals of the user metadata
+// and writes the document
+func updateGoals(doc *blef.BLEFDocument, goals []stats.Goal, inputFile string) []stats.Goal {
+	if goalsClear {
+		goals = nil
+	}
+	for _, value := range goalsAdd {
+		goal, err := stats.ParseGoal(value)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			os.Exit(1)
+		}
+		goals = append(goals, goal)
+		fmt.Printf("➕ Added goal: %s\n", goal)
+	}
+	stats.SaveGoals(doc, goals)
+
+	output := goalsOutputFile
+	if output == "" {
+		output = inputFile
+	}
+	if err := doc.SaveToFile(output); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error writing file: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("💾 Goals saved to %s\n\n", output)
+	return goals
+}
+
+func printGoalProgress(p stats.Progress) {
+	name := p.Goal.Name
+	if name == "" {
+		name = p.Goal.String()
+	}
+	// Goals for the current year or month show which one it is
+	if p.Goal.Year == 0 {
+		if p.Goal.Period == stats.Monthly {
+			name += p.Start.Format(" (January 2006)")
+		} else {
+			name += p.Start.Format(" (2006)")
+		}
+	}
+
+	bar := statsBar(int(p.Percent), 100)
+	bar += strings.Repeat("░", 20-utf8.RuneCountInString(bar))
+
+	fmt.Printf("\n%s %s\n", goalStatusEmoji(p.Status), name)
+	fmt.Printf("  %d / %d %s  %s %.0f%%\n", p.Done, p.Goal.Target, p.Goal.Metric, bar, p.Percent)
+	fmt.Printf("  Status: %s\n", p.Status)
+	if p.Projected != nil {
+		fmt.Printf("  Projected finish: %s\n", p.Projected.Format("2006-01-02"))
+	}
+	if p.PaceNeeded > 0 {
+		if p.Goal.Metric == stats.MetricBooks {
+			fmt.Printf("  Pace needed: %.1f books per week\n", p.PaceNeeded*7)
+		} else {
+			fmt.Printf("  Pace needed: %.0f pages per day\n", p.PaceNeeded)
+		}
+	}
+}
+
+func goalStatusEmoji(status string) string {
+	switch status {
+	case stats.GoalCompleted:
+		return "🏆"
+	case stats.GoalOnTrack:
+		return "✅"
+	case stats.GoalBehind:
+		return "⚠️ "
+	case stats.GoalMissed:
+		return "❌"
+	default:
+		return "⏳"
+	}
+}
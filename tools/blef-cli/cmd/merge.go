@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/blef"
+)
+
+var (
+	mergeOutputFile string
+	mergeStrategy   string
+	mergeFields     []string
+	mergeBase       string
+	mergeMarkers    bool
+)
+
+var mergeCmd = &cobra.Command{
+	Use:   "merge [blef-file] [blef-file]...",
+	Short: "Merge several BLEF files into one",
+	Long: `Merge two or more BLEF files into a single library.
+
+Files are merged from left to right. Books are matched by ID, ISBN-13, ISBN-10
+(in either form) and then by ASIN, Open Library, Wikidata or Goodreads ID.
+Collections are united by ID. When both files have an entry for the same book,
+the entries are reconciled field by field and every conflict is reported.
+
+Strategies:
+  prefer-newest - keep the value of the entry with the latest added_at
+  prefer-left   - keep the value of the leftmost file
+  prefer-right  - keep the value of the rightmost file
+  max           - keep the highest rating (rating only)
+  union         - combine both lists (tags, read_dates, collection_ids only)
+
+--strategy sets the default for every field. --field overrides a single field
+and can be repeated. Fields: status, rating, review, private_notes, favorite,
+ownership, tags, read_dates, collection_ids, and book and collection for the
+metadata of matched books and collections. By default ratings use max and
+lists use union. A field left empty in one file (no rating, no review, not a
+favorite) never erases the value set in the other. Fields unknown to this
+version are united, one set differently in both files being a conflict.
+
+With --base, the two files are merged against their common ancestor, e.g.
+the export both devices started from. Changes made on one side only are kept,
+including deletions; tags, collection IDs and subjects are merged as sets.
+Changes made differently on both sides are conflicts: the first file's value
+is kept, every conflict is listed and the command exits with status 1. Use
+--markers to also record conflicts in the metadata of each element, under
+"merge_conflicts". --strategy and --field do not apply to three-way merges.
+
+Examples:
+  blef-cli merge goodreads.blef.json babelio.blef.json
+  blef-cli merge a.blef.json b.blef.json c.blef.json -o library.blef.json
+  blef-cli merge a.blef.json b.blef.json --strategy prefer-left --field rating=prefer-right
+  blef-cli merge --base last-sync.blef.json laptop.blef.json phone.blef.json --markers`,
+	Args: cobra.MinimumNArgs(2),
+	Run:  runMerge,
+}
+
+func init() {
+	rootCmd.AddCommand(mergeCmd)
+
+	mergeCmd.Flags().StringVarP(&mergeOutputFile, "output", "o", "merged.blef.json", "Output file path")
+	mergeCmd.Flags().StringVar(&mergeStrategy, "strategy", string(blef.PreferNewest), "Default strategy (prefer-newest, prefer-left, prefer-right)")
+	mergeCmd.Flags().StringArrayVar(&mergeFields, "field", nil, "Strategy for a single field, as field=strategy (repeatable)")
+	mergeCmd.Flags().StringVar(&mergeBase, "base", "", "Common ancestor of the two files, for a three-way merge")
+	mergeCmd.Flags().BoolVar(&mergeMarkers, "markers", false, "Record three-way conflicts in element metadata")
+}
+
+func runMerge(cmd *cobra.Command, args []string) {
+	if mergeBase != "" {
+		runThreeWayMerge(args)
+		return
+	}
+
+	opts := blef.DefaultMergeOptions()
+	opts.Default = blef.MergeStrategy(mergeStrategy)
+	for _, field := range mergeFields {
+		name, strategy, ok := strings.Cut(field, "=")
+		if !ok {
+			fmt.Fprintf(os.Stderr, "❌ Invalid --field %q, expected field=strategy\n", field)
+			os.Exit(1)
+		}
+		opts.Fields[strings.TrimSpace(name)] = blef.MergeStrategy(strings.TrimSpace(strategy))
+	}
+	if err := opts.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("🔀 Merging %d BLEF files\n", len(args))
+
+	docs := make([]*blef.BLEFDocument, len(args))
+	for i, filename := range args {
+		doc, err := blef.LoadFromFile(filename)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error reading %s: %v\n", filename, err)
+			os.Exit(1)
+		}
+		warnUnknownFields(filename, doc)
+		docs[i] = doc
+	}
+	fmt.Printf("📖 %s: %d books\n", args[0], len(docs[0].Books))
+
+	results, err := blef.MergeAll(docs, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error merging %s: %v\n", args[len(results)+1], err)
+		os.Exit(1)
+	}
+
+	var conflicts []blef.MergeConflict
+	for i, result := range results {
+		fmt.Printf("📖 %s: %d books (%d matched, %d added), %d collections added, %d entries merged, %d conflicts\n",
+			args[i+1], len(docs[i+1].Books), result.BooksMatched, result.BooksAdded, result.CollectionsAdded,
+			result.EntriesMerged, len(result.Conflicts))
+		conflicts = append(conflicts, result.Conflicts...)
+	}
+	merged := results[len(results)-1].Document
+
+	if len(conflicts) > 0 {
+		fmt.Printf("\n⚠️  %d conflict(s) resolved:\n", len(conflicts))
+		for _, conflict := range conflicts {
+			fmt.Printf("  • %s\n", conflict)
+		}
+	}
+
+	if errs := blef.ValidateDocument(merged); len(errs) > 0 {
+		fmt.Printf("\n⚠️  Merged document has %d validation error(s):\n", len(errs))
+		for _, err := range errs {
+			fmt.Printf("  • %s\n", err)
+		}
+	}
+
+	fmt.Printf("\n💾 Writing to %s...\n", mergeOutputFile)
+	if err := merged.SaveToFile(mergeOutputFile); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error writing file: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ Merge complete! %d books, %d collections, %d entries\n",
+		len(merged.Books), len(merged.Collections), len(merged.Entries))
+}
+
+func runThreeWayMerge(args []string) {
+	if len(args) != 2 {
+		fmt.Fprintln(os.Stderr, "❌ A three-way merge takes exactly two files besides --base")
+		os.Exit(1)
+	}
+
+	var docs []*blef.BLEFDocument
+	for _, filename := range []string{mergeBase, args[0], args[1]} {
+		doc, err := blef.LoadFromFile(filename)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error reading %s: %v\n", filename, err)
+			os.Exit(1)
+		}
+		warnUnknownFields(filename, doc)
+		docs = append(docs, doc)
+	}
+
+	fmt.Printf("🔀 Three-way merge of %s and %s (base: %s)\n", args[0], args[1], mergeBase)
+
+	result, err := blef.ThreeWayMerge(docs[0], docs[1], docs[2], blef.ThreeWayOptions{ConflictMarkers: mergeMarkers})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error merging: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("📖 %d change(s) merged from %s\n", result.Merged, args[1])
+
+	merged := result.Document
+	if errs := blef.ValidateDocument(merged); len(errs) > 0 {
+		fmt.Printf("\n⚠️  Merged document has %d validation error(s):\n", len(errs))
+		for _, err := range errs {
+			fmt.Printf("  • %s\n", err)
+		}
+	}
+
+	fmt.Printf("\n💾 Writing to %s...\n", mergeOutputFile)
+	if err := merged.SaveToFile(mergeOutputFile); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error writing file: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(result.Conflicts) > 0 {
+		fmt.Printf("\n⚠️  %d conflict(s), kept the value of %s:\n", len(result.Conflicts), args[0])
+		for _, conflict := range result.Conflicts {
+			fmt.Printf("  • %s\n", conflict)
+		}
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ Merge complete! %d books, %d collections, %d entries\n",
+		len(merged.Books), len(merged.Collections), len(merged.Entries))
+}
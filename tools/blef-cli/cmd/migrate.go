@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/blef"
+)
+
+var (
+	migrateTo         string
+	migrateOutputFile string
+	migrateAllowLossy bool
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate [blef-file]",
+	Short: "Migrate a BLEF file to another spec version",
+	Long: `Migrate a BLEF file between versions of the BLEF specification.
+
+The document's version is read from its "version" field, then every upgrade
+or downgrade step between that version and the target is applied in turn.
+
+Steps that drop or alter data are reported. The migrated file is only written
+if no data was lost, unless --allow-lossy is given.
+
+Examples:
+  blef-cli migrate library.blef.json
+  blef-cli migrate library.blef.json --to 0.1.0 -o migrated.blef.json
+  blef-cli migrate library.blef.json --to 0.1.0 --allow-lossy`,
+	Args: cobra.ExactArgs(1),
+	Run:  runMigrate,
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+
+	migrateCmd.Flags().StringVar(&migrateTo, "to", blef.CurrentVersion, "Target BLEF version")
+	migrateCmd.Flags().StringVarP(&migrateOutputFile, "output", "o", "", "Output file path (default: input-v<version>.blef.json)")
+	migrateCmd.Flags().BoolVar(&migrateAllowLossy, "allow-lossy", false, "Write the result even if data was lost")
+}
+
+func runMigrate(cmd *cobra.Command, args []string) {
+	inputFile := args[0]
+
+	if migrateOutputFile == "" {
+		base := strings.TrimSuffix(strings.TrimSuffix(inputFile, ".json"), ".blef")
+		migrateOutputFile = fmt.Sprintf("%s-v%s.blef.json", base, migrateTo)
+	}
+
+	doc, err := blef.LoadFromFile(inputFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error reading BLEF file: %v\n", err)
+		os.Exit(1)
+	}
+	warnUnknownFields(inputFile, doc)
+
+	fmt.Printf("🔀 Migrating BLEF file: %s\n", inputFile)
+	fmt.Printf("Version: %s → %s\n\n", doc.Version, migrateTo)
+
+	if doc.Version == migrateTo {
+		fmt.Printf("✅ Document is already at version %s, nothing to do\n", migrateTo)
+		return
+	}
+
+	result, err := blef.DefaultMigrations.Migrate(doc, migrateTo)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Migration failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	for _, step := range result.Steps {
+		fmt.Printf("  • %s → %s", step.From, step.To)
+		if step.Description != "" {
+			fmt.Printf(": %s", step.Description)
+		}
+		fmt.Println()
+	}
+
+	if result.Lossy() {
+		fmt.Printf("\n⚠️  %d lossy change(s):\n", len(result.Loss))
+		for _, loss := range result.Loss {
+			fmt.Printf("  • %s\n", loss)
+		}
+		if !migrateAllowLossy {
+			fmt.Fprintln(os.Stderr, "\n❌ Migration would lose data, re-run with --allow-lossy to write it anyway")
+			os.Exit(1)
+		}
+	}
+
+	fmt.Printf("\n💾 Writing to %s...\n", migrateOutputFile)
+	if err := doc.SaveToFile(migrateOutputFile); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error writing file: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("✅ Migration complete!")
+}
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/blef"
+)
+
+var (
+	queryFormat     string
+	queryOutputFile string
+	queryListFields bool
+	queryRedact     []string
+)
+
+var queryCmd = &cobra.Command{
+	Use:   "query [blef-file] [query]",
+	Short: "Search a BLEF file with a query",
+	Long: `Search the books of a BLEF file, joined with their entry and collections.
+
+A query is a boolean expression over fields, optionally followed by an order
+and a limit. "select" picks the fields to show.
+
+Operators:
+  = != < <= > >=    compare values (strings are case-insensitive)
+  in                value is in a list field or literal, e.g. "Fantasy" in subjects
+  contains          list field holds a value, or string contains a substring
+  matches, ~        regular expression match (case-insensitive)
+  and, or, not      combine conditions, with parentheses for grouping
+
+A field on its own is true when it is set, e.g. "owned" or "not review".
+Use --list-fields to see every field. --redact removes sensitive data before
+the query runs, with the profiles of "blef-cli redact" (the pseudonym key is
+read from BLEF_PSEUDONYM_KEY).
+
+Formats:
+  table - aligned columns (default)
+  json  - array of objects with the selected fields
+  blef  - BLEF document with the matching books, their entries and collections
+
+Examples:
+  blef-cli query library.blef.json 'status = "to-read" and owned and "Fantasy" in subjects and pages < 400 order by pages'
+  blef-cli query library.blef.json 'select title, rating where rating >= 4 order by rating desc limit 10'
+  blef-cli query library.blef.json 'author ~ "tolkien"' --format blef -o tolkien.blef.json
+  blef-cli query --list-fields`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if queryListFields {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(2)(cmd, args)
+	},
+	Run: runQuery,
+}
+
+func init() {
+	rootCmd.AddCommand(queryCmd)
+
+	queryCmd.Flags().StringVar(&queryFormat, "format", "table", "Output format (table, json, blef)")
+	queryCmd.Flags().StringVarP(&queryOutputFile, "output", "o", "", "Output file path (default: stdout)")
+	queryCmd.Flags().StringSliceVar(&queryRedact, "redact", nil, "Redaction profiles to apply before querying (see blef-cli redact)")
+	queryCmd.Flags().BoolVar(&queryListFields, "list-fields", false, "List the fields available in queries")
+}
+
+func runQuery(cmd *cobra.Command, args []string) {
+	if queryListFields {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, field := range blef.QueryFields() {
+			fmt.Fprintf(w, "%s\t%s\n", field[0], field[1])
+		}
+		w.Flush()
+		return
+	}
+
+	switch queryFormat {
+	case "table", "json", "blef":
+	default:
+		fmt.Fprintf(os.Stderr, "❌ Unknown query format: %s (supported: table, json, blef)\n", queryFormat)
+		os.Exit(1)
+	}
+
+	query, err := blef.ParseQuery(args[1])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	doc, err := blef.LoadFromFile(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error reading %s: %v\n", args[0], err)
+		os.Exit(1)
+	}
+	warnUnknownFields(args[0], doc)
+
+	if len(queryRedact) > 0 {
+		applyRedactions(doc, queryRedact, "", os.Stderr)
+	}
+
+	records := query.Run(doc)
+
+	out := os.Stdout
+	if queryOutputFile != "" {
+		file, err := os.Create(queryOutputFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error creating output file: %v\n", err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	switch queryFormat {
+	case "table":
+		fields := query.Projection()
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, strings.ToUpper(strings.Join(fields, "\t")))
+		for i := range records {
+			cells := make([]string, len(fields))
+			for j, field := range fields {
+				cells[j] = formatQueryCell(records[i].Value(field))
+			}
+			fmt.Fprintln(w, strings.Join(cells, "\t"))
+		}
+		err = w.Flush()
+		if err == nil {
+			fmt.Fprintf(os.Stderr, "\n📚 %d book(s) found\n", len(records))
+		}
+
+	case "json":
+		rows := make([]map[string]interface{}, len(records))
+		for i := range records {
+			rows[i] = query.Project(&records[i])
+		}
+		var data []byte
+		if data, err = json.MarshalIndent(rows, "", "  "); err == nil {
+			_, err = fmt.Fprintln(out, string(data))
+		}
+
+	case "blef":
+		var data []byte
+		if data, err = blef.FilterDocument(doc, records).ToJSON(); err == nil {
+			_, err = fmt.Fprintln(out, string(data))
+		}
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error writing query results: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// formatQueryCell renders a query value for a table cell
+func formatQueryCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []string:
+		return strings.Join(v, ", ")
+	case float64:
+		return fmt.Sprintf("%g", v)
+	case bool:
+		if v {
+			return "yes"
+		}
+		return "no"
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/blef"
+)
+
+// pseudonymKeyEnv is the environment variable read for the pseudonym key
+const pseudonymKeyEnv = "BLEF_PSEUDONYM_KEY"
+
+var (
+	redactProfiles         []string
+	redactOutputFile       string
+	redactPseudonymKeyFile string
+	redactVerbose          bool
+	redactList             bool
+)
+
+var redactCmd = &cobra.Command{
+	Use:   "redact [blef-file]",
+	Short: "Remove sensitive data from a BLEF file before sharing it",
+	Long: `Remove or mask sensitive data from a BLEF file with named profiles.
+
+Profiles:
+  private      - remove private notes, loan borrowers and loan notes, the
+                 user's email and metadata
+  public       - drop collections that are not public, and the entries and
+                 books found only in them; fails if no collection is public
+  pseudonymize - replace the user ID by a pseudonym made with a secret key
+                 and remove the user's name
+  share        - private, public and pseudonymize together
+
+Several profiles can be given, they are applied in order. The same profiles
+are available through --redact on the export and query commands.
+
+The pseudonym key is read from --pseudonym-key-file or from the
+BLEF_PSEUDONYM_KEY environment variable. The same key always gives the same
+pseudonym, so exports of one user can be matched with each other; keep it
+secret, as anyone with the key can check a guess of the user ID. Without a
+key, a random one is used and pseudonyms change with every redaction.
+
+Examples:
+  blef-cli redact library.blef.json -p share -o public.blef.json
+  blef-cli redact library.blef.json -p private -p pseudonymize -v
+  blef-cli redact --list`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if redactList {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	Run: runRedact,
+}
+
+func init() {
+	rootCmd.AddCommand(redactCmd)
+
+	redactCmd.Flags().StringSliceVarP(&redactProfiles, "profile", "p", []string{blef.RedactShare}, "Redaction profiles to apply (repeatable or comma-separated)")
+	redactCmd.Flags().StringVarP(&redactOutputFile, "output", "o", "redacted.blef.json", "Output file path")
+	redactCmd.Flags().StringVar(&redactPseudonymKeyFile, "pseudonym-key-file", "", "Read the secret pseudonym key from a file")
+	redactCmd.Flags().BoolVarP(&redactVerbose, "verbose", "v", false, "List every redacted field")
+	redactCmd.Flags().BoolVar(&redactList, "list", false, "List the available profiles")
+}
+
+func runRedact(cmd *cobra.Command, args []string) {
+	if redactList {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, profile := range blef.DefaultRedactions.Profiles() {
+			fmt.Fprintf(w, "%s\t%s\n", profile.Name, profile.Description)
+		}
+		w.Flush()
+		return
+	}
+
+	doc, err := blef.LoadFromFile(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error reading BLEF file: %v\n", err)
+		os.Exit(1)
+	}
+	warnUnknownFields(args[0], doc)
+
+	fmt.Printf("🕶️  Redacting %s\n", args[0])
+	redactions := applyRedactions(doc, redactProfiles, redactPseudonymKeyFile, os.Stdout)
+	if redactVerbose {
+		for _, redaction := range redactions {
+			fmt.Printf("  • %s\n", redaction)
+		}
+	}
+
+	if errs := blef.ValidateDocument(doc); len(errs) > 0 {
+		fmt.Printf("\n⚠️  Redacted document has %d validation error(s):\n", len(errs))
+		for _, err := range errs {
+			fmt.Printf("  • %s\n", err)
+		}
+	}
+
+	fmt.Printf("\n💾 Writing to %s...\n", redactOutputFile)
+	if err := doc.SaveToFile(redactOutputFile); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error writing file: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ Redaction complete! %d books, %d collections, %d entries\n",
+		len(doc.Books), len(doc.Collections), len(doc.Entries))
+}
+
+// applyRedactions redacts a document with the named profiles and prints a
+// summary to out. The pseudonym key is read from keyFile or from the
+// environment. It exits on an unknown or failing profile.
+func applyRedactions(doc *blef.BLEFDocument, profiles []string, keyFile string, out io.Writer) []blef.Redaction {
+	opts := blef.RedactionOptions{PseudonymKey: []byte(os.Getenv(pseudonymKeyEnv))}
+	if keyFile != "" {
+		data, err := os.ReadFile(keyFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error reading pseudonym key file: %v\n", err)
+			os.Exit(1)
+		}
+		opts.PseudonymKey = []byte(strings.TrimRight(string(data), "\r\n"))
+	}
+
+	redactions, err := blef.Redact(doc, opts, profiles...)
+	if errors.Is(err, blef.ErrNoPublicCollection) {
+		fmt.Fprintf(os.Stderr, "❌ %v: mark a collection with is_public, or leave out the %s profile\n", err, blef.RedactPublic)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(out, "🕶️  %d field(s) or element(s) redacted (%s)\n", len(redactions), strings.Join(profiles, ", "))
+	return redactions
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/blef"
+	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/stats"
+)
+
+var (
+	reportYear       int
+	reportFormat     string
+	reportOutputFile string
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report [blef-file]",
+	Short: "Generate a year-in-review report",
+	Long: `Generate a year in review from the books finished during a year, as a
+self-contained HTML page or a Markdown file.
+
+Sections:
+  - highlights: books finished, total pages, shortest and longest book,
+    highest rated
+  - books finished per month, as an SVG bar chart
+  - books finished, with their covers from cover_url
+  - favorite books
+  - authors discovered: authors read for the first time that year
+
+The format is taken from the output file extension (.md for Markdown),
+HTML by default.
+
+Examples:
+  blef-cli report library.blef.json --year 2026
+  blef-cli report library.blef.json --year 2026 -o 2026.md
+  blef-cli report library.blef.json --format markdown`,
+	Args: cobra.ExactArgs(1),
+	Run:  runReport,
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+
+	reportCmd.Flags().IntVar(&reportYear, "year", time.Now().Year(), "Year to review")
+	reportCmd.Flags().StringVar(&reportFormat, "format", "", "Output format (html, markdown) (default: from the output file extension, or html)")
+	reportCmd.Flags().StringVarP(&reportOutputFile, "output", "o", "", "Output file path (default: YEAR-in-review.html or .md)")
+}
+
+func runReport(cmd *cobra.Command, args []string) {
+	if reportFormat == "" {
+		reportFormat = stats.ReviewHTML
+		if ext := strings.ToLower(filepath.Ext(reportOutputFile)); ext == ".md" || ext == ".markdown" {
+			reportFormat = stats.ReviewMarkdown
+		}
+	}
+	if reportFormat == "md" {
+		reportFormat = stats.ReviewMarkdown
+	}
+	if reportFormat != stats.ReviewHTML && reportFormat != stats.ReviewMarkdown {
+		fmt.Fprintf(os.Stderr, "❌ Unknown report format: %s (supported: html, markdown)\n", reportFormat)
+		os.Exit(1)
+	}
+	if reportOutputFile == "" {
+		ext := ".html"
+		if reportFormat == stats.ReviewMarkdown {
+			ext = ".md"
+		}
+		reportOutputFile = fmt.Sprintf("%d-in-review%s", reportYear, ext)
+	}
+
+	doc, err := blef.LoadFromFile(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error reading BLEF file: %v\n", err)
+		os.Exit(1)
+	}
+	warnUnknownFields(args[0], doc)
+
+	fmt.Printf("📅 Reviewing %d in %s\n", reportYear, args[0])
+	review := stats.YearInReview(doc, reportYear)
+	if len(review.Books) == 0 {
+		fmt.Printf("⚠️  No books finished in %d\n", reportYear)
+	} else {
+		fmt.Printf("📚 %d book(s) finished, %d pages\n", len(review.Books), review.Pages)
+	}
+
+	file, err := os.Create(reportOutputFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error creating output file: %v\n", err)
+		os.Exit(1)
+	}
+	err = stats.WriteReview(file, review, reportFormat)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error writing report: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ Report written to %s\n", reportOutputFile)
+}
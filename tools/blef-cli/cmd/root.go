@@ -3,8 +3,10 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/blef"
 )
 
 var (
@@ -12,6 +14,9 @@ var (
 	Version = "dev"
 )
 
+// maxListedUnknownFields bounds the fields named by warnUnknownFields
+const maxListedUnknownFields = 10
+
 var rootCmd = &cobra.Command{
 	Use:   "blef-cli",
 	Short: "BLEF - Book Library Exchange Format CLI tool",
@@ -23,7 +28,23 @@ between reading platforms, management tools, and online services.
 Commands:
   validate - Validate a BLEF file against the JSON schema
   convert  - Convert CSV files to BLEF format
-  view     - Interactive viewer for BLEF files`,
+  view     - Interactive viewer for BLEF files
+  export   - Export BLEF files to CSV format
+  migrate  - Migrate BLEF files between spec versions
+  merge    - Merge several BLEF files into one
+  diff     - Show what changed between two BLEF files
+  apply    - Apply a BLEF patch to a BLEF file
+  query    - Search a BLEF file with a query
+  dedupe   - Find and merge duplicate books
+  authors  - Find and normalize the spellings of author names
+  redact   - Remove sensitive data from a BLEF file before sharing it
+  encrypt  - Encrypt sensitive entry fields with a passphrase
+  decrypt  - Decrypt the entry fields encrypted by encrypt
+  sign     - Sign a BLEF file with an Ed25519 key
+  verify   - Verify the signature of a BLEF file
+  stats    - Show reading statistics of a BLEF file
+  goals    - Track reading goals
+  report   - Generate a year-in-review report`,
 	Version: Version,
 }
 
@@ -35,6 +56,22 @@ func Execute() {
 	}
 }
 
+// warnUnknownFields lists on stderr the fields of a document unknown to this
+// version of BLEF. They are kept as they are in every file written back.
+func warnUnknownFields(filename string, doc *blef.BLEFDocument) {
+	fields := blef.UnknownFields(doc)
+	if len(fields) == 0 {
+		return
+	}
+	listed := fields[:min(len(fields), maxListedUnknownFields)]
+	fmt.Fprintf(os.Stderr, "⚠️  %s has %d field(s) unknown to this version, kept as is: %s",
+		filename, len(fields), strings.Join(listed, ", "))
+	if len(fields) > len(listed) {
+		fmt.Fprintf(os.Stderr, " and %d more", len(fields)-len(listed))
+	}
+	fmt.Fprintln(os.Stderr)
+}
+
 func init() {
 	rootCmd.SetVersionTemplate(`{{.Version}}
 `)
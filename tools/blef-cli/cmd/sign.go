@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/blef"
+	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/blef/signature"
+)
+
+var (
+	signKeyFile     string
+	signOutputFile  string
+	signSigner      string
+	signGenerateKey string
+)
+
+var signCmd = &cobra.Command{
+	Use:   "sign [blef-file]",
+	Short: "Sign a BLEF file with an Ed25519 key",
+	Long: `Sign a BLEF file and write a detached signature next to it.
+
+The signature covers the canonical form of the file (sorted keys, no
+whitespace, numbers as written), so it stays valid when the file is
+reformatted, and is checked with "blef-cli verify". Files with duplicate keys
+or invalid UTF-8 cannot be signed. It records the public key, its ID, the signer
+(default: blef-cli and its version) and the signing date.
+
+Keys are PEM files: PKCS #8 for the private key and PKIX for the public key.
+--generate-key creates a key pair: the private key at the given path and
+the public key next to it with a .pub extension. Share the public key with
+the services that verify your files.
+
+Examples:
+  blef-cli sign --generate-key blef.key
+  blef-cli sign library.blef.json --key blef.key
+  blef-cli sign library.blef.json --key blef.key --signer "My Library App" -o library.sig`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if signGenerateKey != "" {
+			return cobra.MaximumNArgs(1)(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	Run: runSign,
+}
+
+func init() {
+	rootCmd.AddCommand(signCmd)
+
+	signCmd.Flags().StringVarP(&signKeyFile, "key", "k", "", "Private key file (PEM)")
+	signCmd.Flags().StringVarP(&signOutputFile, "output", "o", "", "Signature file path (default: blef-file.sig)")
+	signCmd.Flags().StringVar(&signSigner, "signer", "", "Name of the signer (default: blef-cli and its version)")
+	signCmd.Flags().StringVar(&signGenerateKey, "generate-key", "", "Generate a key pair at this path (public key: path.pub)")
+}
+
+func runSign(cmd *cobra.Command, args []string) {
+	if signGenerateKey != "" {
+		generateSigningKey(signGenerateKey)
+		if len(args) == 0 {
+			return
+		}
+		if signKeyFile == "" {
+			signKeyFile = signGenerateKey
+		}
+		fmt.Println()
+	}
+
+	inputFile := args[0]
+	if signKeyFile == "" {
+		fmt.Fprintln(os.Stderr, "❌ --key is required to sign a file")
+		os.Exit(1)
+	}
+	if signOutputFile == "" {
+		signOutputFile = inputFile + signature.Extension
+	}
+	if signSigner == "" {
+		signSigner = "blef-cli " + Version
+	}
+
+	key, err := signature.LoadPrivateKey(signKeyFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error reading key: %v\n", err)
+		os.Exit(1)
+	}
+	data, err := os.ReadFile(inputFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error reading BLEF file: %v\n", err)
+		os.Exit(1)
+	}
+	doc, err := blef.FromJSON(data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error reading BLEF file: %v\n", err)
+		os.Exit(1)
+	}
+	warnUnknownFields(inputFile, doc)
+
+	fmt.Printf("✍️  Signing %s\n", inputFile)
+	sig, err := signature.Sign(data, key, signSigner)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Signing failed: %v\n", err)
+		os.Exit(1)
+	}
+	if err := sig.SaveToFile(signOutputFile); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error writing signature: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ Signature written to %s\n", signOutputFile)
+	fmt.Printf("  Key ID: %s\n", sig.KeyID)
+	fmt.Printf("  Signer: %s\n", sig.Signer)
+}
+
+// generateSigningKey writes a new key pair, refusing to overwrite a key
+func generateSigningKey(path string) {
+	publicPath := strings.TrimSuffix(path, ".key") + ".pub"
+	for _, file := range []string{path, publicPath} {
+		if _, err := os.Stat(file); err == nil {
+			fmt.Fprintf(os.Stderr, "❌ %s already exists\n", file)
+			os.Exit(1)
+		}
+	}
+
+	public, private, err := signature.GenerateKey()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error generating key: %v\n", err)
+		os.Exit(1)
+	}
+	privateData, err := signature.MarshalPrivateKey(private)
+	if err == nil {
+		err = os.WriteFile(path, privateData, 0600)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error writing private key: %v\n", err)
+		os.Exit(1)
+	}
+	publicData, err := signature.MarshalPublicKey(public)
+	if err == nil {
+		err = os.WriteFile(publicPath, publicData, 0644)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error writing public key: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("🔑 Generated key %s\n", signature.KeyID(public))
+	fmt.Printf("  Private key: %s (keep it secret)\n", path)
+	fmt.Printf("  Public key:  %s\n", publicPath)
+}
@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/blef"
+	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/stats"
+)
+
+var (
+	statsFormat     string
+	statsOutputFile string
+	statsYear       int
+	statsTop        int
+	statsMonths     bool
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats [blef-file]",
+	Short: "Show reading statistics of a BLEF file",
+	Long: `Compute reading statistics from the read dates, ratings and editions of a
+BLEF file:
+
+  - books and pages read per year and month
+  - average, median and distribution of ratings
+  - average days to finish a book
+  - re-reads and longest reading streaks
+  - top authors, subjects and languages, and the split of formats
+
+With --year, only the books finished that year are counted.
+
+Formats:
+  table - terminal tables (default)
+  json  - one JSON object, for dashboards
+
+Examples:
+  blef-cli stats library.blef.json
+  blef-cli stats library.blef.json --year 2024 --months
+  blef-cli stats library.blef.json --format json -o stats.json`,
+	Args: cobra.ExactArgs(1),
+	Run:  runStats,
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+
+	statsCmd.Flags().StringVar(&statsFormat, "format", "table", "Output format (table, json)")
+	statsCmd.Flags().StringVarP(&statsOutputFile, "output", "o", "", "Output file path (default: stdout)")
+	statsCmd.Flags().IntVar(&statsYear, "year", 0, "Only count the books finished this year")
+	statsCmd.Flags().IntVar(&statsTop, "top", stats.DefaultTop, "Length of the top lists")
+	statsCmd.Flags().BoolVar(&statsMonths, "months", false, "Show books and pages per month in tables")
+}
+
+func runStats(cmd *cobra.Command, args []string) {
+	if statsFormat != "table" && statsFormat != "json" {
+		fmt.Fprintf(os.Stderr, "❌ Unknown stats format: %s (supported: table, json)\n", statsFormat)
+		os.Exit(1)
+	}
+
+	doc, err := blef.LoadFromFile(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error reading BLEF file: %v\n", err)
+		os.Exit(1)
+	}
+	warnUnknownFields(args[0], doc)
+	result := stats.Compute(doc, stats.Options{Year: statsYear, Top: statsTop})
+
+	out := os.Stdout
+	if statsOutputFile != "" {
+		file, err := os.Create(statsOutputFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error creating output file: %v\n", err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	if statsFormat == "json" {
+		var data []byte
+		if data, err = json.MarshalIndent(result, "", "  "); err == nil {
+			_, err = fmt.Fprintln(out, string(data))
+		}
+	} else {
+		err = printStats(out, result)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error writing stats: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func printStats(out io.Writer, s *stats.Stats) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	title := "whole library"
+	if s.Year != 0 {
+		title = fmt.Sprintf("books finished in %d", s.Year)
+	}
+	fmt.Fprintf(w, "📊 Reading statistics (%s)\n\n", title)
+	fmt.Fprintf(w, "  Books:\t%d\n", s.Books)
+	fmt.Fprintf(w, "  Reads:\t%d (%d re-read(s))\n", s.Reads, s.Rereads)
+	fmt.Fprintf(w, "  Pages read:\t%d\n", s.PagesRead)
+	if s.DaysToFinish.Count > 0 {
+		fmt.Fprintf(w, "  Days to finish:\t%.1f on average, %g median (%d reads)\n", s.DaysToFinish.Average, s.DaysToFinish.Median, s.DaysToFinish.Count)
+	}
+	if s.Ratings.Count > 0 {
+		fmt.Fprintf(w, "  Rating:\t%.2f on average, %g median (%d ratings)\n", s.Ratings.Average, s.Ratings.Median, s.Ratings.Count)
+	}
+
+	if len(s.Statuses) > 0 {
+		fmt.Fprintln(w, "\n📖 Reading Status:")
+		statuses := make([]string, 0, len(s.Statuses))
+		for status := range s.Statuses {
+			statuses = append(statuses, status)
+		}
+		sort.Strings(statuses)
+		for _, status := range statuses {
+			fmt.Fprintf(w, "  %s %s\t%d\n", getStatusEmoji(status), status, s.Statuses[status])
+		}
+	}
+
+	printPeriods(w, "📅 Per year:", s.Years)
+	if statsMonths {
+		printPeriods(w, "🗓️  Per month:", s.Months)
+	}
+
+	if len(s.Ratings.Distribution) > 0 {
+		fmt.Fprintln(w, "\n⭐ Ratings:")
+		for _, rating := range s.Ratings.Distribution {
+			fmt.Fprintf(w, "  %g\t%d\t%s\n", rating.Rating, rating.Count, statsBar(rating.Count, s.Ratings.Count))
+		}
+	}
+
+	if len(s.Streaks) > 0 {
+		fmt.Fprintln(w, "\n🔥 Longest reading streaks:")
+		for _, streak := range s.Streaks {
+			fmt.Fprintf(w, "  %d days\t%s → %s\n", streak.Days, streak.From, streak.To)
+		}
+	}
+
+	printCounts(w, "🔁 Most re-read:", s.MostReread)
+	printCounts(w, "✍️  Top authors:", s.Authors)
+	printCounts(w, "🏷️  Top subjects:", s.Subjects)
+	printCounts(w, "🌍 Top languages:", s.Languages)
+	printCounts(w, "📦 Formats:", s.Formats)
+
+	return w.Flush()
+}
+
+func printPeriods(w io.Writer, title string, periods []stats.Period) {
+	if len(periods) == 0 {
+		return
+	}
+	max := 0
+	for _, period := range periods {
+		if period.Books > max {
+			max = period.Books
+		}
+	}
+	fmt.Fprintf(w, "\n%s\n", title)
+	fmt.Fprintln(w, "  PERIOD\tBOOKS\tPAGES\t")
+	for _, period := range periods {
+		fmt.Fprintf(w, "  %s\t%d\t%d\t%s\n", period.Period, period.Books, period.Pages, statsBar(period.Books, max))
+	}
+}
+
+func printCounts(w io.Writer, title string, counts []stats.Count) {
+	if len(counts) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n%s\n", title)
+	for _, count := range counts {
+		fmt.Fprintf(w, "  %s\t%d\n", count.Name, count.Count)
+	}
+}
+
+// statsBar draws a bar proportional to value, 20 characters for max
+func statsBar(value, max int) string {
+	if max == 0 {
+		return ""
+	}
+	return strings.Repeat("█", (value*20+max-1)/max)
+}
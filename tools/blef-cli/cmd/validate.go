@@ -3,6 +3,8 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/blef"
@@ -14,11 +16,27 @@ var validateCmd = &cobra.Command{
 	Long: `Validate a BLEF file for correctness.
 
 This command performs comprehensive validation including:
-- JSON schema validation
-- Referential integrity checks
+- JSON schema validation against the schema matching the document's version
+- Referential integrity checks and duplicate entries
 - ISBN-13 check digit validation
 - Required field validation
-- Status and rating range validation
+- Enumerated values (status, collection type, edition format, author role)
+- ISO 8601 dates, ISO 639-1 language codes and cover URIs
+- Rating, progress and page count ranges
+
+Use --stream for very large files: elements are validated one at a time
+without loading the whole document. JSON schema validation is skipped in
+streaming mode.
+
+Every finding has a stable rule code (e.g. BLEF-E012), a severity and the
+JSON pointer of the offending value. Use --lint to also get warnings for
+contradictory data, such as a read book without a finished date or a loaned
+book that is not owned. Use --fix to repair what can be repaired mechanically
+(hyphenated or ISBN-10 book IDs, missing collections, duplicate entries,
+out-of-range ratings, dates in other formats): the repaired document is
+written next to the input and every change is listed. Use --format json or
+--format sarif to get a machine-readable report on stdout, for CI pipelines
+and code scanning.
 
 Exit codes:
   0 - File is valid
@@ -27,13 +45,49 @@ Exit codes:
 	Run:  runValidate,
 }
 
+var (
+	validateStream        bool
+	validateSchemaVersion string
+	validateFormat        string
+	validateLint          bool
+	validateFix           bool
+	validateOutputFile    string
+)
+
 func init() {
 	rootCmd.AddCommand(validateCmd)
+
+	validateCmd.Flags().BoolVar(&validateStream, "stream", false, "Validate one element at a time (skips JSON schema validation)")
+	validateCmd.Flags().StringVar(&validateSchemaVersion, "schema-version", "", "Validate against this schema version instead of the document's version")
+	validateCmd.Flags().StringVar(&validateFormat, "format", "text", "Report format (text, json, sarif)")
+	validateCmd.Flags().BoolVar(&validateFix, "fix", false, "Repair mechanically fixable findings and write the repaired document")
+	validateCmd.Flags().StringVarP(&validateOutputFile, "output", "o", "", "Output file for --fix (default: input-fixed.blef.json)")
+	validateCmd.Flags().BoolVar(&validateLint, "lint", false, "Also report consistency warnings (e.g. read books without a finished date)")
 }
 
 func runValidate(cmd *cobra.Command, args []string) {
 	filename := args[0]
 
+	switch validateFormat {
+	case "text", "json", "sarif":
+	default:
+		fmt.Fprintf(os.Stderr, "❌ Unknown report format: %s (supported: text, json, sarif)\n", validateFormat)
+		os.Exit(1)
+	}
+
+	if validateStream {
+		if validateLint || validateFix {
+			fmt.Fprintln(os.Stderr, "❌ --lint and --fix cannot be used with --stream")
+			os.Exit(1)
+		}
+		if validateSchemaVersion != "" {
+			fmt.Fprintln(os.Stderr, "❌ --schema-version cannot be used with --stream, which skips JSON schema validation")
+			os.Exit(1)
+		}
+		runValidateStream(filename)
+		return
+	}
+
 	// Read file
 	data, err := os.ReadFile(filename)
 	if err != nil {
@@ -41,36 +95,57 @@ func runValidate(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	// Parse BLEF document
-	doc, err := blef.FromJSON(data)
+	if validateFix {
+		filename, data = runValidateFix(filename, data)
+	}
+
+	report, err := blef.ValidateJSON(data, validateSchemaVersion)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "❌ Error parsing BLEF file: %v\n", err)
+		fmt.Fprintf(os.Stderr, "❌ Schema validation failed: %v\n", err)
 		os.Exit(1)
 	}
+	if validateLint && report.Document != nil {
+		report.Add(blef.LintDocument(report.Document)...)
+	}
+
+	if validateFormat != "text" {
+		writeReport(report, filename, data)
+		return
+	}
 
 	fmt.Printf("📚 Validating BLEF file: %s\n\n", filename)
 
-	// Validate against schema
+	// Schema findings come first, so that type and format errors are
+	// reported with their location
+	var schemaFindings, documentFindings []blef.ValidationError
+	for _, finding := range report.Findings {
+		if finding.Code == blef.RuleSchema {
+			schemaFindings = append(schemaFindings, finding)
+		} else {
+			documentFindings = append(documentFindings, finding)
+		}
+	}
+
 	fmt.Println("🔍 Checking JSON schema...")
-	if err := blef.ValidateAgainstSchema(data); err != nil {
-		fmt.Fprintf(os.Stderr, "❌ Schema validation failed:\n%v\n", err)
+	if len(schemaFindings) > 0 {
+		fmt.Println("❌ Schema validation failed:")
+		printFindings(schemaFindings)
 		os.Exit(1)
 	}
-	fmt.Println("✅ Schema validation passed")
+	fmt.Printf("✅ Schema validation passed (schema v%s)\n", report.SchemaVersion)
 
 	// Validate document structure and integrity
 	fmt.Println("\n🔍 Checking document integrity...")
-	errors := blef.ValidateDocument(doc)
-	if len(errors) > 0 {
+	if report.HasErrors() {
 		fmt.Println("❌ Validation errors found:")
-		for _, err := range errors {
-			fmt.Printf("  • %v\n", err)
-		}
+		printFindings(documentFindings)
 		os.Exit(1)
 	}
 	fmt.Println("✅ Document integrity validated")
+	printWarnings(documentFindings)
 
 	// Display statistics
+	doc := report.Document
 	fmt.Println("\n📊 Document Statistics:")
 	fmt.Printf("  Format: %s v%s\n", doc.Format, doc.Version)
 	fmt.Printf("  Exported: %s\n", doc.ExportedAt.Format("2006-01-02 15:04:05"))
@@ -87,7 +162,171 @@ func runValidate(cmd *cobra.Command, args []string) {
 	for _, entry := range doc.Entries {
 		statusCount[entry.UserData.Status]++
 	}
+	printStatusBreakdown(statusCount)
 
+	fmt.Println("\n✅ File is valid!")
+}
+
+func runValidateStream(filename string) {
+	file, err := os.Open(filename)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error reading file: %v\n", err)
+		os.Exit(1)
+	}
+	defer file.Close()
+
+	text := validateFormat == "text"
+	if text {
+		fmt.Printf("📚 Validating BLEF file: %s\n\n", filename)
+		fmt.Println("⚠️  Streaming mode: JSON schema validation skipped")
+		fmt.Println("\n🔍 Checking document integrity...")
+	}
+
+	counts := make(map[blef.ElementKind]int)
+	statusCount := make(map[string]int)
+	header, errors, err := blef.ValidateStream(file, func(elem *blef.Element) {
+		counts[elem.Kind]++
+		if elem.Kind == blef.KindEntry {
+			statusCount[elem.Entry.UserData.Status]++
+		}
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error parsing BLEF file: %v\n", err)
+		os.Exit(1)
+	}
+
+	report := blef.NewValidationReport()
+	report.Add(errors...)
+
+	if !text {
+		writeReport(report, filename, nil)
+		return
+	}
+
+	if report.HasErrors() {
+		fmt.Println("❌ Validation errors found:")
+		printFindings(report.Findings)
+		os.Exit(1)
+	}
+	fmt.Println("✅ Document integrity validated")
+	printWarnings(report.Findings)
+
+	// Display statistics
+	fmt.Println("\n📊 Document Statistics:")
+	fmt.Printf("  Format: %s v%s\n", header.Format, header.Version)
+	fmt.Printf("  Exported: %s\n", header.ExportedAt.Format("2006-01-02 15:04:05"))
+	fmt.Printf("  Books: %d\n", counts[blef.KindBook])
+	fmt.Printf("  Collections: %d\n", counts[blef.KindCollection])
+	fmt.Printf("  Entries: %d\n", counts[blef.KindEntry])
+
+	if header.User != nil && header.User.Name != "" {
+		fmt.Printf("  User: %s\n", header.User.Name)
+	}
+
+	printStatusBreakdown(statusCount)
+
+	fmt.Println("\n✅ File is valid!")
+}
+
+// runValidateFix repairs the document, writes it and prints the changelog.
+// It returns the file name and content to validate: the repaired file, or the
+// input when there was nothing to fix.
+func runValidateFix(filename string, data []byte) (string, []byte) {
+	// Keep stdout clean for machine-readable reports
+	out := os.Stdout
+	if validateFormat != "text" {
+		out = os.Stderr
+	}
+
+	doc, err := blef.FromJSON(data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Cannot repair a document that does not parse: %v\n", err)
+		os.Exit(1)
+	}
+	warnUnknownFields(filename, doc)
+
+	fmt.Fprintf(out, "🔧 Repairing BLEF file: %s\n", filename)
+	changes := blef.Repair(doc)
+	if len(changes) == 0 {
+		fmt.Fprintln(out, "✅ Nothing to fix")
+		fmt.Fprintln(out)
+		return filename, data
+	}
+
+	fmt.Fprintf(out, "\n📝 %d change(s):\n", len(changes))
+	for _, change := range changes {
+		fmt.Fprintf(out, "  • %s\n", change)
+	}
+
+	outputFile := validateOutputFile
+	if outputFile == "" {
+		base := strings.TrimSuffix(strings.TrimSuffix(filename, ".json"), ".blef")
+		outputFile = base + "-fixed.blef.json"
+	}
+
+	fmt.Fprintf(out, "\n💾 Writing to %s...\n\n", outputFile)
+	if err := doc.SaveToFile(outputFile); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error writing file: %v\n", err)
+		os.Exit(1)
+	}
+
+	repaired, err := doc.ToJSON()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error encoding repaired document: %v\n", err)
+		os.Exit(1)
+	}
+	return outputFile, repaired
+}
+
+// writeReport prints a machine-readable report on stdout and exits with
+// status 1 if it contains errors. data is the file content, used to give
+// SARIF results a line number; it may be nil.
+func writeReport(report *blef.ValidationReport, filename string, data []byte) {
+	var err error
+	if validateFormat == "sarif" {
+		err = report.WriteSARIF(os.Stdout, filepath.ToSlash(filename), data)
+	} else {
+		err = report.WriteJSON(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error writing report: %v\n", err)
+		os.Exit(1)
+	}
+	if report.HasErrors() {
+		os.Exit(1)
+	}
+}
+
+func printFindings(findings []blef.ValidationError) {
+	for _, finding := range findings {
+		location := finding.Pointer
+		if location == "" {
+			location = "(root)"
+		}
+		fmt.Printf("  • [%s] %s %s: %s\n", finding.Code, getSeverityEmoji(finding.Severity), location, finding.Message)
+	}
+}
+
+// printWarnings lists the findings of a report that has no error
+func printWarnings(findings []blef.ValidationError) {
+	if len(findings) > 0 {
+		fmt.Printf("⚠️  %d warning(s):\n", len(findings))
+		printFindings(findings)
+	}
+}
+
+func getSeverityEmoji(severity blef.Severity) string {
+	switch severity {
+	case blef.SeverityWarning:
+		return "⚠️"
+	case blef.SeverityInfo:
+		return "ℹ️"
+	default:
+		return "❌"
+	}
+}
+
+func printStatusBreakdown(statusCount map[string]int) {
 	if len(statusCount) > 0 {
 		fmt.Println("\n📖 Reading Status:")
 		for status, count := range statusCount {
@@ -95,8 +334,6 @@ func runValidate(cmd *cobra.Command, args []string) {
 			fmt.Printf("  %s %s: %d\n", emoji, status, count)
 		}
 	}
-
-	fmt.Println("\n✅ File is valid!")
 }
 
 func getStatusEmoji(status string) string {
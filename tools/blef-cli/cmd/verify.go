@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/blef"
+	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/blef/signature"
+)
+
+var (
+	verifyKeyFile       string
+	verifySignatureFile string
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify [blef-file]",
+	Short: "Verify the signature of a BLEF file",
+	Long: `Verify a BLEF file against the detached signature written by "blef-cli sign".
+
+With --key, the file must have been signed with that public key: this proves
+where the file comes from. Without it, only the integrity of the file is
+checked, against the public key recorded in the signature.
+
+Exits with status 1 when the signature does not match.
+
+Examples:
+  blef-cli verify library.blef.json --key blef.pub
+  blef-cli verify library.blef.json -s library.sig`,
+	Args: cobra.ExactArgs(1),
+	Run:  runVerify,
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+
+	verifyCmd.Flags().StringVarP(&verifyKeyFile, "key", "k", "", "Trusted public key file (PEM)")
+	verifyCmd.Flags().StringVarP(&verifySignatureFile, "signature", "s", "", "Signature file path (default: blef-file.sig)")
+}
+
+func runVerify(cmd *cobra.Command, args []string) {
+	inputFile := args[0]
+	if verifySignatureFile == "" {
+		verifySignatureFile = inputFile + signature.Extension
+	}
+
+	var trusted ed25519.PublicKey
+	if verifyKeyFile != "" {
+		var err error
+		if trusted, err = signature.LoadPublicKey(verifyKeyFile); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error reading key: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	sig, err := signature.LoadSignature(verifySignatureFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error reading signature: %v\n", err)
+		os.Exit(1)
+	}
+	data, err := os.ReadFile(inputFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error reading BLEF file: %v\n", err)
+		os.Exit(1)
+	}
+	doc, err := blef.FromJSON(data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error reading BLEF file: %v\n", err)
+		os.Exit(1)
+	}
+	warnUnknownFields(inputFile, doc)
+
+	fmt.Printf("🔏 Verifying %s with %s\n", inputFile, verifySignatureFile)
+	fmt.Printf("  Key ID:    %s\n", signature.KeyID(sig.PublicKey))
+	if sig.Signer != "" {
+		fmt.Printf("  Signer:    %s\n", sig.Signer)
+	}
+	fmt.Printf("  Signed at: %s\n\n", sig.SignedAt.Format("2006-01-02 15:04:05 MST"))
+
+	if err := signature.Verify(data, sig, trusted); err != nil {
+		switch {
+		case errors.Is(err, signature.ErrInvalidSignature):
+			fmt.Fprintln(os.Stderr, "❌ Invalid signature: the file was modified after it was signed")
+		case errors.Is(err, signature.ErrUntrustedKey):
+			fmt.Fprintf(os.Stderr, "❌ The file was signed with key %s, not with %s\n", signature.KeyID(sig.PublicKey), signature.KeyID(trusted))
+		default:
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		}
+		os.Exit(1)
+	}
+
+	if trusted == nil {
+		fmt.Println("✅ Signature is valid: the file was not modified")
+		fmt.Println("⚠️  The signing key was not checked, use --key to check where the file comes from")
+		return
+	}
+	fmt.Println("✅ Signature is valid and made with the trusted key")
+}
@@ -26,7 +26,10 @@ Controls:
   Tab         - Switch between views (Books/Collections/Stats)
   Enter       - View book details
   Esc         - Go back
-  q           - Quit`,
+  q           - Quit
+
+Encrypted fields (see "blef-cli encrypt") are decrypted before viewing, with
+the passphrase from the BLEF_PASSPHRASE environment variable or a prompt.`,
 	Args: cobra.ExactArgs(1),
 	Run:  runView,
 }
@@ -50,6 +53,15 @@ func runView(cmd *cobra.Command, args []string) {
 		fmt.Fprintf(os.Stderr, "Error parsing BLEF file: %v\n", err)
 		os.Exit(1)
 	}
+	warnUnknownFields(filename, doc)
+
+	if blef.IsEncrypted(doc) {
+		fmt.Println("🔐 This file has encrypted fields")
+		if _, err := blef.DecryptDocument(doc, readPassphrase("", false)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error decrypting BLEF file: %v\n", err)
+			os.Exit(1)
+		}
+	}
 
 	// Create and run TUI
 	model := viewer.NewModel(doc)
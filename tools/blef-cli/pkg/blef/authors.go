@@ -0,0 +1,417 @@
+package blef
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Author identifier keys written by NormalizeAuthors
+const (
+	AuthorSortNameKey = "sort_name"
+	AuthorNameKey     = "name_key"
+)
+
+// AuthorName is a personal name split into its parts
+type AuthorName struct {
+	Given    string // "Antoine", "J. K."
+	Particle string // lowercase particles such as "de" or "van der"
+	Family   string // "Saint-Exupéry", "Le Guin"
+	Suffix   string // "Jr.", "III"
+}
+
+// nameParticles are the lowercase particles that belong to the family name
+// but are ignored when sorting, as in "Ludwig van Beethoven". Capitalized,
+// as in "Ursula K. Le Guin", they are part of the family name.
+var nameParticles = map[string]bool{
+	"de": true, "du": true, "des": true, "d'": true, "del": true, "della": true, "der": true, "den": true,
+	"van": true, "von": true, "ten": true, "ter": true, "zu": true,
+	"da": true, "di": true, "do": true, "dos": true, "das": true,
+	"le": true, "la": true, "y": true,
+}
+
+var nameSuffixes = map[string]bool{
+	"jr": true, "jr.": true, "sr": true, "sr.": true, "ii": true, "iii": true, "iv": true,
+}
+
+// ParseAuthorName splits a name written either "First Last" or "Last, First",
+// such as "J.K. Rowling", "Rowling, J.K." or "Saint-Exupéry, Antoine de".
+// Initials are normalized to "J. K.".
+func ParseAuthorName(name string) AuthorName {
+	var parsed AuthorName
+
+	family, given, inverted := strings.Cut(name, ",")
+	if inverted {
+		// "King, Martin Luther, Jr."
+		if rest, suffix, ok := strings.Cut(given, ","); ok && nameSuffixes[strings.ToLower(strings.TrimSpace(suffix))] {
+			given, parsed.Suffix = rest, strings.TrimSpace(suffix)
+		}
+		familyWords := strings.Fields(family)
+		givenWords := splitInitials(strings.Fields(given))
+		if len(givenWords) == 0 {
+			// "Plato," or a trailing comma
+			return ParseAuthorName(family)
+		}
+
+		// Lowercase particles can come after the given name or before the family name
+		particles := 0
+		for particles < len(givenWords)-1 && nameParticles[givenWords[len(givenWords)-1-particles]] {
+			particles++
+		}
+		leading := 0
+		for leading < len(familyWords)-1 && nameParticles[familyWords[leading]] {
+			leading++
+		}
+		parsed.Given = strings.Join(givenWords[:len(givenWords)-particles], " ")
+		parsed.Particle = strings.Join(append(familyWords[:leading:leading], givenWords[len(givenWords)-particles:]...), " ")
+		parsed.Family = strings.Join(familyWords[leading:], " ")
+		return parsed
+	}
+
+	words := splitInitials(strings.Fields(name))
+	if len(words) > 1 && nameSuffixes[strings.ToLower(strings.TrimSuffix(words[len(words)-1], ","))] {
+		parsed.Suffix = words[len(words)-1]
+		words = words[:len(words)-1]
+	}
+	if len(words) == 0 {
+		return parsed
+	}
+
+	// The family name starts at the first particle after the given name,
+	// lowercase or capitalized, or is the last word
+	start := len(words) - 1
+	for i := 1; i < len(words)-1; i++ {
+		if nameParticles[strings.ToLower(words[i])] {
+			start = i
+			break
+		}
+	}
+	end := start
+	for end < len(words)-1 && nameParticles[words[end]] {
+		end++
+	}
+
+	parsed.Given = strings.Join(words[:start], " ")
+	parsed.Particle = strings.Join(words[start:end], " ")
+	parsed.Family = strings.Join(words[end:], " ")
+	return parsed
+}
+
+// splitInitials rewrites "J.K.", "J.K" and "JK" as separate initials
+func splitInitials(words []string) []string {
+	var result []string
+	for _, word := range words {
+		letters := strings.ReplaceAll(word, ".", "")
+		count := utf8.RuneCountInString(letters)
+		capitals := strings.ToUpper(letters) == letters && isLetters(letters) && !nameSuffixes[strings.ToLower(word)]
+		initials := capitals && count > 1 && count <= strings.Count(word, ".")+1
+		if !initials && capitals && count <= 3 && count > 1 {
+			// "JK" or "JRR", but not a lone word in capitals
+			initials = len(words) > 1 && strings.ToUpper(strings.Join(words, " ")) != strings.Join(words, " ")
+		}
+		if !initials {
+			result = append(result, word)
+			continue
+		}
+		for _, r := range letters {
+			result = append(result, string(r)+".")
+		}
+	}
+	return result
+}
+
+func isLetters(s string) bool {
+	for _, r := range s {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// String returns the name in display order, e.g. "Antoine de Saint-Exupéry"
+func (n AuthorName) String() string {
+	return joinNonEmpty(" ", n.Given, n.Particle, n.Family, n.Suffix)
+}
+
+// SortName returns the name in sort order, e.g. "Saint-Exupéry, Antoine de"
+func (n AuthorName) SortName() string {
+	if n.Given == "" && n.Suffix == "" {
+		return joinNonEmpty(" ", n.Particle, n.Family)
+	}
+	return joinNonEmpty(", ", n.Family, joinNonEmpty(" ", n.Given, n.Particle), n.Suffix)
+}
+
+// Key returns the identity key of the name: the folded family name with its
+// particles followed by the folded given names, so "J.K. Rowling" and
+// "Rowling, J. K." share the key "rowling j k". Spellings with initials,
+// such as "Joanne Rowling", have other keys: see Compatible.
+func (n AuthorName) Key() string {
+	return joinNonEmpty(" ", n.familyKey(), FoldText(n.Given))
+}
+
+func (n AuthorName) familyKey() string {
+	return strings.ReplaceAll(FoldText(n.Particle+" "+n.Family), " ", "")
+}
+
+// Compatible reports whether two names can be spellings of the same person:
+// their family names are the same and each given name matches the given name
+// at the same position, an initial matching any name starting with it. A
+// missing given name matches any, so "J. K. Rowling" is compatible with
+// "Joanne Rowling" and "Joanne Kathleen Rowling", but "John Smith" is not
+// compatible with "Jane Smith".
+func (n AuthorName) Compatible(other AuthorName) bool {
+	if n.familyKey() != other.familyKey() {
+		return false
+	}
+	return givenNamesCompatible(strings.Fields(FoldText(n.Given)), strings.Fields(FoldText(other.Given)))
+}
+
+func givenNamesCompatible(a, b []string) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		x, y := a[i], b[i]
+		switch {
+		case x == y:
+		case utf8.RuneCountInString(x) == 1 && strings.HasPrefix(y, x):
+		case utf8.RuneCountInString(y) == 1 && strings.HasPrefix(x, y):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func joinNonEmpty(sep string, parts ...string) string {
+	var kept []string
+	for _, part := range parts {
+		if part != "" {
+			kept = append(kept, part)
+		}
+	}
+	return strings.Join(kept, sep)
+}
+
+// AuthorIdentity groups the spellings of one author across a document
+type AuthorIdentity struct {
+	Key         string
+	Name        string            // canonical name
+	SortName    string            // canonical name in sort order
+	Variants    []string          // distinct spellings, most used first
+	BookIDs     []string          // books by this author, in document order
+	Identifiers map[string]string // identifiers known for any spelling
+}
+
+// ResolveAuthors groups the spellings of the authors of a document that are
+// compatible (see AuthorName.Compatible). Spellings are placed from the most
+// complete, and one compatible with several identities, such as "J. Smith"
+// next to "John Smith" and "Jane Smith", is kept apart rather than guessed.
+// The canonical name is the most used spelling, preferring the most complete
+// one (full given names, diacritics) on ties, and the key is its key.
+// Identities are sorted by sort name.
+func ResolveAuthors(doc *BLEFDocument) []AuthorIdentity {
+	type spelling struct {
+		name   string
+		parsed AuthorName
+		uses   int
+	}
+	var spellings []*spelling
+	byName := make(map[string]*spelling)
+	for _, book := range doc.Books {
+		for _, author := range book.Authors {
+			name := normalizeAuthorName(author.Name)
+			if byName[name] == nil {
+				parsed := ParseAuthorName(name)
+				if parsed.Key() == "" {
+					continue
+				}
+				byName[name] = &spelling{name: name, parsed: parsed}
+				spellings = append(spellings, byName[name])
+			}
+			byName[name].uses++
+		}
+	}
+	sort.SliceStable(spellings, func(i, j int) bool {
+		return nameCompleteness(spellings[i].name) > nameCompleteness(spellings[j].name)
+	})
+
+	// Group the spellings into identities
+	var groups [][]*spelling
+	group := make(map[string]int)
+	for _, s := range spellings {
+		match := -1
+		for i, members := range groups {
+			compatible := true
+			for _, member := range members {
+				compatible = compatible && member.parsed.Compatible(s.parsed)
+			}
+			if !compatible {
+				continue
+			}
+			if match >= 0 {
+				match = -2 // ambiguous
+				break
+			}
+			match = i
+		}
+		if match < 0 {
+			match = len(groups)
+			groups = append(groups, nil)
+		}
+		groups[match] = append(groups[match], s)
+		group[s.name] = match
+	}
+
+	identities := make([]*AuthorIdentity, len(groups))
+	for i := range identities {
+		identities[i] = &AuthorIdentity{Identifiers: make(map[string]string)}
+	}
+	for _, book := range doc.Books {
+		for _, author := range book.Authors {
+			name := normalizeAuthorName(author.Name)
+			if byName[name] == nil {
+				continue
+			}
+			identity := identities[group[name]]
+			if !containsString(identity.Variants, name) {
+				identity.Variants = append(identity.Variants, name)
+			}
+			if !containsString(identity.BookIDs, book.ID) {
+				identity.BookIDs = append(identity.BookIDs, book.ID)
+			}
+			for scheme, value := range author.Identifiers {
+				if _, exists := identity.Identifiers[scheme]; !exists && scheme != AuthorSortNameKey && scheme != AuthorNameKey {
+					identity.Identifiers[scheme] = value
+				}
+			}
+		}
+	}
+
+	result := make([]AuthorIdentity, 0, len(identities))
+	for _, identity := range identities {
+		sort.SliceStable(identity.Variants, func(i, j int) bool {
+			a, b := byName[identity.Variants[i]], byName[identity.Variants[j]]
+			if a.uses != b.uses {
+				return a.uses > b.uses
+			}
+			return nameCompleteness(a.name) > nameCompleteness(b.name)
+		})
+		canonical := byName[identity.Variants[0]].parsed
+		identity.Key = canonical.Key()
+		identity.Name = canonical.String()
+		identity.SortName = canonical.SortName()
+		result = append(result, *identity)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := FoldText(result[i].SortName), FoldText(result[j].SortName)
+		if a != b {
+			return a < b
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
+
+// normalizeAuthorName collapses the spaces of a name, giving the spelling
+// listed in AuthorIdentity.Variants
+func normalizeAuthorName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// nameCompleteness scores how much a spelling tells: letters in the given
+// name, then letters outside ASCII such as diacritics
+func nameCompleteness(name string) int {
+	score := 0
+	for _, r := range ParseAuthorName(name).Given {
+		if unicode.IsLetter(r) {
+			score += 10
+		}
+	}
+	for _, r := range name {
+		if r > unicode.MaxASCII {
+			score++
+		}
+	}
+	return score
+}
+
+// AuthorOptions configures NormalizeAuthors
+type AuthorOptions struct {
+	// SortNames records the sort name of each author under AuthorSortNameKey
+	SortNames bool
+	// Identifiers records the identity key under AuthorNameKey and copies the
+	// identifiers known for any spelling of an author to every spelling
+	Identifiers bool
+}
+
+// AuthorChange describes an author rewritten by NormalizeAuthors
+type AuthorChange struct {
+	BookID string
+	From   string
+	To     string
+}
+
+func (c AuthorChange) String() string {
+	return fmt.Sprintf("book %s: %q → %q", c.BookID, c.From, c.To)
+}
+
+// AuthorIdentities maps every spelling of the identities to its identity
+func AuthorIdentities(identities []AuthorIdentity) map[string]AuthorIdentity {
+	bySpelling := make(map[string]AuthorIdentity)
+	for _, identity := range identities {
+		for _, variant := range identity.Variants {
+			bySpelling[variant] = identity
+		}
+	}
+	return bySpelling
+}
+
+// LookupAuthor returns the identity of an author name in a map built by
+// AuthorIdentities
+func LookupAuthor(bySpelling map[string]AuthorIdentity, name string) (AuthorIdentity, bool) {
+	identity, ok := bySpelling[normalizeAuthorName(name)]
+	return identity, ok
+}
+
+// NormalizeAuthors renames every author of the document to the canonical
+// name of its identity, as found by ResolveAuthors, and returns the renamed
+// authors
+func NormalizeAuthors(doc *BLEFDocument, opts AuthorOptions) []AuthorChange {
+	identities := AuthorIdentities(ResolveAuthors(doc))
+
+	var changes []AuthorChange
+	for i := range doc.Books {
+		book := &doc.Books[i]
+		for j := range book.Authors {
+			author := &book.Authors[j]
+			identity, ok := LookupAuthor(identities, author.Name)
+			if !ok {
+				continue
+			}
+			if author.Name != identity.Name {
+				changes = append(changes, AuthorChange{BookID: book.ID, From: author.Name, To: identity.Name})
+				author.Name = identity.Name
+			}
+
+			if !opts.SortNames && !opts.Identifiers {
+				continue
+			}
+			if author.Identifiers == nil {
+				author.Identifiers = make(map[string]string)
+			}
+			if opts.SortNames {
+				author.Identifiers[AuthorSortNameKey] = identity.SortName
+			}
+			if opts.Identifiers {
+				author.Identifiers[AuthorNameKey] = identity.Key
+				for scheme, value := range identity.Identifiers {
+					if _, exists := author.Identifiers[scheme]; !exists {
+						author.Identifiers[scheme] = value
+					}
+				}
+			}
+		}
+	}
+	return changes
+}
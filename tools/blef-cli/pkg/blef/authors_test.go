@@ -0,0 +1,169 @@
+package blef
+
+import "testing"
+
+func TestParseAuthorName(t *testing.T) {
+	tests := []struct {
+		input    string
+		want     AuthorName
+		sortName string
+	}{
+		{"J.K. Rowling", AuthorName{Given: "J. K.", Family: "Rowling"}, "Rowling, J. K."},
+		{"Rowling, J.K.", AuthorName{Given: "J. K.", Family: "Rowling"}, "Rowling, J. K."},
+		{"JK Rowling", AuthorName{Given: "J. K.", Family: "Rowling"}, "Rowling, J. K."},
+		{"Antoine de Saint-Exupéry", AuthorName{Given: "Antoine", Particle: "de", Family: "Saint-Exupéry"}, "Saint-Exupéry, Antoine de"},
+		{"Saint-Exupéry, Antoine de", AuthorName{Given: "Antoine", Particle: "de", Family: "Saint-Exupéry"}, "Saint-Exupéry, Antoine de"},
+		{"de Saint-Exupéry, Antoine", AuthorName{Given: "Antoine", Particle: "de", Family: "Saint-Exupéry"}, "Saint-Exupéry, Antoine de"},
+		{"Ludwig van Beethoven", AuthorName{Given: "Ludwig", Particle: "van", Family: "Beethoven"}, "Beethoven, Ludwig van"},
+		{"Ursula K. Le Guin", AuthorName{Given: "Ursula K.", Family: "Le Guin"}, "Le Guin, Ursula K."},
+		{"Le Guin, Ursula K.", AuthorName{Given: "Ursula K.", Family: "Le Guin"}, "Le Guin, Ursula K."},
+		{"Martin Luther King Jr.", AuthorName{Given: "Martin Luther", Family: "King", Suffix: "Jr."}, "King, Martin Luther, Jr."},
+		{"King, Martin Luther, Jr.", AuthorName{Given: "Martin Luther", Family: "King", Suffix: "Jr."}, "King, Martin Luther, Jr."},
+		{"Homer", AuthorName{Family: "Homer"}, "Homer"},
+		{"St. John Ervine", AuthorName{Given: "St. John", Family: "Ervine"}, "Ervine, St. John"},
+	}
+
+	for _, tt := range tests {
+		got := ParseAuthorName(tt.input)
+		if got != tt.want {
+			t.Errorf("ParseAuthorName(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+		if got.SortName() != tt.sortName {
+			t.Errorf("SortName(%q) = %q, want %q", tt.input, got.SortName(), tt.sortName)
+		}
+	}
+}
+
+func TestAuthorNameKey(t *testing.T) {
+	same := [][]string{
+		{"J.K. Rowling", "Rowling, J. K.", "JK Rowling"},
+		{"Antoine de Saint-Exupéry", "Saint-Exupery, Antoine de", "de Saint-Exupéry, Antoine"},
+		{"Ursula K. Le Guin", "Le Guin, Ursula K."},
+	}
+	for _, names := range same {
+		key := ParseAuthorName(names[0]).Key()
+		for _, name := range names[1:] {
+			if other := ParseAuthorName(name).Key(); other != key {
+				t.Errorf("Expected %q and %q to share a key, got %q and %q", names[0], name, key, other)
+			}
+		}
+	}
+
+	if ParseAuthorName("John Smith").Key() == ParseAuthorName("Jane Smith").Key() {
+		t.Error("Expected different given names to give different keys")
+	}
+}
+
+func TestAuthorNameCompatible(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"J. K. Rowling", "Joanne Rowling", true},
+		{"J. K. Rowling", "Joanne Kathleen Rowling", true},
+		{"Ursula K. Le Guin", "Ursula Le Guin", true},
+		{"Le Guin, Ursula", "Ursula K. Le Guin", true},
+		{"J. Smith", "John Smith", true},
+		{"Smith", "John Smith", true},
+		{"John Smith", "Jane Smith", false},
+		{"J. Smith", "K. Smith", false},
+		{"Joanne Rowling", "Kevin Rowling", false},
+		{"John Smith", "John Smyth", false},
+	}
+	for _, tt := range tests {
+		if got := ParseAuthorName(tt.a).Compatible(ParseAuthorName(tt.b)); got != tt.want {
+			t.Errorf("Compatible(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func authorsTestDocument() *BLEFDocument {
+	doc := NewDocument()
+	doc.Books = []Book{
+		{ID: "9780439139595", Title: "Goblet of Fire", Authors: []Author{{Name: "J.K. Rowling"}}},
+		{ID: "9780439358071", Title: "Order of the Phoenix", Authors: []Author{{Name: "Rowling, J.K.", Identifiers: map[string]string{"wikidata": "Q34660"}}}},
+		{ID: "9780545010221", Title: "Deathly Hallows", Authors: []Author{{Name: "J.K. Rowling"}}},
+		{ID: "9780156013987", Title: "The Little Prince", Authors: []Author{{Name: "Antoine de Saint-Exupery"}}},
+		{ID: "9782070612758", Title: "Le Petit Prince", Authors: []Author{{Name: "Saint-Exupéry, Antoine de"}}},
+	}
+	return doc
+}
+
+func TestResolveAuthors(t *testing.T) {
+	identities := ResolveAuthors(authorsTestDocument())
+	if len(identities) != 2 {
+		t.Fatalf("Expected 2 identities, got %+v", identities)
+	}
+
+	rowling := identities[0]
+	if rowling.Name != "J. K. Rowling" || rowling.SortName != "Rowling, J. K." {
+		t.Errorf("Expected the most used spelling, got %q (%q)", rowling.Name, rowling.SortName)
+	}
+	if len(rowling.Variants) != 2 || len(rowling.BookIDs) != 3 || rowling.Identifiers["wikidata"] != "Q34660" {
+		t.Errorf("Unexpected identity: %+v", rowling)
+	}
+
+	// On a tie, diacritics win
+	if identities[1].Name != "Antoine de Saint-Exupéry" {
+		t.Errorf("Expected the spelling with diacritics, got %q", identities[1].Name)
+	}
+}
+
+func TestNormalizeAuthors(t *testing.T) {
+	doc := authorsTestDocument()
+	changes := NormalizeAuthors(doc, AuthorOptions{SortNames: true, Identifiers: true})
+	if len(changes) != 5 {
+		t.Errorf("Expected 5 renamed authors, got %v", changes)
+	}
+
+	author := doc.Books[2].Authors[0]
+	if author.Name != "J. K. Rowling" {
+		t.Errorf("Expected the canonical name, got %q", author.Name)
+	}
+	if author.Identifiers[AuthorSortNameKey] != "Rowling, J. K." || author.Identifiers[AuthorNameKey] != "rowling j k" {
+		t.Errorf("Expected sort name and key in identifiers, got %v", author.Identifiers)
+	}
+	if author.Identifiers["wikidata"] != "Q34660" {
+		t.Errorf("Expected identifiers to be shared across spellings, got %v", author.Identifiers)
+	}
+
+	if changes := NormalizeAuthors(doc, AuthorOptions{}); len(changes) != 0 {
+		t.Errorf("Expected normalization to be idempotent, got %v", changes)
+	}
+}
+
+func TestResolveAuthorsDistinctGivenNames(t *testing.T) {
+	doc := NewDocument()
+	doc.Books = []Book{
+		{ID: "book-1", Title: "One", Authors: []Author{{Name: "John Smith", Identifiers: map[string]string{"viaf": "111"}}}},
+		{ID: "book-2", Title: "Two", Authors: []Author{{Name: "Jane Smith"}}},
+		{ID: "book-3", Title: "Three", Authors: []Author{{Name: "J. Smith"}}},
+		{ID: "book-4", Title: "Four", Authors: []Author{{Name: "Joanne Rowling"}}},
+		{ID: "book-5", Title: "Five", Authors: []Author{{Name: "J.K. Rowling"}}},
+	}
+
+	identities := ResolveAuthors(doc)
+	if len(identities) != 4 {
+		t.Fatalf("Expected 4 identities, got %+v", identities)
+	}
+	for _, identity := range identities {
+		if identity.Name == "Jane Smith" && identity.Identifiers["viaf"] != "" {
+			t.Errorf("Expected identifiers to stay with their author, got %+v", identity)
+		}
+		// "J. Smith" could be either of them
+		if identity.Name == "J. Smith" && len(identity.Variants) != 1 {
+			t.Errorf("Expected the ambiguous spelling to stay apart, got %+v", identity)
+		}
+	}
+
+	NormalizeAuthors(doc, AuthorOptions{Identifiers: true})
+	if doc.Books[0].Authors[0].Name != "John Smith" || doc.Books[1].Authors[0].Name != "Jane Smith" {
+		t.Errorf("Expected different people to keep their names, got %v and %v", doc.Books[0].Authors, doc.Books[1].Authors)
+	}
+	if doc.Books[1].Authors[0].Identifiers["viaf"] != "" {
+		t.Errorf("Expected no identifiers to be copied across people, got %v", doc.Books[1].Authors[0].Identifiers)
+	}
+	if doc.Books[3].Authors[0].Name != doc.Books[4].Authors[0].Name {
+		t.Errorf("Expected initials to join the full name, got %v and %v", doc.Books[3].Authors, doc.Books[4].Authors)
+	}
+}
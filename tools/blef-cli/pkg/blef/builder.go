@@ -11,7 +11,7 @@ import (
 func NewDocument() *BLEFDocument {
 	return &BLEFDocument{
 		Format:      "BLEF",
-		Version:     "0.1.0",
+		Version:     CurrentVersion,
 		ExportedAt:  time.Now().UTC(),
 		Books:       []Book{},
 		Collections: []Collection{},
@@ -98,6 +98,19 @@ func LoadFromFile(filename string) (*BLEFDocument, error) {
 	return FromJSON(data)
 }
 
+// SaveToFile writes the document to a file, streaming it to disk
+func (d *BLEFDocument) SaveToFile(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	if err := WriteDocument(file, d); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return file.Close()
+}
+
 // GetBookByID retrieves a book by its ID
 func (d *BLEFDocument) GetBookByID(id string) *Book {
 	for i := range d.Books {
@@ -0,0 +1,258 @@
+package blef
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// DatePrecision is the most precise part known of a PartialDate
+type DatePrecision int
+
+const (
+	PrecisionNone  DatePrecision = iota // zero or invalid date
+	PrecisionYear                       // 2024
+	PrecisionMonth                      // 2024-03
+	PrecisionDay                        // 2024-03-05
+	PrecisionTime                       // 2024-03-05T18:30:00+01:00
+)
+
+func (p DatePrecision) String() string {
+	switch p {
+	case PrecisionYear:
+		return "year"
+	case PrecisionMonth:
+		return "month"
+	case PrecisionDay:
+		return "day"
+	case PrecisionTime:
+		return "time"
+	default:
+		return "none"
+	}
+}
+
+// PartialDate is a date known to the year, the month, the day or the time
+// of day. It is written in JSON as an ISO 8601 string: "2024", "2024-03",
+// "2024-03-05" or an RFC 3339 date-time.
+//
+// Text that is not an ISO 8601 date is kept as is, so that documents with
+// malformed dates can still be read, validated and repaired; such a date is
+// not valid and has no precision. The zero value is an unknown date, omitted
+// from JSON by fields tagged omitzero. PartialDate values can be compared
+// with ==. Published dates can have any precision, but read and loan dates
+// must be known to the day (see ReadDate).
+type PartialDate struct {
+	year, month, day            int
+	hour, minute, second, nanos int
+	offset                      int // seconds east of UTC, for PrecisionTime
+	precision                   DatePrecision
+	invalid                     string
+}
+
+// NewYear returns a date known to the year
+func NewYear(year int) PartialDate {
+	return PartialDate{year: year, precision: PrecisionYear}
+}
+
+// NewYearMonth returns a date known to the month
+func NewYearMonth(year int, month time.Month) PartialDate {
+	return PartialDate{year: year, month: int(month), precision: PrecisionMonth}
+}
+
+// DateOf returns the calendar date of t, in the location of t
+func DateOf(t time.Time) PartialDate {
+	return PartialDate{year: t.Year(), month: int(t.Month()), day: t.Day(), precision: PrecisionDay}
+}
+
+// DateTimeOf returns the date and time of day of t, with its UTC offset
+func DateTimeOf(t time.Time) PartialDate {
+	d := DateOf(t)
+	d.hour, d.minute, d.second, d.nanos = t.Hour(), t.Minute(), t.Second(), t.Nanosecond()
+	_, d.offset = t.Zone()
+	d.precision = PrecisionTime
+	return d
+}
+
+var isoDateRegex = regexp.MustCompile(`^(\d{4})(?:-(\d{2})(?:-(\d{2}))?)?$`)
+
+// ParseDate parses a strict ISO 8601 date: "YYYY", "YYYY-MM", "YYYY-MM-DD"
+// or an RFC 3339 date-time such as "2024-03-05T18:30:00Z"
+func ParseDate(s string) (PartialDate, error) {
+	if s == "" {
+		return PartialDate{}, nil
+	}
+	if len(s) > 10 && (s[10] == 'T' || s[10] == 't') {
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return PartialDate{}, fmt.Errorf("invalid ISO 8601 date-time %q", s)
+		}
+		return DateTimeOf(t), nil
+	}
+
+	match := isoDateRegex.FindStringSubmatch(s)
+	if match == nil {
+		return PartialDate{}, fmt.Errorf("invalid ISO 8601 date %q (expected YYYY, YYYY-MM or YYYY-MM-DD)", s)
+	}
+	year, _ := strconv.Atoi(match[1])
+	month, _ := strconv.Atoi(match[2])
+	day, _ := strconv.Atoi(match[3])
+	switch {
+	case match[2] == "":
+		return NewYear(year), nil
+	case match[3] == "":
+		if month < 1 || month > 12 {
+			return PartialDate{}, fmt.Errorf("invalid month in date %q", s)
+		}
+		return NewYearMonth(year, time.Month(month)), nil
+	}
+	return newDate(year, month, day, s)
+}
+
+// newDate checks a calendar date
+func newDate(year, month, day int, s string) (PartialDate, error) {
+	t := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if t.Year() != year || int(t.Month()) != month || t.Day() != day {
+		return PartialDate{}, fmt.Errorf("invalid day in date %q", s)
+	}
+	return DateOf(t), nil
+}
+
+// MustParseDate is like ParseDate but panics on error. It is meant for
+// constant dates.
+func MustParseDate(s string) PartialDate {
+	d, err := ParseDate(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// IsZero reports whether the date is unknown
+func (d PartialDate) IsZero() bool {
+	return d.precision == PrecisionNone && d.invalid == ""
+}
+
+// IsValid reports whether the date is known and was an ISO 8601 date
+func (d PartialDate) IsValid() bool {
+	return d.precision != PrecisionNone
+}
+
+// Precision returns the most precise part known of the date
+func (d PartialDate) Precision() DatePrecision {
+	return d.precision
+}
+
+// Year returns the year, 0 if unknown
+func (d PartialDate) Year() int {
+	return d.year
+}
+
+// Month returns the month, 0 if unknown
+func (d PartialDate) Month() time.Month {
+	return time.Month(d.month)
+}
+
+// Day returns the day of the month, 0 if unknown
+func (d PartialDate) Day() int {
+	return d.day
+}
+
+// Time returns the start of the date: midnight UTC on its first day, or the
+// exact instant for a date-time. It returns the zero time for an invalid date.
+func (d PartialDate) Time() time.Time {
+	if !d.IsValid() {
+		return time.Time{}
+	}
+	month, day := max(d.month, 1), max(d.day, 1)
+	if d.precision < PrecisionTime {
+		return time.Date(d.year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	}
+	location := time.UTC
+	if d.offset != 0 {
+		location = time.FixedZone("", d.offset)
+	}
+	return time.Date(d.year, time.Month(month), day, d.hour, d.minute, d.second, d.nanos, location)
+}
+
+// Truncate drops the parts of the date more precise than p
+func (d PartialDate) Truncate(p DatePrecision) PartialDate {
+	if !d.IsValid() || d.precision <= p {
+		return d
+	}
+	switch p {
+	case PrecisionYear:
+		return NewYear(d.year)
+	case PrecisionMonth:
+		return NewYearMonth(d.year, time.Month(d.month))
+	case PrecisionDay:
+		return PartialDate{year: d.year, month: d.month, day: d.day, precision: PrecisionDay}
+	}
+	return PartialDate{}
+}
+
+// Compare compares two dates at the precision they have in common:
+// "2024-03" and "2024-03-05" are equal, "2024-02" is before "2024-03-05".
+// Date-times are compared as instants. Unknown and invalid dates sort first.
+// It returns -1, 0 or +1.
+func (d PartialDate) Compare(other PartialDate) int {
+	switch {
+	case !d.IsValid() && !other.IsValid():
+		return 0
+	case !d.IsValid():
+		return -1
+	case !other.IsValid():
+		return 1
+	}
+	precision := min(d.precision, other.precision)
+	return d.Truncate(precision).Time().Compare(other.Truncate(precision).Time())
+}
+
+// Before reports whether d is before other, at their common precision
+func (d PartialDate) Before(other PartialDate) bool {
+	return d.Compare(other) < 0
+}
+
+// After reports whether d is after other, at their common precision
+func (d PartialDate) After(other PartialDate) bool {
+	return d.Compare(other) > 0
+}
+
+// String returns the date in ISO 8601 form, or the original text of an
+// invalid date
+func (d PartialDate) String() string {
+	switch d.precision {
+	case PrecisionYear:
+		return fmt.Sprintf("%04d", d.year)
+	case PrecisionMonth:
+		return fmt.Sprintf("%04d-%02d", d.year, d.month)
+	case PrecisionDay:
+		return fmt.Sprintf("%04d-%02d-%02d", d.year, d.month, d.day)
+	case PrecisionTime:
+		return d.Time().Format(time.RFC3339Nano)
+	default:
+		return d.invalid
+	}
+}
+
+// MarshalJSON writes the date as an ISO 8601 string
+func (d PartialDate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON reads a date string. Strings that are not ISO 8601 dates
+// are kept as invalid dates rather than rejected.
+func (d *PartialDate) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("date must be a string: %w", err)
+	}
+	parsed, err := ParseDate(s)
+	if err != nil {
+		parsed = PartialDate{invalid: s}
+	}
+	*d = parsed
+	return nil
+}
@@ -0,0 +1,233 @@
+package blef
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ErrAmbiguousDate is returned for numeric dates whose day and month could be
+// swapped when the date order is unknown
+var ErrAmbiguousDate = errors.New("ambiguous date")
+
+// DateOrder tells how to read numeric dates such as 05/03/2024
+type DateOrder int
+
+const (
+	// AmbiguousDates only accepts numeric dates that cannot be misread,
+	// such as 25/03/2024
+	AmbiguousDates DateOrder = iota
+	// DayFirst reads 05/03/2024 as 5 March 2024
+	DayFirst
+	// MonthFirst reads 05/03/2024 as May 3, 2024
+	MonthFirst
+)
+
+func (o DateOrder) String() string {
+	switch o {
+	case DayFirst:
+		return "day-first"
+	case MonthFirst:
+		return "month-first"
+	default:
+		return "unambiguous"
+	}
+}
+
+// ParseDateOrder parses "day-first" or "month-first"
+func ParseDateOrder(s string) (DateOrder, error) {
+	switch strings.ToLower(s) {
+	case "day-first", "dmy":
+		return DayFirst, nil
+	case "month-first", "mdy":
+		return MonthFirst, nil
+	case "", "unambiguous":
+		return AmbiguousDates, nil
+	}
+	return AmbiguousDates, fmt.Errorf("unknown date order %q (expected day-first or month-first)", s)
+}
+
+// monthFirstRegions write numeric dates month first
+var monthFirstRegions = map[string]bool{"US": true, "PH": true, "FM": true, "MH": true, "PW": true}
+
+// DateOrderForLocale returns the usual date order of a locale such as
+// "en-US" or "fr_FR". A bare "en" is read as American English.
+func DateOrderForLocale(locale string) DateOrder {
+	language, region := splitLocale(locale)
+	if monthFirstRegions[region] || (language == "en" && region == "") {
+		return MonthFirst
+	}
+	return DayFirst
+}
+
+func splitLocale(locale string) (language, region string) {
+	language, region, _ = strings.Cut(strings.ReplaceAll(locale, "_", "-"), "-")
+	return strings.ToLower(language), strings.ToUpper(region)
+}
+
+// monthNames are the month names of the supported languages, without
+// diacritics. Abbreviations are matched as prefixes.
+var monthNames = map[string][12]string{
+	"en": {"january", "february", "march", "april", "may", "june", "july", "august", "september", "october", "november", "december"},
+	"fr": {"janvier", "fevrier", "mars", "avril", "mai", "juin", "juillet", "aout", "septembre", "octobre", "novembre", "decembre"},
+	"de": {"januar", "februar", "marz", "april", "mai", "juni", "juli", "august", "september", "oktober", "november", "dezember"},
+	"es": {"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+	"it": {"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+}
+
+// DateParser reads the dates found in exports of other tools. ISO 8601
+// dates are always accepted; numeric dates follow Order, and month names are
+// read in English and in the language of Locale.
+type DateParser struct {
+	Order  DateOrder
+	Locale string
+}
+
+// NewDateParser returns a parser with the date order and month names of a
+// locale
+func NewDateParser(locale string) DateParser {
+	return DateParser{Order: DateOrderForLocale(locale), Locale: locale}
+}
+
+var (
+	// 2024-03-05 15:04:05, 2024-03-05T15:04:05: local times, read as UTC
+	localTimeRegex = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})[T ](\d{2}):(\d{2})(?::(\d{2}))?$`)
+	// 2024/03/05, 2024.3.5, 2024/03
+	yearFirstRegex = regexp.MustCompile(`^(\d{4})[/.-](\d{1,2})(?:[/.-](\d{1,2}))?$`)
+	// 05/03/2024, 5.3.2024, 05-03-2024
+	numericRegex = regexp.MustCompile(`^(\d{1,2})[/.-](\d{1,2})[/.-](\d{4})$`)
+	// 03/2024
+	monthYearRegex = regexp.MustCompile(`^(\d{1,2})[/.-](\d{4})$`)
+	// 20240305
+	compactRegex = regexp.MustCompile(`^(\d{4})(\d{2})(\d{2})$`)
+	// 1st, 2nd, 1er
+	ordinalRegex = regexp.MustCompile(`^(\d{1,2})(?:st|nd|rd|th|er|e|º|o)?$`)
+)
+
+// Parse reads a date. Numeric dates whose day and month could be swapped
+// are rejected unless Order says how to read them, and dates that contradict
+// Order, such as 13/05/2024 month first, are rejected.
+func (p DateParser) Parse(s string) (PartialDate, error) {
+	s = strings.TrimSpace(s)
+	if d, err := ParseDate(s); err == nil {
+		return d, nil
+	}
+
+	if m := localTimeRegex.FindStringSubmatch(s); m != nil {
+		if m[4] == "" {
+			m[4] = "00"
+		}
+		t, err := time.Parse("2006-01-02 15:04:05", fmt.Sprintf("%s %s:%s:%s", m[1], m[2], m[3], m[4]))
+		if err != nil {
+			return PartialDate{}, fmt.Errorf("invalid date %q", s)
+		}
+		return DateTimeOf(t), nil
+	}
+	if m := yearFirstRegex.FindStringSubmatch(s); m != nil {
+		return numericDate(m[1], m[2], m[3], s)
+	}
+	if m := compactRegex.FindStringSubmatch(s); m != nil {
+		return numericDate(m[1], m[2], m[3], s)
+	}
+	if m := monthYearRegex.FindStringSubmatch(s); m != nil {
+		return numericDate(m[2], m[1], "", s)
+	}
+	if m := numericRegex.FindStringSubmatch(s); m != nil {
+		first, _ := strconv.Atoi(m[1])
+		second, _ := strconv.Atoi(m[2])
+		order := p.Order
+		if order == AmbiguousDates {
+			switch {
+			case first > 12 && second <= 12:
+				order = DayFirst
+			case second > 12 && first <= 12:
+				order = MonthFirst
+			case first != second:
+				return PartialDate{}, fmt.Errorf("%w %q: day and month order is unknown", ErrAmbiguousDate, s)
+			default:
+				order = DayFirst
+			}
+		}
+		if order == DayFirst {
+			return numericDate(m[3], m[2], m[1], s)
+		}
+		return numericDate(m[3], m[1], m[2], s)
+	}
+	return p.parseWords(s)
+}
+
+func numericDate(year, month, day, s string) (PartialDate, error) {
+	y, _ := strconv.Atoi(year)
+	m, _ := strconv.Atoi(month)
+	if m < 1 || m > 12 {
+		return PartialDate{}, fmt.Errorf("invalid month in date %q", s)
+	}
+	if day == "" {
+		return NewYearMonth(y, time.Month(m)), nil
+	}
+	d, _ := strconv.Atoi(day)
+	return newDate(y, m, d, s)
+}
+
+// parseWords reads dates with a month name: "March 5, 2024", "5 mars 2024",
+// "1er janv. 2024" or "March 2024"
+func (p DateParser) parseWords(s string) (PartialDate, error) {
+	words := strings.FieldsFunc(FoldText(s), func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	year, month, day := 0, 0, 0
+	for _, word := range words {
+		if n, err := strconv.Atoi(word); err == nil && len(word) == 4 && year == 0 {
+			year = n
+			continue
+		}
+		if m := ordinalRegex.FindStringSubmatch(word); m != nil && day == 0 {
+			day, _ = strconv.Atoi(m[1])
+			continue
+		}
+		if m := p.month(word); m != 0 && month == 0 {
+			month = m
+			continue
+		}
+		return PartialDate{}, fmt.Errorf("invalid date %q", s)
+	}
+
+	switch {
+	case year == 0 || month == 0:
+		return PartialDate{}, fmt.Errorf("invalid date %q", s)
+	case day == 0:
+		return NewYearMonth(year, time.Month(month)), nil
+	}
+	return newDate(year, month, day, s)
+}
+
+// month returns the month named by word in English or in the parser's
+// language, 0 if none. Prefixes of at least three letters must name a single
+// month.
+func (p DateParser) month(word string) int {
+	languages := []string{"en"}
+	if language, _ := splitLocale(p.Locale); language != "" && language != "en" {
+		languages = append(languages, language)
+	}
+	for _, language := range languages {
+		found := 0
+		for i, name := range monthNames[language] {
+			if word == name {
+				return i + 1
+			}
+			if len(word) >= 3 && strings.HasPrefix(name, word) {
+				if found != 0 {
+					found = -1
+					break
+				}
+				found = i + 1
+			}
+		}
+		if found > 0 {
+			return found
+		}
+	}
+	return 0
+}
@@ -0,0 +1,176 @@
+package blef
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// invalidDate returns a date read from malformed text, as UnmarshalJSON does
+func invalidDate(s string) PartialDate {
+	return PartialDate{invalid: s}
+}
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		input     string
+		expected  string
+		precision DatePrecision
+		ok        bool
+	}{
+		{"2024", "2024", PrecisionYear, true},
+		{"2024-03", "2024-03", PrecisionMonth, true},
+		{"2024-03-05", "2024-03-05", PrecisionDay, true},
+		{"2024-03-05T18:30:00+01:00", "2024-03-05T18:30:00+01:00", PrecisionTime, true},
+		{"2024-03-05T18:30:00.5Z", "2024-03-05T18:30:00.5Z", PrecisionTime, true},
+		{"", "", PrecisionNone, true},
+		{"2024-13", "", PrecisionNone, false},
+		{"2024-02-30", "", PrecisionNone, false},
+		{"2024-3-5", "", PrecisionNone, false},
+		{"24", "", PrecisionNone, false},
+		{"2024-03-05T18:30", "", PrecisionNone, false},
+		{"2024/03/05", "", PrecisionNone, false},
+	}
+
+	for _, tt := range tests {
+		date, err := ParseDate(tt.input)
+		if (err == nil) != tt.ok || date.String() != tt.expected || date.Precision() != tt.precision {
+			t.Errorf("ParseDate(%q) = %q (%s), %v, want %q (%s)", tt.input, date, date.Precision(), err, tt.expected, tt.precision)
+		}
+	}
+}
+
+func TestPartialDateJSON(t *testing.T) {
+	var readDate ReadDate
+	if err := json.Unmarshal([]byte(`{"started":"2024-03","finished":"03/04/2024"}`), &readDate); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if readDate.Started != NewYearMonth(2024, time.March) {
+		t.Errorf("Expected started 2024-03, got %v", readDate.Started)
+	}
+	if readDate.Finished.IsValid() || readDate.Finished.IsZero() {
+		t.Errorf("Expected an invalid finished date, got %v", readDate.Finished)
+	}
+
+	// Malformed dates are written back as they were read, unknown ones omitted
+	readDate.Started = PartialDate{}
+	data, err := json.Marshal(readDate)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if string(data) != `{"finished":"03/04/2024"}` {
+		t.Errorf("Unexpected JSON %s", data)
+	}
+
+	if err := json.Unmarshal([]byte(`{"started":20240305}`), &readDate); err == nil {
+		t.Error("Expected an error for a date that is not a string")
+	}
+}
+
+func TestPartialDateCompare(t *testing.T) {
+	tests := []struct {
+		a, b     PartialDate
+		expected int
+	}{
+		{MustParseDate("2024-03-05"), MustParseDate("2024-03-06"), -1},
+		{MustParseDate("2024-03"), MustParseDate("2024-03-05"), 0},
+		{MustParseDate("2024"), MustParseDate("2023-12-31"), 1},
+		{MustParseDate("2024-02"), MustParseDate("2024-03-05"), -1},
+		{MustParseDate("2024-03-05T23:30:00-02:00"), MustParseDate("2024-03-06T00:30:00Z"), 1},
+		{MustParseDate("2024-03-05T10:00:00Z"), MustParseDate("2024-03-05"), 0},
+		{PartialDate{}, MustParseDate("1900"), -1},
+		{invalidDate("soon"), PartialDate{}, 0},
+	}
+
+	for _, tt := range tests {
+		if got := tt.a.Compare(tt.b); got != tt.expected {
+			t.Errorf("%q.Compare(%q) = %d, want %d", tt.a, tt.b, got, tt.expected)
+		}
+	}
+}
+
+func TestPartialDateTime(t *testing.T) {
+	if got := MustParseDate("2024-03").Time(); !got.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the first day of the month, got %v", got)
+	}
+	if got := MustParseDate("2024-03-05T18:30:00+01:00").Time(); !got.Equal(time.Date(2024, 3, 5, 17, 30, 0, 0, time.UTC)) {
+		t.Errorf("Expected the instant of the date-time, got %v", got)
+	}
+	if got := MustParseDate("2024-03-05T18:30:00+01:00").Truncate(PrecisionDay); got != MustParseDate("2024-03-05") {
+		t.Errorf("Expected the date-time truncated to its day, got %v", got)
+	}
+	if got := DateOf(time.Date(2024, 3, 5, 23, 0, 0, 0, time.FixedZone("", -5*3600))); got.String() != "2024-03-05" {
+		t.Errorf("Expected the calendar date in the location of the time, got %v", got)
+	}
+}
+
+func TestDateParser(t *testing.T) {
+	tests := []struct {
+		parser   DateParser
+		input    string
+		expected string
+	}{
+		// ISO 8601 and other year-first dates do not depend on the order
+		{DateParser{}, "2024-03-05", "2024-03-05"},
+		{DateParser{}, "2024/03/05", "2024-03-05"},
+		{DateParser{}, "2024.3.5", "2024-03-05"},
+		{DateParser{}, "20240305", "2024-03-05"},
+		{DateParser{}, "2024/03", "2024-03"},
+		{DateParser{}, "2024-03-05 18:30:00", "2024-03-05T18:30:00Z"},
+
+		// Numeric dates follow the order, or must be unambiguous
+		{DateParser{Order: DayFirst}, "05/03/2024", "2024-03-05"},
+		{DateParser{Order: MonthFirst}, "05/03/2024", "2024-05-03"},
+		{DateParser{}, "25/03/2024", "2024-03-25"},
+		{DateParser{}, "03/25/2024", "2024-03-25"},
+		{DateParser{}, "03/03/2024", "2024-03-03"},
+		{DateParser{}, "03/2024", "2024-03"},
+		{DateParser{}, "05/03/2024", ""},
+		{DateParser{Order: MonthFirst}, "25/03/2024", ""},
+		{DateParser{Order: DayFirst}, "03/25/2024", ""},
+		{DateParser{Order: DayFirst}, "05/03/24", ""},
+
+		// Month names in English and in the language of the locale
+		{DateParser{}, "March 5, 2024", "2024-03-05"},
+		{DateParser{}, "Mar 5th 2024", "2024-03-05"},
+		{DateParser{}, "5 Sept. 2024", "2024-09-05"},
+		{DateParser{}, "March 2024", "2024-03"},
+		{NewDateParser("fr-FR"), "1er février 2024", "2024-02-01"},
+		{NewDateParser("fr-FR"), "5 déc. 2024", "2024-12-05"},
+		{NewDateParser("de-DE"), "5. März 2024", "2024-03-05"},
+		{DateParser{}, "5 février 2024", ""},
+		{NewDateParser("fr-FR"), "5 ju 2024", ""},
+		{NewDateParser("fr-FR"), "5 jui 2024", ""},
+		{DateParser{}, "February 30, 2024", ""},
+		{DateParser{}, "soon", ""},
+	}
+
+	for _, tt := range tests {
+		date, err := tt.parser.Parse(tt.input)
+		if tt.expected == "" {
+			if err == nil {
+				t.Errorf("%+v.Parse(%q) = %q, want an error", tt.parser, tt.input, date)
+			}
+			continue
+		}
+		if err != nil || date.String() != tt.expected {
+			t.Errorf("%+v.Parse(%q) = %q, %v, want %q", tt.parser, tt.input, date, err, tt.expected)
+		}
+	}
+}
+
+func TestDateOrderForLocale(t *testing.T) {
+	tests := map[string]DateOrder{
+		"en":    MonthFirst,
+		"en-US": MonthFirst,
+		"en_GB": DayFirst,
+		"fr-FR": DayFirst,
+		"de":    DayFirst,
+		"":      DayFirst,
+	}
+	for locale, expected := range tests {
+		if got := DateOrderForLocale(locale); got != expected {
+			t.Errorf("DateOrderForLocale(%q) = %s, want %s", locale, got, expected)
+		}
+	}
+}
@@ -0,0 +1,452 @@
+package blef
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// DuplicateIDsKey is the book metadata key listing the IDs of the duplicates
+// merged into a book
+const DuplicateIDsKey = "duplicate_ids"
+
+// DuplicateOptions configures duplicate detection
+type DuplicateOptions struct {
+	// MinConfidence is the lowest score, from 0 to 1, for two books to be
+	// reported as duplicates
+	MinConfidence float64
+}
+
+// DefaultDuplicateOptions returns the options used by the dedupe command
+func DefaultDuplicateOptions() DuplicateOptions {
+	return DuplicateOptions{MinConfidence: 0.8}
+}
+
+// DuplicateLink explains why two books are considered duplicates
+type DuplicateLink struct {
+	Left, Right string
+	Score       float64
+	Reason      string
+}
+
+// DuplicateCluster is a group of books that look like the same work. The
+// first book ID is the suggested book to keep.
+type DuplicateCluster struct {
+	BookIDs []string
+	// Confidence is the lowest score of the links joining the cluster
+	Confidence float64
+	Links      []DuplicateLink
+}
+
+// duplicateCandidate holds the normalized keys of a book
+type duplicateCandidate struct {
+	book    *Book
+	title   string   // normalized title
+	main    string   // normalized title before any colon
+	numbers []string // numbers in the title, such as a volume or a year
+	volume  string   // normalized series volume
+	authors []AuthorName
+}
+
+// numberRegex matches the numbers of a normalized title
+var numberRegex = regexp.MustCompile(`\d+`)
+
+// FindDuplicates clusters the books of a document that share an identifier
+// or have similar titles and authors. Clusters are ordered by the position
+// of their first book in the document.
+func FindDuplicates(doc *BLEFDocument, opts DuplicateOptions) []DuplicateCluster {
+	candidates := make([]duplicateCandidate, len(doc.Books))
+	for i := range doc.Books {
+		book := &doc.Books[i]
+		title := NormalizeTitle(book.Title)
+		candidates[i] = duplicateCandidate{
+			book:    book,
+			title:   title,
+			main:    NormalizeTitle(strings.SplitN(book.Title, ":", 2)[0]),
+			numbers: titleNumbers(title),
+			authors: parseAuthors(book.Authors),
+		}
+		if book.Series != nil && book.Series.Volume != nil {
+			candidates[i].volume = FoldText(fmt.Sprint(book.Series.Volume))
+		}
+	}
+
+	var links []DuplicateLink
+	linked := make(map[[2]int]bool)
+	link := func(i, j int, score float64, reason string) {
+		if i > j {
+			i, j = j, i
+		}
+		if i == j || linked[[2]int{i, j}] || score < opts.MinConfidence {
+			return
+		}
+		linked[[2]int{i, j}] = true
+		links = append(links, DuplicateLink{Left: doc.Books[i].ID, Right: doc.Books[j].ID, Score: score, Reason: reason})
+	}
+
+	// Shared identifiers are certain
+	seen := make(map[string]int)
+	for i := range doc.Books {
+		for _, key := range duplicateIdentifiers(&doc.Books[i]) {
+			if j, ok := seen[key]; ok {
+				scheme, value, _ := strings.Cut(key, ":")
+				link(j, i, 1, fmt.Sprintf("same %s %s", scheme, value))
+				continue
+			}
+			seen[key] = i
+		}
+	}
+
+	for i := range candidates {
+		for j := i + 1; j < len(candidates); j++ {
+			if linked[[2]int{i, j}] {
+				continue
+			}
+			if score, reason := scoreDuplicate(&candidates[i], &candidates[j]); score > 0 {
+				link(i, j, score, reason)
+			}
+		}
+	}
+
+	return clusterDuplicates(doc, links)
+}
+
+// duplicateIdentifiers returns the identifiers of a book as "scheme:value"
+// keys, with every ISBN in its ISBN-13 form
+func duplicateIdentifiers(book *Book) []string {
+	var keys []string
+	for _, value := range []string{book.ID, book.Identifiers.ISBN13, book.Identifiers.ISBN10} {
+		if isbn13, _, ok := normalizeBookID(value); ok && !containsString(keys, "isbn:"+isbn13) {
+			keys = append(keys, "isbn:"+isbn13)
+		}
+	}
+	for key := range otherIdentifiers(book) {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// titleNumbers returns the numbers of a normalized title without leading
+// zeros
+func titleNumbers(title string) []string {
+	numbers := numberRegex.FindAllString(title, -1)
+	for i, number := range numbers {
+		if trimmed := strings.TrimLeft(number, "0"); trimmed != "" {
+			numbers[i] = trimmed
+		} else {
+			numbers[i] = "0"
+		}
+	}
+	return numbers
+}
+
+// scoreDuplicate returns how likely two books are the same work. Titles
+// weigh 70% and authors 30%; books without authors get a neutral author
+// score, so an identical title alone scores 0.85. Books whose authors are
+// known and differ are never duplicates, and neither are books whose titles
+// have different numbers or whose series volumes differ, such as two volumes
+// of a collection.
+func scoreDuplicate(a, b *duplicateCandidate) (float64, string) {
+	if a.title == "" || b.title == "" {
+		return 0, ""
+	}
+	if strings.Join(a.numbers, " ") != strings.Join(b.numbers, " ") ||
+		a.volume != "" && b.volume != "" && a.volume != b.volume {
+		return 0, ""
+	}
+	title := similarity(a.title, b.title)
+	if a.main != a.title || b.main != b.title {
+		title = max(title, similarity(a.main, b.main)*0.95)
+	}
+	if title < 0.8 {
+		return 0, ""
+	}
+
+	author := 0.5
+	if len(a.authors) > 0 && len(b.authors) > 0 {
+		author = 0
+		for _, x := range a.authors {
+			for _, y := range b.authors {
+				author = max(author, authorSimilarity(x, y))
+			}
+		}
+		// Same title by different authors, such as "Collected Poems"
+		if author < 0.5 {
+			return 0, ""
+		}
+	}
+
+	reason := fmt.Sprintf("title %.0f%% similar", title*100)
+	if title == 1 {
+		reason = "same title"
+	}
+	switch {
+	case author == 1:
+		reason += ", same author"
+	case author != 0.5:
+		reason += fmt.Sprintf(", author %.0f%% similar", author*100)
+	}
+	return 0.7*title + 0.3*author, reason
+}
+
+// clusterDuplicates joins linked books into clusters
+func clusterDuplicates(doc *BLEFDocument, links []DuplicateLink) []DuplicateCluster {
+	position := make(map[string]int, len(doc.Books))
+	for i, book := range doc.Books {
+		position[book.ID] = i
+	}
+
+	parent := make([]int, len(doc.Books))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for _, link := range links {
+		a, b := find(position[link.Left]), find(position[link.Right])
+		parent[max(a, b)] = min(a, b)
+	}
+
+	clusters := make(map[int]*DuplicateCluster)
+	var roots []int
+	for _, link := range links {
+		root := find(position[link.Left])
+		cluster, ok := clusters[root]
+		if !ok {
+			cluster = &DuplicateCluster{Confidence: 1}
+			clusters[root] = cluster
+			roots = append(roots, root)
+		}
+		cluster.Links = append(cluster.Links, link)
+		cluster.Confidence = min(cluster.Confidence, link.Score)
+	}
+	sort.Ints(roots)
+
+	lib := NewLibrary(doc)
+	result := make([]DuplicateCluster, 0, len(roots))
+	for _, root := range roots {
+		cluster := clusters[root]
+		for i := range doc.Books {
+			if find(i) == root {
+				cluster.BookIDs = append(cluster.BookIDs, doc.Books[i].ID)
+			}
+		}
+		// The best book to keep comes first, ties keep document order
+		sort.SliceStable(cluster.BookIDs, func(i, j int) bool {
+			return keeperScore(lib, cluster.BookIDs[i]) > keeperScore(lib, cluster.BookIDs[j])
+		})
+		result = append(result, *cluster)
+	}
+	return result
+}
+
+// keeperScore ranks the books of a cluster: ISBN IDs first, then books with
+// an entry, then the most complete ones
+func keeperScore(lib *Library, id string) int {
+	book := lib.GetBookByID(id)
+	score := 0
+	if isbn13, _, ok := normalizeBookID(id); ok && isbn13 == id {
+		score += 100
+	}
+	if lib.GetEntryForBook(id) != nil {
+		score += 50
+	}
+	for _, value := range []interface{}{book.Subtitle, book.Authors, book.Language, book.Description,
+		book.CoverURL, book.Edition, book.Series, book.Subjects} {
+		if !isEmptyValue(value) {
+			score++
+		}
+	}
+	return score
+}
+
+// MergeDuplicates folds every cluster into its first book. Book fields and
+// entries are reconciled like in Merge, and the merged book records the IDs
+// of its duplicates under DuplicateIDsKey. An entry that cannot be merged,
+// for instance because it references a missing collection, fails the merge.
+// The document is not modified.
+func MergeDuplicates(doc *BLEFDocument, clusters []DuplicateCluster, opts MergeOptions) (*MergeResult, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	merged, err := cloneDocument(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &merger{
+		opts:   opts,
+		lib:    NewLibrary(merged),
+		result: &MergeResult{Document: merged},
+		bookID: make(map[string]string),
+		newest: make(map[string]time.Time),
+	}
+	m.indexIdentifiers()
+	for _, cluster := range clusters {
+		if len(cluster.BookIDs) < 2 || m.lib.GetBookByID(cluster.BookIDs[0]) == nil {
+			return nil, fmt.Errorf("invalid duplicate cluster %v", cluster.BookIDs)
+		}
+		for _, id := range cluster.BookIDs[1:] {
+			if err := m.mergeDuplicate(cluster.BookIDs[0], id); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return m.result, nil
+}
+
+func (m *merger) mergeDuplicate(keepID, id string) error {
+	duplicate := m.lib.GetBookByID(id)
+	if duplicate == nil {
+		return fmt.Errorf("book with ID %s does not exist", id)
+	}
+	book := *duplicate
+	entries := m.lib.GetEntriesForBook(id)
+	if err := m.lib.RemoveBook(id); err != nil {
+		return err
+	}
+
+	keep := m.lib.GetBookByID(keepID)
+	m.bookID[id] = keepID
+	m.mergeBookInto(keep, &book)
+	m.result.BooksMatched++
+
+	keep = m.lib.GetBookByID(keepID)
+	if keep.Metadata == nil {
+		keep.Metadata = make(map[string]interface{})
+	}
+	ids, _ := keep.Metadata[DuplicateIDsKey].([]interface{})
+	keep.Metadata[DuplicateIDsKey] = append(ids, id)
+
+	for i := range entries {
+		if err := m.mergeEntry(&entries[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Text normalization
+
+// titleArticles are the leading articles ignored when comparing titles
+var titleArticles = map[string]bool{
+	"the": true, "a": true, "an": true,
+	"le": true, "la": true, "les": true, "l": true, "un": true, "une": true,
+	"der": true, "die": true, "das": true, "ein": true, "eine": true,
+	"el": true, "los": true, "las": true, "il": true, "lo": true, "gli": true,
+}
+
+var foldReplacer = strings.NewReplacer("œ", "oe", "æ", "ae", "ß", "ss", "ø", "o", "ł", "l", "đ", "d")
+
+// FoldText lowercases a string, removes diacritics and replaces punctuation
+// by single spaces, so "L'Étranger" and "l etranger" compare equal
+func FoldText(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(t, strings.ToLower(s))
+	if err != nil {
+		folded = strings.ToLower(s)
+	}
+	folded = foldReplacer.Replace(folded)
+
+	return strings.Join(strings.FieldsFunc(folded, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// NormalizeTitle folds a title, drops bracketed parts such as the
+// "(Harry Potter, #4)" suffix of Goodreads exports, and strips a leading
+// article
+func NormalizeTitle(title string) string {
+	var b strings.Builder
+	depth := 0
+	for _, r := range title {
+		switch r {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			if depth > 0 {
+				depth--
+			}
+		default:
+			if depth == 0 {
+				b.WriteRune(r)
+			}
+		}
+	}
+
+	words := strings.Fields(FoldText(b.String()))
+	if len(words) > 1 && titleArticles[words[0]] {
+		words = words[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+// parseAuthors returns the parsed names of the authors, see ParseAuthorName
+func parseAuthors(authors []Author) []AuthorName {
+	var names []AuthorName
+	for _, author := range authors {
+		if name := ParseAuthorName(author.Name); name.Key() != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// authorSimilarity compares two author names by family name, allowing for
+// typos, and is 0 when their given names cannot be the same person's (see
+// AuthorName.Compatible)
+func authorSimilarity(a, b AuthorName) float64 {
+	if !givenNamesCompatible(strings.Fields(FoldText(a.Given)), strings.Fields(FoldText(b.Given))) {
+		return 0
+	}
+	return similarity(a.familyKey(), b.familyKey())
+}
+
+// similarity returns 1 minus the Levenshtein distance of two strings divided
+// by the length of the longest
+func similarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	x, y := []rune(a), []rune(b)
+	longest := max(len(x), len(y))
+	// The distance is at least the length difference
+	if float64(longest-min(len(x), len(y)))/float64(longest) > 0.5 {
+		return 0
+	}
+	return 1 - float64(levenshtein(x, y))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
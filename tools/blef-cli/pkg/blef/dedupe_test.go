@@ -0,0 +1,157 @@
+package blef
+
+import "testing"
+
+func TestNormalizeTitle(t *testing.T) {
+	tests := []struct {
+		input, want string
+	}{
+		{"The Hobbit", "hobbit"},
+		{"L'Étranger", "etranger"},
+		{"Harry Potter and the Goblet of Fire (Harry Potter, #4)", "harry potter and the goblet of fire"},
+		{"Cœur de pirate", "coeur de pirate"},
+		{"  A  ", "a"},
+		{"Die Verwandlung", "verwandlung"},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeTitle(tt.input); got != tt.want {
+			t.Errorf("NormalizeTitle(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	if got := similarity("kitten", "sitting"); got < 0.57 || got > 0.58 {
+		t.Errorf("Expected 1 - 3/7, got %f", got)
+	}
+	if got := similarity("abc", "abc"); got != 1 {
+		t.Errorf("Expected identical strings to score 1, got %f", got)
+	}
+	if got := similarity("a", "abcdef"); got != 0 {
+		t.Errorf("Expected very different lengths to score 0, got %f", got)
+	}
+}
+
+func dedupeTestDocument() *BLEFDocument {
+	doc := NewDocument()
+	doc.Books = []Book{
+		{ID: "f47ac10b-58cc-4372-a567-0e02b2c3d479", Title: "Le Petit Prince (French Edition)", Authors: []Author{{Name: "Saint-Exupéry, Antoine de"}}},
+		{ID: "9780156013987", Title: "The Little Prince", Authors: []Author{{Name: "Antoine de Saint-Exupéry"}}, Identifiers: Identifiers{ISBN13: "9780156013987"}},
+		{ID: "9782070612758", Title: "Le petit prince", Authors: []Author{{Name: "Antoine de Saint-Exupery"}}, Edition: &Edition{Publisher: "Gallimard"}},
+		{ID: "6ba7b810-9dad-41d1-80b4-00c04fd430c8", Title: "The Little Prince: 75th Anniversary", Authors: []Author{{Name: "Antoine de Saint-Exupéry"}}, Identifiers: Identifiers{ISBN10: "0156013983"}},
+		{ID: "9780451524935", Title: "1984", Authors: []Author{{Name: "George Orwell"}}},
+		{ID: "9780547928227", Title: "Collected Poems", Authors: []Author{{Name: "W. H. Auden"}}},
+		{ID: "9780307271754", Title: "Collected Poems", Authors: []Author{{Name: "Philip Larkin"}}},
+	}
+	doc.Collections = []Collection{{ID: "read", Name: "Read", Type: "read"}}
+	doc.Entries = []Entry{
+		{BookID: "f47ac10b-58cc-4372-a567-0e02b2c3d479", CollectionIDs: []string{"read"}, UserData: UserData{Status: "read", Rating: 5, Tags: []string{"french"}}},
+		{BookID: "9782070612758", CollectionIDs: []string{"read"}, UserData: UserData{Status: "read", Rating: 4, Tags: []string{"classic"}}},
+	}
+	return doc
+}
+
+func TestFindDuplicates(t *testing.T) {
+	doc := dedupeTestDocument()
+	clusters := FindDuplicates(doc, DefaultDuplicateOptions())
+	if len(clusters) != 2 {
+		t.Fatalf("Expected 2 clusters, got %+v", clusters)
+	}
+
+	// The French editions match on title and author
+	french := clusters[0]
+	if len(french.BookIDs) != 2 || french.BookIDs[0] != "9782070612758" {
+		t.Errorf("Expected the ISBN book with an entry first, got %v", french.BookIDs)
+	}
+	if french.Confidence < 0.95 {
+		t.Errorf("Expected a high confidence, got %f", french.Confidence)
+	}
+
+	// The English editions share an ISBN, in two forms
+	english := clusters[1]
+	if len(english.BookIDs) != 2 || english.BookIDs[0] != "9780156013987" || english.Confidence != 1 {
+		t.Errorf("Expected the English editions to share an ISBN, got %+v", english)
+	}
+
+	// Same title with different authors is not enough
+	for _, cluster := range clusters {
+		if containsString(cluster.BookIDs, "9780547928227") {
+			t.Errorf("Expected different poets not to be duplicates, got %v", cluster.BookIDs)
+		}
+	}
+}
+
+func TestFindDuplicatesVolumes(t *testing.T) {
+	doyle := []Author{{Name: "Arthur Conan Doyle"}}
+	doc := NewDocument()
+	doc.Books = []Book{
+		{ID: "f47ac10b-58cc-4372-a567-0e02b2c3d479", Title: "The Complete Sherlock Holmes, Volume 1", Authors: doyle},
+		{ID: "6ba7b810-9dad-41d1-80b4-00c04fd430c8", Title: "The Complete Sherlock Holmes, Volume 2", Authors: doyle},
+		{ID: "9780553212419", Title: "Sherlock Holmes: The Complete Novels and Stories", Authors: doyle,
+			Series: &Series{Name: "Sherlock Holmes", Volume: 1}},
+		{ID: "9780553212426", Title: "Sherlock Holmes: The Complete Novels and Stories", Authors: doyle,
+			Series: &Series{Name: "Sherlock Holmes", Volume: "2"}},
+	}
+
+	if clusters := FindDuplicates(doc, DuplicateOptions{MinConfidence: 0}); len(clusters) > 0 {
+		t.Errorf("Expected different volumes not to be duplicates, got %+v", clusters)
+	}
+}
+
+func TestFindDuplicatesDifferentAuthors(t *testing.T) {
+	doc := NewDocument()
+	doc.Books = []Book{
+		{ID: "f47ac10b-58cc-4372-a567-0e02b2c3d479", Title: "Collected Poems", Authors: []Author{{Name: "John Smith"}}},
+		{ID: "6ba7b810-9dad-41d1-80b4-00c04fd430c8", Title: "Collected Poems", Authors: []Author{{Name: "Jane Smith"}}},
+	}
+
+	if clusters := FindDuplicates(doc, DuplicateOptions{MinConfidence: 0}); len(clusters) > 0 {
+		t.Errorf("Expected books by different authors not to be duplicates, got %+v", clusters)
+	}
+}
+
+func TestMergeDuplicatesInvalidEntry(t *testing.T) {
+	doc := dedupeTestDocument()
+	doc.Entries[0].CollectionIDs = []string{"missing"}
+	doc.Entries[0].UserData.Review = "A favorite"
+	clusters := []DuplicateCluster{{BookIDs: []string{"9780156013987", "f47ac10b-58cc-4372-a567-0e02b2c3d479"}}}
+
+	if _, err := MergeDuplicates(doc, clusters, DefaultMergeOptions()); err == nil {
+		t.Error("Expected an error for a duplicate entry that cannot be merged")
+	}
+}
+
+func TestMergeDuplicates(t *testing.T) {
+	doc := dedupeTestDocument()
+	clusters := FindDuplicates(doc, DefaultDuplicateOptions())
+
+	result, err := MergeDuplicates(doc, clusters[:1], DefaultMergeOptions())
+	if err != nil {
+		t.Fatalf("MergeDuplicates failed: %v", err)
+	}
+	merged := result.Document
+	if len(merged.Books) != len(doc.Books)-1 || len(doc.Books) != 7 {
+		t.Fatalf("Expected one book to be removed from a copy, got %d books", len(merged.Books))
+	}
+	if merged.GetBookByID("f47ac10b-58cc-4372-a567-0e02b2c3d479") != nil {
+		t.Error("Expected the duplicate to be removed")
+	}
+
+	book := merged.GetBookByID("9782070612758")
+	ids, _ := book.Metadata[DuplicateIDsKey].([]interface{})
+	if len(ids) != 1 || ids[0] != "f47ac10b-58cc-4372-a567-0e02b2c3d479" {
+		t.Errorf("Expected the duplicate ID in metadata, got %v", book.Metadata)
+	}
+
+	entries := merged.GetEntriesForBook("9782070612758")
+	if len(entries) != 1 {
+		t.Fatalf("Expected the entries to be merged, got %d", len(entries))
+	}
+	if entries[0].UserData.Rating != 5 || len(entries[0].UserData.Tags) != 2 {
+		t.Errorf("Expected max rating and united tags, got %+v", entries[0].UserData)
+	}
+	if errs := ValidateDocument(merged); len(errs) > 0 {
+		t.Errorf("Merged document is invalid: %v", errs)
+	}
+}
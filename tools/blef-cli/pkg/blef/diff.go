@@ -0,0 +1,571 @@
+package blef
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// ChangeKind identifies the type of a change between two documents
+type ChangeKind string
+
+const (
+	ChangeBookAdded         ChangeKind = "book-added"
+	ChangeBookRemoved       ChangeKind = "book-removed"
+	ChangeBookUpdated       ChangeKind = "book-updated"
+	ChangeCollectionAdded   ChangeKind = "collection-added"
+	ChangeCollectionRemoved ChangeKind = "collection-removed"
+	ChangeCollectionUpdated ChangeKind = "collection-updated"
+	ChangeEntryAdded        ChangeKind = "entry-added"
+	ChangeEntryRemoved      ChangeKind = "entry-removed"
+	ChangeEntryUpdated      ChangeKind = "entry-updated"
+	ChangeStatus            ChangeKind = "status-changed"
+	ChangeRating            ChangeKind = "rating-changed"
+	ChangeCollectionJoined  ChangeKind = "collection-joined"
+	ChangeCollectionLeft    ChangeKind = "collection-left"
+	ChangeLoanStarted       ChangeKind = "loan-started"
+	ChangeLoanReturned      ChangeKind = "loan-returned"
+	// ChangeDocumentUpdated is a change of an unknown member of the document
+	// root or of the user block
+	ChangeDocumentUpdated ChangeKind = "document-updated"
+)
+
+// Change is a single semantic difference. Book changes set BookID,
+// collection changes set CollectionID, membership changes set both and
+// document changes set neither.
+// Field, Old and New are set for field-level changes; Old and New are nil
+// when a list, map or object is unset, and zero scalars are kept.
+type Change struct {
+	Kind         ChangeKind  `json:"kind"`
+	BookID       string      `json:"book_id,omitempty"`
+	CollectionID string      `json:"collection_id,omitempty"`
+	Title        string      `json:"title,omitempty"` // book title or collection name
+	Field        string      `json:"field,omitempty"`
+	Old          interface{} `json:"old"`
+	New          interface{} `json:"new"`
+}
+
+// Description describes the change, without the book or collection it applies to
+func (c Change) Description() string {
+	switch c.Kind {
+	case ChangeBookAdded, ChangeCollectionAdded:
+		return "added"
+	case ChangeBookRemoved, ChangeCollectionRemoved:
+		return "removed"
+	case ChangeEntryAdded:
+		return fmt.Sprintf("added to the library as %s", c.New)
+	case ChangeEntryRemoved:
+		return fmt.Sprintf("removed from the library (was %s)", c.Old)
+	case ChangeCollectionJoined:
+		return fmt.Sprintf("added to collection %s", c.CollectionID)
+	case ChangeCollectionLeft:
+		return fmt.Sprintf("removed from collection %s", c.CollectionID)
+	case ChangeLoanStarted:
+		return "loaned" + describeLoan(c.New, "to")
+	case ChangeLoanReturned:
+		return "returned" + describeLoan(c.Old, "by")
+	default:
+		return fmt.Sprintf("%s: %s → %s", c.Field, formatValue(c.Old), formatValue(c.New))
+	}
+}
+
+// describeLoan names the borrower and the loan date, e.g. " to Alice (2024-03-01)"
+func describeLoan(v interface{}, preposition string) string {
+	loan, ok := v.(*Loaned)
+	if !ok || loan == nil {
+		return ""
+	}
+	var description string
+	if loan.To != "" {
+		description += " " + preposition + " " + loan.To
+	}
+	if !loan.Date.IsZero() {
+		description += " (" + loan.Date.String() + ")"
+	}
+	return description
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s", c.subject(), c.Description())
+}
+
+// subject names what the change applies to, e.g. book 9780156013987 "The Little Prince"
+func (c Change) subject() string {
+	if c.Kind == ChangeDocumentUpdated {
+		return "document"
+	}
+	if c.isCollectionChange() {
+		return fmt.Sprintf("collection %s %q", c.CollectionID, c.Title)
+	}
+	return fmt.Sprintf("book %s %q", c.BookID, c.Title)
+}
+
+func (c Change) isCollectionChange() bool {
+	switch c.Kind {
+	case ChangeCollectionAdded, ChangeCollectionRemoved, ChangeCollectionUpdated:
+		return true
+	default:
+		return false
+	}
+}
+
+// Diff is the list of changes turning one document into another, grouped
+// by book and collection: collections come first, then books in the order
+// of the new document, then removed books.
+type Diff struct {
+	Changes []Change
+}
+
+// DiffSummary counts the books and collections affected by a diff, and tells
+// whether unknown members of the document root or user block changed
+type DiffSummary struct {
+	BooksAdded         int  `json:"books_added"`
+	BooksRemoved       int  `json:"books_removed"`
+	BooksChanged       int  `json:"books_changed"`
+	CollectionsAdded   int  `json:"collections_added"`
+	CollectionsRemoved int  `json:"collections_removed"`
+	CollectionsChanged int  `json:"collections_changed"`
+	DocumentChanged    bool `json:"document_changed"`
+}
+
+// DiffDocuments compares two documents. Books, collections and entries are
+// matched by ID; entries are compared field by field and reported as status,
+// rating, collection membership and loan changes where possible. Members
+// unknown to this version of BLEF are compared too, each as a field, those of
+// the document root and of the user block coming first.
+func DiffDocuments(old, new *BLEFDocument) *Diff {
+	d := &Diff{}
+
+	document := Change{Kind: ChangeDocumentUpdated}
+	d.extensions(document, "", old.Extensions, new.Extensions)
+	var oldUser, newUser Extensions
+	if old.User != nil {
+		oldUser = old.User.Extensions
+	}
+	if new.User != nil {
+		newUser = new.User.Extensions
+	}
+	d.extensions(document, "user.", oldUser, newUser)
+
+	oldCollections := make(map[string]*Collection, len(old.Collections))
+	for i := range old.Collections {
+		oldCollections[old.Collections[i].ID] = &old.Collections[i]
+	}
+	newCollections := make(map[string]bool, len(new.Collections))
+	for i := range new.Collections {
+		collection := &new.Collections[i]
+		newCollections[collection.ID] = true
+		if previous, ok := oldCollections[collection.ID]; ok {
+			d.diffCollection(previous, collection)
+		} else {
+			d.add(Change{Kind: ChangeCollectionAdded, CollectionID: collection.ID, Title: collection.Name})
+		}
+	}
+	for _, collection := range old.Collections {
+		if !newCollections[collection.ID] {
+			d.add(Change{Kind: ChangeCollectionRemoved, CollectionID: collection.ID, Title: collection.Name})
+		}
+	}
+
+	oldBooks := make(map[string]*Book, len(old.Books))
+	for i := range old.Books {
+		oldBooks[old.Books[i].ID] = &old.Books[i]
+	}
+	oldEntries, newEntries := firstEntries(old), firstEntries(new)
+	newBooks := make(map[string]bool, len(new.Books))
+	for i := range new.Books {
+		book := &new.Books[i]
+		newBooks[book.ID] = true
+		previous, ok := oldBooks[book.ID]
+		if !ok {
+			d.add(Change{Kind: ChangeBookAdded, BookID: book.ID, Title: book.Title})
+			continue
+		}
+		d.diffBook(previous, book)
+		d.diffEntry(book, oldEntries[book.ID], newEntries[book.ID])
+	}
+	for _, book := range old.Books {
+		if !newBooks[book.ID] {
+			d.add(Change{Kind: ChangeBookRemoved, BookID: book.ID, Title: book.Title})
+		}
+	}
+
+	return d
+}
+
+// firstEntries maps book IDs to their first entry
+func firstEntries(doc *BLEFDocument) map[string]*Entry {
+	entries := make(map[string]*Entry, len(doc.Entries))
+	for i := range doc.Entries {
+		if _, exists := entries[doc.Entries[i].BookID]; !exists {
+			entries[doc.Entries[i].BookID] = &doc.Entries[i]
+		}
+	}
+	return entries
+}
+
+func (d *Diff) add(change Change) {
+	d.Changes = append(d.Changes, change)
+}
+
+// field records a field change if the values differ. Empty lists, maps and
+// nil pointers are recorded as nil; zero scalars such as a rating of 0 or
+// favorite false are kept.
+func (d *Diff) field(change Change, field string, old, new interface{}) {
+	if valuesEqual(old, new) {
+		return
+	}
+	change.Field, change.Old, change.New = field, unsetAsNil(old), unsetAsNil(new)
+	d.add(change)
+}
+
+// extensions records a field change for each unknown member that differs,
+// its name prefixed with prefix
+func (d *Diff) extensions(change Change, prefix string, old, new Extensions) {
+	for _, member := range new {
+		d.field(change, prefix+member.Name, old.Get(member.Name), member.Value)
+	}
+	for _, member := range old {
+		if new.Get(member.Name) == nil {
+			d.field(change, prefix+member.Name, member.Value, nil)
+		}
+	}
+}
+
+// unsetAsNil returns nil for empty lists and maps and nil pointers
+func unsetAsNil(v interface{}) interface{} {
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Slice, reflect.Map, reflect.Ptr:
+		if isEmptyValue(v) {
+			return nil
+		}
+	}
+	return v
+}
+
+// valuesEqual treats every empty value as equal, so that a nil and an empty
+// slice are not reported as a change
+func valuesEqual(a, b interface{}) bool {
+	if isEmptyValue(a) && isEmptyValue(b) {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func (d *Diff) diffCollection(old, new *Collection) {
+	change := Change{Kind: ChangeCollectionUpdated, CollectionID: new.ID, Title: new.Name}
+	d.field(change, "name", old.Name, new.Name)
+	d.field(change, "type", old.Type, new.Type)
+	d.field(change, "description", old.Description, new.Description)
+	d.field(change, "is_public", old.IsPublic, new.IsPublic)
+	d.field(change, "metadata", old.Metadata, new.Metadata)
+	d.extensions(change, "", old.Extensions, new.Extensions)
+}
+
+func (d *Diff) diffBook(old, new *Book) {
+	change := Change{Kind: ChangeBookUpdated, BookID: new.ID, Title: new.Title}
+	d.field(change, "title", old.Title, new.Title)
+	d.field(change, "subtitle", old.Subtitle, new.Subtitle)
+	d.field(change, "authors", old.Authors, new.Authors)
+	d.field(change, "identifiers", old.Identifiers, new.Identifiers)
+	d.field(change, "language", old.Language, new.Language)
+	d.field(change, "description", old.Description, new.Description)
+	d.field(change, "cover_url", old.CoverURL, new.CoverURL)
+	d.field(change, "edition", old.Edition, new.Edition)
+	d.field(change, "series", old.Series, new.Series)
+	d.field(change, "subjects", old.Subjects, new.Subjects)
+	d.field(change, "metadata", old.Metadata, new.Metadata)
+	d.extensions(change, "", old.Extensions, new.Extensions)
+}
+
+func (d *Diff) diffEntry(book *Book, old, new *Entry) {
+	base := Change{BookID: book.ID, Title: book.Title}
+
+	switch {
+	case old == nil && new == nil:
+		return
+	case old == nil:
+		base.Kind, base.New = ChangeEntryAdded, new.UserData.Status
+		d.add(base)
+		return
+	case new == nil:
+		base.Kind, base.Old = ChangeEntryRemoved, old.UserData.Status
+		d.add(base)
+		return
+	}
+
+	status := base
+	status.Kind = ChangeStatus
+	d.field(status, "status", old.UserData.Status, new.UserData.Status)
+
+	rating := base
+	rating.Kind = ChangeRating
+	d.field(rating, "rating", old.UserData.Rating, new.UserData.Rating)
+
+	for _, collID := range new.CollectionIDs {
+		if !containsString(old.CollectionIDs, collID) {
+			joined := base
+			joined.Kind, joined.CollectionID = ChangeCollectionJoined, collID
+			d.add(joined)
+		}
+	}
+	for _, collID := range old.CollectionIDs {
+		if !containsString(new.CollectionIDs, collID) {
+			left := base
+			left.Kind, left.CollectionID = ChangeCollectionLeft, collID
+			d.add(left)
+		}
+	}
+
+	d.diffLoan(base, activeLoan(old), activeLoan(new))
+
+	updated := base
+	updated.Kind = ChangeEntryUpdated
+	d.field(updated, "review", old.UserData.Review, new.UserData.Review)
+	d.field(updated, "private_notes", old.UserData.PrivateNotes, new.UserData.PrivateNotes)
+	d.field(updated, "tags", old.UserData.Tags, new.UserData.Tags)
+	d.field(updated, "favorite", old.UserData.Favorite, new.UserData.Favorite)
+	d.field(updated, "read_dates", old.UserData.ReadDates, new.UserData.ReadDates)
+	d.field(updated, "added_at", old.UserData.AddedAt, new.UserData.AddedAt)
+	d.field(updated, "ownership.owned", old.Ownership != nil && old.Ownership.Owned, new.Ownership != nil && new.Ownership.Owned)
+	d.field(updated, "metadata", old.Metadata, new.Metadata)
+	d.extensions(updated, "", old.Extensions, new.Extensions)
+	d.extensions(updated, "user_data.", old.UserData.Extensions, new.UserData.Extensions)
+	var oldOwnership, newOwnership Extensions
+	if old.Ownership != nil {
+		oldOwnership = old.Ownership.Extensions
+	}
+	if new.Ownership != nil {
+		newOwnership = new.Ownership.Extensions
+	}
+	d.extensions(updated, "ownership.", oldOwnership, newOwnership)
+}
+
+// activeLoan returns the loan of an entry if the book is currently loaned out
+func activeLoan(entry *Entry) *Loaned {
+	if entry.Ownership == nil || entry.Ownership.Loaned == nil || !entry.Ownership.Loaned.Status {
+		return nil
+	}
+	return entry.Ownership.Loaned
+}
+
+func (d *Diff) diffLoan(base Change, old, new *Loaned) {
+	if old != nil && new != nil && old.To == new.To {
+		updated := base
+		updated.Kind = ChangeEntryUpdated
+		d.field(updated, "ownership.loaned", old, new)
+		return
+	}
+	// A loan to someone else is a return followed by a new loan
+	if old != nil {
+		returned := base
+		returned.Kind, returned.Old = ChangeLoanReturned, old
+		d.add(returned)
+	}
+	if new != nil {
+		started := base
+		started.Kind, started.New = ChangeLoanStarted, new
+		d.add(started)
+	}
+}
+
+// Empty reports whether the documents are semantically identical
+func (d *Diff) Empty() bool {
+	return len(d.Changes) == 0
+}
+
+// Count returns the number of changes of a kind
+func (d *Diff) Count(kind ChangeKind) int {
+	count := 0
+	for _, change := range d.Changes {
+		if change.Kind == kind {
+			count++
+		}
+	}
+	return count
+}
+
+// Summary counts the books and collections that were added, removed or changed
+func (d *Diff) Summary() DiffSummary {
+	summary := DiffSummary{
+		BooksAdded:         d.Count(ChangeBookAdded),
+		BooksRemoved:       d.Count(ChangeBookRemoved),
+		CollectionsAdded:   d.Count(ChangeCollectionAdded),
+		CollectionsRemoved: d.Count(ChangeCollectionRemoved),
+	}
+	for _, group := range d.groups() {
+		switch group[0].Kind {
+		case ChangeBookAdded, ChangeBookRemoved, ChangeCollectionAdded, ChangeCollectionRemoved:
+		case ChangeCollectionUpdated:
+			summary.CollectionsChanged++
+		case ChangeDocumentUpdated:
+			summary.DocumentChanged = true
+		default:
+			summary.BooksChanged++
+		}
+	}
+	return summary
+}
+
+func (s DiffSummary) String() string {
+	var parts []string
+	add := func(count int, noun, verb string) {
+		if count == 0 {
+			return
+		}
+		if count > 1 {
+			noun += "s"
+		}
+		parts = append(parts, fmt.Sprintf("%d %s %s", count, noun, verb))
+	}
+	add(s.BooksAdded, "book", "added")
+	add(s.BooksRemoved, "book", "removed")
+	add(s.BooksChanged, "book", "changed")
+	add(s.CollectionsAdded, "collection", "added")
+	add(s.CollectionsRemoved, "collection", "removed")
+	add(s.CollectionsChanged, "collection", "changed")
+	if s.DocumentChanged {
+		parts = append(parts, "document changed")
+	}
+	if len(parts) == 0 {
+		return "no changes"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// groups splits the changes into consecutive runs applying to the same
+// book or collection
+func (d *Diff) groups() [][]Change {
+	var groups [][]Change
+	for i, change := range d.Changes {
+		if i > 0 && change.subject() == d.Changes[i-1].subject() {
+			groups[len(groups)-1] = append(groups[len(groups)-1], change)
+			continue
+		}
+		groups = append(groups, []Change{change})
+	}
+	return groups
+}
+
+// WriteText writes the diff for a terminal, one block per book or collection
+func (d *Diff) WriteText(w io.Writer) error {
+	for _, group := range d.groups() {
+		first := group[0]
+		switch first.Kind {
+		case ChangeBookAdded, ChangeCollectionAdded:
+			if _, err := fmt.Fprintf(w, "+ %s\n", first.subject()); err != nil {
+				return err
+			}
+			continue
+		case ChangeBookRemoved, ChangeCollectionRemoved:
+			if _, err := fmt.Fprintf(w, "- %s\n", first.subject()); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if _, err := fmt.Fprintf(w, "~ %s\n", first.subject()); err != nil {
+			return err
+		}
+		for _, change := range group {
+			if _, err := fmt.Fprintf(w, "    %s\n", change.Description()); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "\n%s\n", d.Summary())
+	return err
+}
+
+// WriteJSON writes the summary and the typed changes as JSON
+func (d *Diff) WriteJSON(w io.Writer) error {
+	changes := d.Changes
+	if changes == nil {
+		changes = []Change{}
+	}
+
+	output := struct {
+		Summary DiffSummary `json:"summary"`
+		Changes []Change    `json:"changes"`
+	}{
+		Summary: d.Summary(),
+		Changes: changes,
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(output)
+}
+
+// WriteMarkdown writes the diff as a Markdown document, e.g. for a pull
+// request comment or a changelog
+func (d *Diff) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+
+	b.WriteString("# Library changes\n\n")
+	b.WriteString(capitalize(d.Summary().String()) + ".\n")
+
+	var document, collections, books [][]Change
+	for _, group := range d.groups() {
+		switch {
+		case group[0].Kind == ChangeDocumentUpdated:
+			document = append(document, group)
+		case group[0].isCollectionChange():
+			collections = append(collections, group)
+		default:
+			books = append(books, group)
+		}
+	}
+
+	for _, section := range []struct {
+		title  string
+		groups [][]Change
+	}{
+		{"Document", document},
+		{"Collections", collections},
+		{"Books", books},
+	} {
+		if len(section.groups) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n## %s\n", section.title)
+		for _, group := range section.groups {
+			first := group[0]
+			if first.Kind == ChangeDocumentUpdated {
+				b.WriteString("\n")
+				for _, change := range group {
+					fmt.Fprintf(&b, "- %s\n", markdownEscape(change.Description()))
+				}
+				continue
+			}
+			id := first.BookID
+			if first.isCollectionChange() {
+				id = first.CollectionID
+			}
+			fmt.Fprintf(&b, "\n### %s (`%s`)\n\n", markdownEscape(first.Title), id)
+			for _, change := range group {
+				fmt.Fprintf(&b, "- %s\n", markdownEscape(change.Description()))
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// markdownEscaper escapes the characters that would be read as Markdown markup
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "<", `&lt;`)
+
+func markdownEscape(s string) string {
+	return markdownEscaper.Replace(s)
+}
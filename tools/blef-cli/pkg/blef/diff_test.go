@@ -0,0 +1,207 @@
+package blef
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func diffTestDocuments() (*BLEFDocument, *BLEFDocument) {
+	old := NewDocument()
+	old.Books = []Book{
+		{ID: "9780156013987", Title: "The Little Prince", Authors: []Author{{Name: "Antoine de Saint-Exupéry"}}},
+		{ID: "9780451524935", Title: "1984", Authors: []Author{{Name: "George Orwell"}}},
+	}
+	old.Collections = []Collection{
+		{ID: "to-read", Name: "To Read", Type: "to-read"},
+		{ID: "read", Name: "Read", Type: "read"},
+	}
+	old.Entries = []Entry{
+		{BookID: "9780156013987", CollectionIDs: []string{"to-read"}, UserData: UserData{Status: "to-read"},
+			Ownership: &Ownership{Owned: true, Loaned: &Loaned{Status: true, To: "Alice", Date: MustParseDate("2024-01-05")}}},
+		{BookID: "9780451524935", CollectionIDs: []string{"read"}, UserData: UserData{Status: "read", Rating: 4}},
+	}
+
+	new := NewDocument()
+	new.Books = []Book{
+		{ID: "9780156013987", Title: "The Little Prince", Subtitle: "Illustrated", Authors: []Author{{Name: "Antoine de Saint-Exupéry"}}},
+		{ID: "9780547928227", Title: "The Hobbit", Authors: []Author{{Name: "J.R.R. Tolkien"}}},
+	}
+	new.Collections = []Collection{
+		{ID: "to-read", Name: "To Read", Type: "to-read"},
+		{ID: "read", Name: "Books I read", Type: "read"},
+		{ID: "favorites", Name: "Favorites", Type: "custom"},
+	}
+	new.Entries = []Entry{
+		{BookID: "9780156013987", CollectionIDs: []string{"read", "favorites"}, UserData: UserData{
+			Status: "read", Rating: 5, Tags: []string{}, ReadDates: []ReadDate{{Finished: MustParseDate("2024-02-01")}},
+		}, Ownership: &Ownership{Owned: true}},
+		{BookID: "9780547928227", CollectionIDs: []string{"to-read"}, UserData: UserData{Status: "to-read"}},
+	}
+
+	return old, new
+}
+
+func TestDiffDocuments(t *testing.T) {
+	diff := DiffDocuments(diffTestDocuments())
+
+	var got []string
+	for _, change := range diff.Changes {
+		got = append(got, string(change.Kind)+" "+change.BookID+change.CollectionID+" "+change.Field)
+	}
+	expected := []string{
+		"collection-updated read name",
+		"collection-added favorites ",
+		"book-updated 9780156013987 subtitle",
+		"status-changed 9780156013987 status",
+		"rating-changed 9780156013987 rating",
+		"collection-joined 9780156013987read ",
+		"collection-joined 9780156013987favorites ",
+		"collection-left 9780156013987to-read ",
+		"loan-returned 9780156013987 ",
+		"entry-updated 9780156013987 read_dates",
+		"book-added 9780547928227 ",
+		"book-removed 9780451524935 ",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected changes:\n%s\nexpected:\n%s", strings.Join(got, "\n"), strings.Join(expected, "\n"))
+	}
+
+	summary := diff.Summary()
+	if summary != (DiffSummary{BooksAdded: 1, BooksRemoved: 1, BooksChanged: 1, CollectionsAdded: 1, CollectionsChanged: 1}) {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+
+	for _, change := range diff.Changes {
+		if change.Kind == ChangeRating && (change.Old != 0.0 || change.New != 5.0) {
+			t.Errorf("Unexpected rating change: %+v", change)
+		}
+		if change.Kind == ChangeLoanReturned && change.Description() != "returned by Alice (2024-01-05)" {
+			t.Errorf("Unexpected loan description: %q", change.Description())
+		}
+	}
+}
+
+func TestDiffIdenticalDocuments(t *testing.T) {
+	old, _ := diffTestDocuments()
+	copied, err := cloneDocument(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Reordering is not a change
+	copied.Books[0], copied.Books[1] = copied.Books[1], copied.Books[0]
+
+	if diff := DiffDocuments(old, copied); !diff.Empty() {
+		t.Errorf("Expected no changes, got %v", diff.Changes)
+	}
+}
+
+func TestDiffUnknownMembers(t *testing.T) {
+	old, _ := diffTestDocuments()
+	new, err := cloneDocument(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	new.Extensions = Extensions{{Name: "x_source", Value: json.RawMessage(`"goodreads"`)}}
+	new.Books[0].Extensions = Extensions{{Name: "x_shelf", Value: json.RawMessage(`3`)}}
+	old.Entries[1].UserData.Extensions = Extensions{{Name: "x_mood", Value: json.RawMessage(`"cozy"`)}}
+
+	diff := DiffDocuments(old, new)
+	var got []string
+	for _, change := range diff.Changes {
+		got = append(got, change.String())
+	}
+	want := []string{
+		`document: x_source: none → "goodreads"`,
+		`book 9780156013987 "The Little Prince": x_shelf: none → 3`,
+		`book 9780451524935 "1984": user_data.x_mood: "cozy" → none`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected changes:\n%s", strings.Join(got, "\n"))
+	}
+	if summary := diff.Summary(); !summary.DocumentChanged || summary.BooksChanged != 2 {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+}
+
+func TestDiffLoanStarted(t *testing.T) {
+	old, _ := diffTestDocuments()
+	new, err := cloneDocument(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	new.Entries[0].Ownership.Loaned = &Loaned{Status: true, To: "Bob"}
+	new.Entries[1].Ownership = &Ownership{Owned: true, Loaned: &Loaned{Status: true, To: "Carol"}}
+
+	diff := DiffDocuments(old, new)
+	if diff.Count(ChangeLoanReturned) != 1 || diff.Count(ChangeLoanStarted) != 2 {
+		t.Errorf("Expected 1 return and 2 new loans, got %v", diff.Changes)
+	}
+}
+
+func TestDiffClearedValuesJSON(t *testing.T) {
+	old, _ := diffTestDocuments()
+	old.Entries[1].UserData.Favorite = true
+	old.Entries[1].UserData.Review = "Chilling"
+	new, err := cloneDocument(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	new.Entries[1].UserData = UserData{Status: "read"}
+
+	var data bytes.Buffer
+	if err := DiffDocuments(old, new).WriteJSON(&data); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"old": 4,`, `"new": 0`, `"new": false`, `"new": ""`} {
+		if !strings.Contains(data.String(), want) {
+			t.Errorf("JSON output is missing %s:\n%s", want, data.String())
+		}
+	}
+}
+
+func TestDiffRenderers(t *testing.T) {
+	diff := DiffDocuments(diffTestDocuments())
+
+	var text bytes.Buffer
+	if err := diff.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`+ book 9780547928227 "The Hobbit"`,
+		`- book 9780451524935 "1984"`,
+		`~ book 9780156013987 "The Little Prince"`,
+		`    status: "to-read" → "read"`,
+		"1 book added, 1 book removed, 1 book changed, 1 collection added, 1 collection changed",
+	} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("Text output is missing %q:\n%s", want, text.String())
+		}
+	}
+
+	var data bytes.Buffer
+	if err := diff.WriteJSON(&data); err != nil {
+		t.Fatal(err)
+	}
+	var output struct {
+		Summary DiffSummary `json:"summary"`
+		Changes []Change    `json:"changes"`
+	}
+	if err := json.Unmarshal(data.Bytes(), &output); err != nil {
+		t.Fatalf("Invalid JSON output: %v", err)
+	}
+	if len(output.Changes) != len(diff.Changes) || output.Summary.BooksAdded != 1 {
+		t.Errorf("Unexpected JSON output: %s", data.String())
+	}
+
+	var markdown bytes.Buffer
+	if err := diff.WriteMarkdown(&markdown); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"## Collections", "## Books", "### The Hobbit (`9780547928227`)", "- added to collection favorites"} {
+		if !strings.Contains(markdown.String(), want) {
+			t.Errorf("Markdown output is missing %q:\n%s", want, markdown.String())
+		}
+	}
+}
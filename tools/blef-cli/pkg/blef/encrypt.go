@@ -0,0 +1,316 @@
+package blef
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// EncryptedKey is the entry metadata key holding the encryption envelope
+const EncryptedKey = "encrypted"
+
+// Envelope algorithms
+const (
+	EnvelopeVersion   = 1
+	EnvelopeAlgorithm = "AES-256-GCM"
+	EnvelopeKDF       = "PBKDF2-SHA256"
+)
+
+// DefaultEncryptionIterations is the PBKDF2 iteration count used when none
+// is given, as recommended by OWASP for PBKDF2-HMAC-SHA256
+const DefaultEncryptionIterations = 600000
+
+// DefaultEncryptedFields are the entry fields encrypted when none are given
+var DefaultEncryptedFields = []string{"user_data.private_notes", "ownership.loaned"}
+
+// requiredEntryFields are the entry members required by the schema. They
+// cannot be encrypted, since removing them would make the entry invalid.
+var requiredEntryFields = map[string]bool{
+	"book_id":                 true,
+	"collection_ids":          true,
+	"user_data":               true,
+	"user_data.status":        true,
+	"ownership.loaned.status": true,
+}
+
+// ErrWrongPassphrase is returned when an envelope cannot be decrypted, either
+// because the passphrase is wrong or because the envelope was tampered with
+var ErrWrongPassphrase = errors.New("wrong passphrase or corrupted data")
+
+// Envelope holds encrypted entry fields. It is stored in the entry metadata
+// under EncryptedKey, so an encrypted document stays valid and readable apart
+// from the encrypted fields.
+//
+// The plaintext is a JSON object mapping each field path, relative to the
+// entry, to its value. The key is derived from the passphrase with PBKDF2
+// and the book ID is used as additional data, so an envelope cannot be moved
+// to another entry. Binary values are base64 encoded.
+type Envelope struct {
+	Version    int      `json:"version"`
+	Algorithm  string   `json:"alg"`
+	KDF        string   `json:"kdf"`
+	Iterations int      `json:"iterations"`
+	Salt       []byte   `json:"salt"`
+	Nonce      []byte   `json:"nonce"`
+	Fields     []string `json:"fields"`
+	Ciphertext []byte   `json:"ciphertext"`
+}
+
+// EncryptionOptions configures EncryptDocument
+type EncryptionOptions struct {
+	Fields     []string // entry field paths (default: DefaultEncryptedFields)
+	Iterations int      // PBKDF2 iterations (default: DefaultEncryptionIterations)
+}
+
+// IsEncrypted reports whether any entry of the document holds an envelope
+func IsEncrypted(doc *BLEFDocument) bool {
+	for i := range doc.Entries {
+		if _, ok := doc.Entries[i].Metadata[EncryptedKey]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// EncryptDocument encrypts the given fields of every entry in place and
+// returns the number of entries encrypted. Entries without any of the fields
+// are left as they are. Only optional fields can be encrypted. The document
+// is left untouched on error.
+func EncryptDocument(doc *BLEFDocument, passphrase string, opts EncryptionOptions) (int, error) {
+	if passphrase == "" {
+		return 0, fmt.Errorf("passphrase is empty")
+	}
+	if IsEncrypted(doc) {
+		return 0, fmt.Errorf("document is already encrypted")
+	}
+	if len(opts.Fields) == 0 {
+		opts.Fields = DefaultEncryptedFields
+	}
+	if opts.Iterations <= 0 {
+		opts.Iterations = DefaultEncryptionIterations
+	}
+	for _, field := range opts.Fields {
+		if field == "" || requiredEntryFields[field] || strings.HasPrefix(field, "metadata") {
+			return 0, fmt.Errorf("field %q cannot be encrypted", field)
+		}
+	}
+
+	// One salt, and so one key derivation, for the whole document
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return 0, err
+	}
+	keys := newEnvelopeKeys(passphrase)
+	clone, err := cloneDocument(doc)
+	if err != nil {
+		return 0, err
+	}
+
+	encrypted := 0
+	for i := range clone.Entries {
+		entry := &clone.Entries[i]
+		fields, err := toJSONMap(entry)
+		if err != nil {
+			return 0, err
+		}
+
+		plain := make(map[string]interface{})
+		var paths []string
+		for _, field := range opts.Fields {
+			path := strings.Split(field, ".")
+			value := getPath(fields, path)
+			if value == nil {
+				continue
+			}
+			plain[field] = value
+			paths = append(paths, field)
+			if err := setPath(fields, path, nil); err != nil {
+				return 0, fmt.Errorf("entry %s: %w", entry.BookID, err)
+			}
+		}
+		if len(paths) == 0 {
+			continue
+		}
+
+		envelope := Envelope{
+			Version:    EnvelopeVersion,
+			Algorithm:  EnvelopeAlgorithm,
+			KDF:        EnvelopeKDF,
+			Iterations: opts.Iterations,
+			Salt:       salt,
+			Fields:     paths,
+		}
+		if err := envelope.seal(keys, entry.BookID, plain); err != nil {
+			return 0, fmt.Errorf("entry %s: %w", entry.BookID, err)
+		}
+		if err := setPath(fields, []string{"metadata", EncryptedKey}, envelope); err != nil {
+			return 0, fmt.Errorf("entry %s: %w", entry.BookID, err)
+		}
+		if err := decodeEntry(fields, entry); err != nil {
+			return 0, fmt.Errorf("entry %s: %w", entry.BookID, err)
+		}
+		encrypted++
+	}
+
+	*doc = *clone
+	return encrypted, nil
+}
+
+// DecryptDocument decrypts every envelope of the document and returns the
+// number of entries decrypted. The document is left untouched on error.
+func DecryptDocument(doc *BLEFDocument, passphrase string) (int, error) {
+	clone, err := cloneDocument(doc)
+	if err != nil {
+		return 0, err
+	}
+	keys := newEnvelopeKeys(passphrase)
+
+	decrypted := 0
+	for i := range clone.Entries {
+		entry := &clone.Entries[i]
+		raw, ok := entry.Metadata[EncryptedKey]
+		if !ok {
+			continue
+		}
+		element := "entry " + entry.BookID
+
+		var envelope Envelope
+		data, err := json.Marshal(raw)
+		if err == nil {
+			err = json.Unmarshal(data, &envelope)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("%s: invalid envelope: %w", element, err)
+		}
+		plain, err := envelope.open(keys, entry.BookID)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", element, err)
+		}
+
+		fields, err := toJSONMap(entry)
+		if err != nil {
+			return 0, err
+		}
+		if err := setPath(fields, []string{"metadata", EncryptedKey}, nil); err != nil {
+			return 0, err
+		}
+		if metadata, ok := fields["metadata"].(map[string]interface{}); ok && len(metadata) == 0 {
+			delete(fields, "metadata")
+		}
+		for _, field := range envelope.Fields {
+			if err := setPath(fields, strings.Split(field, "."), plain[field]); err != nil {
+				return 0, fmt.Errorf("%s: %w", element, err)
+			}
+		}
+		if err := decodeEntry(fields, entry); err != nil {
+			return 0, fmt.Errorf("%s: %w", element, err)
+		}
+		decrypted++
+	}
+
+	*doc = *clone
+	return decrypted, nil
+}
+
+// decodeEntry replaces an entry with its generic JSON representation
+func decodeEntry(fields map[string]interface{}, entry *Entry) error {
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	var decoded Entry
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*entry = decoded
+	return nil
+}
+
+// envelopeKeys caches the keys derived from a passphrase, since key
+// derivation is deliberately slow
+type envelopeKeys struct {
+	passphrase string
+	keys       map[string][]byte
+}
+
+func newEnvelopeKeys(passphrase string) *envelopeKeys {
+	return &envelopeKeys{passphrase: passphrase, keys: make(map[string][]byte)}
+}
+
+func (k *envelopeKeys) get(salt []byte, iterations int) ([]byte, error) {
+	id := fmt.Sprintf("%x/%d", salt, iterations)
+	if key, ok := k.keys[id]; ok {
+		return key, nil
+	}
+	key, err := pbkdf2.Key(sha256.New, k.passphrase, salt, iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	k.keys[id] = key
+	return key, nil
+}
+
+func (e *Envelope) aead(keys *envelopeKeys) (cipher.AEAD, error) {
+	if e.Version != EnvelopeVersion || e.Algorithm != EnvelopeAlgorithm || e.KDF != EnvelopeKDF {
+		return nil, fmt.Errorf("unsupported envelope (version %d, %s, %s)", e.Version, e.Algorithm, e.KDF)
+	}
+	if e.Iterations <= 0 || len(e.Salt) == 0 {
+		return nil, fmt.Errorf("invalid envelope key parameters")
+	}
+	key, err := keys.get(e.Salt, e.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// additionalData binds an envelope to its entry and to its field list
+func (e *Envelope) additionalData(bookID string) []byte {
+	return []byte("blef:entry:" + bookID + ":" + strings.Join(e.Fields, ","))
+}
+
+func (e *Envelope) seal(keys *envelopeKeys, bookID string, plain map[string]interface{}) error {
+	aead, err := e.aead(keys)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(plain)
+	if err != nil {
+		return err
+	}
+	e.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(e.Nonce); err != nil {
+		return err
+	}
+	e.Ciphertext = aead.Seal(nil, e.Nonce, data, e.additionalData(bookID))
+	return nil
+}
+
+func (e *Envelope) open(keys *envelopeKeys, bookID string) (map[string]interface{}, error) {
+	aead, err := e.aead(keys)
+	if err != nil {
+		return nil, err
+	}
+	if len(e.Nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("invalid envelope nonce")
+	}
+	data, err := aead.Open(nil, e.Nonce, e.Ciphertext, e.additionalData(bookID))
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	var plain map[string]interface{}
+	if err := json.Unmarshal(data, &plain); err != nil {
+		return nil, fmt.Errorf("invalid envelope content: %w", err)
+	}
+	return plain, nil
+}
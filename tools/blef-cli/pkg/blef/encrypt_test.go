@@ -0,0 +1,138 @@
+package blef
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// Key derivation is kept cheap in tests
+var testEncryptionOptions = EncryptionOptions{Iterations: 1000}
+
+func TestEncryptDocumentRoundTrip(t *testing.T) {
+	doc := redactTestDocument()
+	original, err := cloneDocument(doc)
+	if err != nil {
+		t.Fatalf("cloneDocument failed: %v", err)
+	}
+
+	count, err := EncryptDocument(doc, "correct horse", testEncryptionOptions)
+	if err != nil {
+		t.Fatalf("EncryptDocument failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 entry to be encrypted, got %d", count)
+	}
+	if !IsEncrypted(doc) {
+		t.Fatal("Expected the document to be encrypted")
+	}
+
+	entry := doc.Entries[0]
+	if entry.UserData.PrivateNotes != "" || entry.Ownership.Loaned != nil {
+		t.Errorf("Expected the fields to be removed from the entry, got %+v", entry)
+	}
+	if !entry.Ownership.Owned || entry.UserData.Status == "" {
+		t.Errorf("Expected the other fields to stay readable, got %+v", entry)
+	}
+	if errs := ValidateDocument(doc); len(errs) > 0 {
+		t.Errorf("Encrypted document is invalid: %v", errs)
+	}
+	if _, err := EncryptDocument(doc, "correct horse", testEncryptionOptions); err == nil {
+		t.Error("Expected an error when encrypting twice")
+	}
+
+	// The envelope survives a save and load
+	data, err := doc.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON failed: %v", err)
+	}
+	loaded, err := FromJSON(data)
+	if err != nil {
+		t.Fatalf("FromJSON failed: %v", err)
+	}
+
+	count, err = DecryptDocument(loaded, "correct horse")
+	if err != nil {
+		t.Fatalf("DecryptDocument failed: %v", err)
+	}
+	if count != 1 || IsEncrypted(loaded) {
+		t.Errorf("Expected 1 entry to be decrypted, got %d", count)
+	}
+	if !reflect.DeepEqual(loaded.Entries, original.Entries) {
+		t.Errorf("Expected the entries to be restored\ngot:  %+v\nwant: %+v", loaded.Entries, original.Entries)
+	}
+}
+
+func TestDecryptDocumentWrongPassphrase(t *testing.T) {
+	doc := redactTestDocument()
+	if _, err := EncryptDocument(doc, "correct horse", testEncryptionOptions); err != nil {
+		t.Fatalf("EncryptDocument failed: %v", err)
+	}
+
+	_, err := DecryptDocument(doc, "battery staple")
+	if !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Expected ErrWrongPassphrase, got %v", err)
+	}
+	if !IsEncrypted(doc) {
+		t.Error("Expected the document to be left untouched")
+	}
+}
+
+func TestDecryptDocumentTampered(t *testing.T) {
+	doc := redactTestDocument()
+	doc.Entries[1].UserData.PrivateNotes = "Signed copy"
+	if _, err := EncryptDocument(doc, "correct horse", testEncryptionOptions); err != nil {
+		t.Fatalf("EncryptDocument failed: %v", err)
+	}
+
+	// Swapping envelopes between entries is detected
+	swapped, _ := cloneDocument(doc)
+	swapped.Entries[0].Metadata[EncryptedKey], swapped.Entries[1].Metadata[EncryptedKey] =
+		swapped.Entries[1].Metadata[EncryptedKey], swapped.Entries[0].Metadata[EncryptedKey]
+	if _, err := DecryptDocument(swapped, "correct horse"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Expected swapped envelopes to be rejected, got %v", err)
+	}
+
+	// So is a change of the field list
+	edited, _ := cloneDocument(doc)
+	envelope := edited.Entries[0].Metadata[EncryptedKey].(map[string]interface{})
+	envelope["fields"] = []interface{}{"user_data.private_notes"}
+	if _, err := DecryptDocument(edited, "correct horse"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Expected an edited field list to be rejected, got %v", err)
+	}
+}
+
+func TestEncryptDocumentFields(t *testing.T) {
+	doc := redactTestDocument()
+	doc.Entries[0].UserData.Review = "Loved it"
+	opts := testEncryptionOptions
+	opts.Fields = []string{"user_data.review"}
+	if _, err := EncryptDocument(doc, "correct horse", opts); err != nil {
+		t.Fatalf("EncryptDocument failed: %v", err)
+	}
+	if doc.Entries[0].UserData.Review != "" || doc.Entries[0].UserData.PrivateNotes == "" {
+		t.Errorf("Expected only the review to be encrypted, got %+v", doc.Entries[0].UserData)
+	}
+
+	// Required members cannot be removed from the entries
+	for _, field := range []string{"book_id", "collection_ids", "user_data", "user_data.status", "ownership.loaned.status", "metadata.encrypted"} {
+		doc := redactTestDocument()
+		before, _ := doc.ToJSON()
+		opts.Fields = []string{"user_data.private_notes", field}
+		if _, err := EncryptDocument(doc, "correct horse", opts); err == nil {
+			t.Errorf("Expected an error when encrypting %s", field)
+		}
+		if after, _ := doc.ToJSON(); string(after) != string(before) {
+			t.Errorf("Expected the document to be untouched after failing to encrypt %s", field)
+		}
+	}
+
+	doc = redactTestDocument()
+	opts.Fields = []string{"ownership"}
+	if _, err := EncryptDocument(doc, "correct horse", opts); err != nil {
+		t.Fatalf("EncryptDocument failed: %v", err)
+	}
+	if errors := ValidateDocument(doc); len(errors) > 0 {
+		t.Errorf("Expected a valid document after encrypting optional fields, got %v", errors)
+	}
+}
@@ -0,0 +1,313 @@
+package blef
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Member is a member of a JSON object
+type Member struct {
+	Name  string
+	Value json.RawMessage
+}
+
+// Extensions are the members of a JSON object that this version of BLEF does
+// not know, such as fields added by a newer version of the spec or by another
+// tool. They are kept in their original order and written back after the
+// known fields, so that a document read and written again loses nothing.
+type Extensions []Member
+
+// Get returns the value of a member, nil if it is not set
+func (e Extensions) Get(name string) json.RawMessage {
+	for _, member := range e {
+		if member.Name == name {
+			return member.Value
+		}
+	}
+	return nil
+}
+
+// UnknownFields returns the JSON pointers of the members of the document
+// unknown to this version of BLEF, in document order
+func UnknownFields(doc *BLEFDocument) []string {
+	var fields []string
+	add := func(extensions Extensions, path ...interface{}) {
+		for _, member := range extensions {
+			fields = append(fields, jsonPointer(append(path, member.Name)...))
+		}
+	}
+
+	add(doc.Extensions)
+	if doc.User != nil {
+		add(doc.User.Extensions, "user")
+	}
+	for i, book := range doc.Books {
+		add(book.Extensions, "books", i)
+		for j, author := range book.Authors {
+			add(author.Extensions, "books", i, "authors", j)
+		}
+		add(book.Identifiers.Extensions, "books", i, "identifiers")
+		if book.Edition != nil {
+			add(book.Edition.Extensions, "books", i, "edition")
+		}
+		if book.Series != nil {
+			add(book.Series.Extensions, "books", i, "series")
+		}
+	}
+	for i, collection := range doc.Collections {
+		add(collection.Extensions, "collections", i)
+	}
+	for i, entry := range doc.Entries {
+		add(entry.Extensions, "entries", i)
+		add(entry.UserData.Extensions, "entries", i, "user_data")
+		for j, readDate := range entry.UserData.ReadDates {
+			add(readDate.Extensions, "entries", i, "user_data", "read_dates", j)
+		}
+		if entry.Ownership != nil {
+			add(entry.Ownership.Extensions, "entries", i, "ownership")
+			if entry.Ownership.Loaned != nil {
+				add(entry.Ownership.Loaned.Extensions, "entries", i, "ownership", "loaned")
+			}
+		}
+	}
+	return fields
+}
+
+// newMember returns a member with its value compacted, so that members
+// compare equal whatever the layout of the file they were read from
+func newMember(name string, raw json.RawMessage) (Member, error) {
+	var value bytes.Buffer
+	if err := json.Compact(&value, raw); err != nil {
+		return Member{}, err
+	}
+	return Member{Name: name, Value: value.Bytes()}, nil
+}
+
+// marshalObject encodes v, a struct without methods, followed by its
+// extensions
+func marshalObject(v interface{}, extensions Extensions) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(extensions) == 0 {
+		return data, err
+	}
+
+	var buf bytes.Buffer
+	buf.Write(data[:len(data)-1])
+	for i, member := range extensions {
+		if i > 0 || len(data) > 2 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(member.Name)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(member.Value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// unmarshalObject decodes data into v, a pointer to a struct without
+// methods, and keeps the members unknown to it in extensions
+func unmarshalObject(data []byte, v interface{}, extensions *Extensions) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil // null leaves v untouched
+	}
+	t := reflect.TypeOf(v).Elem()
+	var unknown Extensions
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		name, _ := tok.(string)
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+		if isKnownMember(t, name) {
+			continue
+		}
+		member, err := newMember(name, raw)
+		if err != nil {
+			return err
+		}
+		unknown = append(unknown, member)
+	}
+	*extensions = unknown
+	return nil
+}
+
+// knownMembers caches the JSON member names of struct types
+var knownMembers sync.Map // reflect.Type -> []string
+
+// isKnownMember reports whether name is decoded into a field of t. Like
+// encoding/json, it matches names case-insensitively.
+func isKnownMember(t reflect.Type, name string) bool {
+	cached, ok := knownMembers.Load(t)
+	if !ok {
+		var names []string
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if !field.IsExported() || tag == "-" {
+				continue
+			}
+			if tag == "" {
+				tag = field.Name
+			}
+			names = append(names, tag)
+		}
+		cached, _ = knownMembers.LoadOrStore(t, names)
+	}
+	for _, known := range cached.([]string) {
+		if strings.EqualFold(known, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d BLEFDocument) MarshalJSON() ([]byte, error) {
+	type plain BLEFDocument
+	return marshalObject(plain(d), d.Extensions)
+}
+
+func (d *BLEFDocument) UnmarshalJSON(data []byte) error {
+	type plain BLEFDocument
+	return unmarshalObject(data, (*plain)(d), &d.Extensions)
+}
+
+func (u User) MarshalJSON() ([]byte, error) {
+	type plain User
+	return marshalObject(plain(u), u.Extensions)
+}
+
+func (u *User) UnmarshalJSON(data []byte) error {
+	type plain User
+	return unmarshalObject(data, (*plain)(u), &u.Extensions)
+}
+
+func (b Book) MarshalJSON() ([]byte, error) {
+	type plain Book
+	return marshalObject(plain(b), b.Extensions)
+}
+
+func (b *Book) UnmarshalJSON(data []byte) error {
+	type plain Book
+	return unmarshalObject(data, (*plain)(b), &b.Extensions)
+}
+
+func (a Author) MarshalJSON() ([]byte, error) {
+	type plain Author
+	return marshalObject(plain(a), a.Extensions)
+}
+
+func (a *Author) UnmarshalJSON(data []byte) error {
+	type plain Author
+	return unmarshalObject(data, (*plain)(a), &a.Extensions)
+}
+
+func (i Identifiers) MarshalJSON() ([]byte, error) {
+	type plain Identifiers
+	return marshalObject(plain(i), i.Extensions)
+}
+
+func (i *Identifiers) UnmarshalJSON(data []byte) error {
+	type plain Identifiers
+	return unmarshalObject(data, (*plain)(i), &i.Extensions)
+}
+
+func (e Edition) MarshalJSON() ([]byte, error) {
+	type plain Edition
+	return marshalObject(plain(e), e.Extensions)
+}
+
+func (e *Edition) UnmarshalJSON(data []byte) error {
+	type plain Edition
+	return unmarshalObject(data, (*plain)(e), &e.Extensions)
+}
+
+func (s Series) MarshalJSON() ([]byte, error) {
+	type plain Series
+	return marshalObject(plain(s), s.Extensions)
+}
+
+func (s *Series) UnmarshalJSON(data []byte) error {
+	type plain Series
+	return unmarshalObject(data, (*plain)(s), &s.Extensions)
+}
+
+func (c Collection) MarshalJSON() ([]byte, error) {
+	type plain Collection
+	return marshalObject(plain(c), c.Extensions)
+}
+
+func (c *Collection) UnmarshalJSON(data []byte) error {
+	type plain Collection
+	// is_public defaults to true when the member is missing
+	if !bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		c.IsPublic = true
+	}
+	return unmarshalObject(data, (*plain)(c), &c.Extensions)
+}
+
+func (e Entry) MarshalJSON() ([]byte, error) {
+	type plain Entry
+	return marshalObject(plain(e), e.Extensions)
+}
+
+func (e *Entry) UnmarshalJSON(data []byte) error {
+	type plain Entry
+	return unmarshalObject(data, (*plain)(e), &e.Extensions)
+}
+
+func (u UserData) MarshalJSON() ([]byte, error) {
+	type plain UserData
+	return marshalObject(plain(u), u.Extensions)
+}
+
+func (u *UserData) UnmarshalJSON(data []byte) error {
+	type plain UserData
+	return unmarshalObject(data, (*plain)(u), &u.Extensions)
+}
+
+func (r ReadDate) MarshalJSON() ([]byte, error) {
+	type plain ReadDate
+	return marshalObject(plain(r), r.Extensions)
+}
+
+func (r *ReadDate) UnmarshalJSON(data []byte) error {
+	type plain ReadDate
+	return unmarshalObject(data, (*plain)(r), &r.Extensions)
+}
+
+func (o Ownership) MarshalJSON() ([]byte, error) {
+	type plain Ownership
+	return marshalObject(plain(o), o.Extensions)
+}
+
+func (o *Ownership) UnmarshalJSON(data []byte) error {
+	type plain Ownership
+	return unmarshalObject(data, (*plain)(o), &o.Extensions)
+}
+
+func (l Loaned) MarshalJSON() ([]byte, error) {
+	type plain Loaned
+	return marshalObject(plain(l), l.Extensions)
+}
+
+func (l *Loaned) UnmarshalJSON(data []byte) error {
+	type plain Loaned
+	return unmarshalObject(data, (*plain)(l), &l.Extensions)
+}
@@ -0,0 +1,136 @@
+package blef
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// extensionsTestJSON has members unknown to this version at every level
+const extensionsTestJSON = `{
+  "format": "BLEF",
+  "x_generator": {"name": "other-tool", "version": 3},
+  "version": "0.2.0",
+  "exported_at": "2025-10-26T14:30:00Z",
+  "user": {"name": "Test User", "pronouns": "they/them"},
+  "books": [
+    {
+      "id": "9780156013987",
+      "title": "The Little Prince",
+      "z_rank": 1,
+      "authors": [{"name": "Antoine de Saint-Exupéry", "birth_year": 1900}],
+      "identifiers": {"isbn13": "9780156013987", "bnf": "cb123"},
+      "edition": {"pages": 96, "binding": "sewn"},
+      "series": {"name": "Classics", "position_label": "first"},
+      "a_rank": 2
+    }
+  ],
+  "collections": [{"id": "read", "name": "Read", "type": "read", "is_public": true, "color": "#ff0000"}],
+  "entries": [
+    {
+      "book_id": "9780156013987",
+      "collection_ids": ["read"],
+      "user_data": {
+        "status": "read",
+        "mood": ["happy", "wistful"],
+        "read_dates": [{"finished": "2024-03-05", "location": "train"}]
+      },
+      "ownership": {"owned": true, "condition": "good", "loaned": {"status": true, "to": "Alice", "due": "2024-04-01"}}
+    }
+  ],
+  "x_checksum": null
+}`
+
+func TestUnknownFieldsRoundTrip(t *testing.T) {
+	doc, err := FromJSON([]byte(extensionsTestJSON))
+	if err != nil {
+		t.Fatalf("FromJSON failed: %v", err)
+	}
+
+	expected := []string{
+		"/x_generator",
+		"/x_checksum",
+		"/user/pronouns",
+		"/books/0/z_rank",
+		"/books/0/a_rank",
+		"/books/0/authors/0/birth_year",
+		"/books/0/identifiers/bnf",
+		"/books/0/edition/binding",
+		"/books/0/series/position_label",
+		"/collections/0/color",
+		"/entries/0/user_data/mood",
+		"/entries/0/user_data/read_dates/0/location",
+		"/entries/0/ownership/condition",
+		"/entries/0/ownership/loaned/due",
+	}
+	if fields := UnknownFields(doc); !reflect.DeepEqual(fields, expected) {
+		t.Errorf("Unexpected unknown fields:\n got: %v\nwant: %v", fields, expected)
+	}
+
+	data, err := doc.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON failed: %v", err)
+	}
+	for _, member := range []string{`"x_generator": {`, `"pronouns": "they/them"`, `"birth_year": 1900`, `"location": "train"`, `"x_checksum": null`} {
+		if !strings.Contains(string(data), member) {
+			t.Errorf("Expected %s in the output:\n%s", member, data)
+		}
+	}
+	if strings.Index(string(data), `"z_rank"`) > strings.Index(string(data), `"a_rank"`) {
+		t.Errorf("Expected unknown members to keep their order:\n%s", data)
+	}
+
+	// A document written by blef-cli reads and writes back identically,
+	// including through the streaming writer
+	again, err := FromJSON(data)
+	if err != nil {
+		t.Fatalf("FromJSON failed: %v", err)
+	}
+	if !reflect.DeepEqual(again, doc) {
+		t.Errorf("Expected the document to survive a round trip\n got: %+v\nwant: %+v", again, doc)
+	}
+	var buf bytes.Buffer
+	if err := WriteDocument(&buf, again); err != nil {
+		t.Fatalf("WriteDocument failed: %v", err)
+	}
+	if buf.String() != string(data) {
+		t.Errorf("Streamed output differs from ToJSON:\n%s\nwant:\n%s", buf.String(), data)
+	}
+}
+
+func TestUnknownFieldsStream(t *testing.T) {
+	reader := NewStreamReader(strings.NewReader(extensionsTestJSON))
+	var books []*Book
+	for {
+		elem, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next failed: %v", err)
+		}
+		if elem.Book != nil {
+			books = append(books, elem.Book)
+		}
+	}
+
+	header := reader.Header()
+	if header.Version != "0.2.0" || len(header.Extensions) != 2 || header.Extensions[1].Name != "x_checksum" {
+		t.Errorf("Expected the header to keep its unknown members, got %+v", header)
+	}
+	if len(books) != 1 || len(books[0].Extensions) != 2 || books[0].Extensions[0].Name != "z_rank" {
+		t.Errorf("Expected the book to keep its unknown members, got %+v", books)
+	}
+}
+
+func TestKnownMembersAreNotExtensions(t *testing.T) {
+	var book Book
+	if err := book.UnmarshalJSON([]byte(`{"id": "1", "Title": "Case", "metadata": {"x": 1}}`)); err != nil {
+		t.Fatalf("UnmarshalJSON failed: %v", err)
+	}
+	if book.Title != "Case" || book.Extensions != nil {
+		t.Errorf("Expected only known members, got %+v", book)
+	}
+}
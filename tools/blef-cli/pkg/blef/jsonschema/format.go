@@ -0,0 +1,84 @@
+package jsonschema
+
+import (
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// formats holds the "format" values asserted by the validator.
+// Unknown formats are ignored, as required by the specification.
+var formats = map[string]func(string) bool{
+	"date":          isDate,
+	"date-time":     isDateTime,
+	"time":          isTime,
+	"email":         isEmail,
+	"uri":           IsURI,
+	"uri-reference": isURIReference,
+	"uuid":          isUUID,
+	"ipv4":          isIPv4,
+	"ipv6":          isIPv6,
+}
+
+var (
+	uuidRegex = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	timeRegex = regexp.MustCompile(`^\d{2}:\d{2}:\d{2}(\.\d+)?([zZ]|[+-]\d{2}:\d{2})$`)
+)
+
+// isDate checks an RFC 3339 full-date (YYYY-MM-DD)
+func isDate(s string) bool {
+	_, err := time.Parse("2006-01-02", s)
+	return err == nil
+}
+
+// isDateTime checks an RFC 3339 date-time
+func isDateTime(s string) bool {
+	// RFC 3339 allows lowercase separators
+	s = strings.Replace(s, "t", "T", 1)
+	s = strings.Replace(s, "z", "Z", 1)
+	_, err := time.Parse(time.RFC3339Nano, s)
+	return err == nil
+}
+
+// isTime checks an RFC 3339 full-time
+func isTime(s string) bool {
+	if !timeRegex.MatchString(s) {
+		return false
+	}
+	return isDateTime("2000-01-01T" + s)
+}
+
+func isEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Address == s
+}
+
+// IsURI reports whether s is an absolute URI: a scheme followed by a
+// hierarchical or opaque part
+func IsURI(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != "" && (u.Host != "" || u.Opaque != "" || u.Path != "") &&
+		!strings.ContainsAny(s, " \t\n")
+}
+
+func isURIReference(s string) bool {
+	_, err := url.Parse(s)
+	return err == nil && !strings.ContainsAny(s, " \t\n")
+}
+
+func isUUID(s string) bool {
+	return uuidRegex.MatchString(s)
+}
+
+func isIPv4(s string) bool {
+	ip := net.ParseIP(s)
+	return ip != nil && ip.To4() != nil && !strings.Contains(s, ":")
+}
+
+func isIPv6(s string) bool {
+	ip := net.ParseIP(s)
+	return ip != nil && strings.Contains(s, ":")
+}
@@ -0,0 +1,352 @@
+// Package jsonschema implements a JSON Schema Draft 2020-12 validator.
+//
+// It supports the core and applicator vocabularies ($id, $ref, $defs, $anchor,
+// $dynamicRef/$dynamicAnchor, allOf/anyOf/oneOf/not, if/then/else,
+// dependentSchemas, properties, patternProperties, additionalProperties,
+// propertyNames, prefixItems, items, contains), the unevaluated vocabulary
+// (unevaluatedItems, unevaluatedProperties) and the validation vocabulary.
+// The "format" keyword is treated as an assertion for the formats listed in
+// format.go. References to external documents are not supported.
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// defaultBase is used as the base URI of schemas without a root $id
+const defaultBase = "https://jsonschema.invalid/schema.json"
+
+// Schema is a compiled JSON Schema
+type Schema struct {
+	root *node
+
+	// index maps "resource-uri#fragment" to nodes, where fragment is either a
+	// JSON pointer from the resource root or an anchor name
+	index map[string]*node
+
+	// dynamicAnchors maps "resource-uri#name" to nodes declaring $dynamicAnchor
+	dynamicAnchors map[string]*node
+}
+
+// node is a compiled schema or subschema
+type node struct {
+	boolean *bool                  // set for boolean schemas
+	kw      map[string]interface{} // raw keywords
+	path    string                 // location in the root document, as a URI fragment
+	res     string                 // URI of the schema resource this node belongs to
+
+	ref           string
+	refNode       *node
+	dynamicRef    string
+	dynamicNode   *node
+	dynamicAnchor string
+
+	allOf, anyOf, oneOf []*node
+	not                 *node
+	ifNode              *node
+	thenNode            *node
+	elseNode            *node
+
+	properties            map[string]*node
+	patternProperties     []patternNode
+	additionalProperties  *node
+	propertyNames         *node
+	dependentSchemas      map[string]*node
+	unevaluatedProperties *node
+
+	prefixItems      []*node
+	items            *node
+	contains         *node
+	unevaluatedItems *node
+
+	pattern *regexp.Regexp
+}
+
+type patternNode struct {
+	re   *regexp.Regexp
+	node *node
+}
+
+// Compile parses and compiles a JSON Schema document
+func Compile(data []byte) (*Schema, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var raw interface{}
+	if err := dec.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+
+	base, _ := url.Parse(defaultBase)
+	s := &Schema{
+		index:          make(map[string]*node),
+		dynamicAnchors: make(map[string]*node),
+	}
+
+	c := &compiler{schema: s}
+	root, err := c.compile(raw, base, "", "#")
+	if err != nil {
+		return nil, err
+	}
+	s.root = root
+
+	for _, n := range c.nodes {
+		if err := s.resolveRefs(n); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// MustCompile is like Compile but panics if the schema cannot be compiled
+func MustCompile(data []byte) *Schema {
+	s, err := Compile(data)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+type compiler struct {
+	schema *Schema
+	nodes  []*node
+}
+
+// compile builds the node for raw. base is the base URI in effect, ptr the
+// JSON pointer from the current resource root, path the location in the root
+// document.
+func (c *compiler) compile(raw interface{}, base *url.URL, ptr, path string) (*node, error) {
+	n := &node{path: path}
+
+	if b, ok := raw.(bool); ok {
+		n.boolean = &b
+		n.res = resourceURI(base)
+		c.register(n.res, ptr, n)
+		return n, nil
+	}
+
+	kw, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: schema must be an object or a boolean", path)
+	}
+	n.kw = kw
+
+	if id, ok := kw["$id"].(string); ok {
+		ref, err := url.Parse(id)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid $id %q: %w", path, id, err)
+		}
+		base = base.ResolveReference(ref)
+		ptr = ""
+	}
+	n.res = resourceURI(base)
+	c.register(n.res, ptr, n)
+	c.nodes = append(c.nodes, n)
+
+	if anchor, ok := kw["$anchor"].(string); ok {
+		c.schema.index[n.res+"#"+anchor] = n
+	}
+	if anchor, ok := kw["$dynamicAnchor"].(string); ok {
+		n.dynamicAnchor = anchor
+		c.schema.index[n.res+"#"+anchor] = n
+		c.schema.dynamicAnchors[n.res+"#"+anchor] = n
+	}
+
+	if ref, ok := kw["$ref"].(string); ok {
+		resolved, err := resolveURI(base, ref)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid $ref %q: %w", path, ref, err)
+		}
+		n.ref = resolved
+	}
+	if ref, ok := kw["$dynamicRef"].(string); ok {
+		resolved, err := resolveURI(base, ref)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid $dynamicRef %q: %w", path, ref, err)
+		}
+		n.dynamicRef = resolved
+	}
+
+	if pattern, ok := kw["pattern"].(string); ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid pattern %q: %w", path, pattern, err)
+		}
+		n.pattern = re
+	}
+
+	// Subschemas
+	child := func(key string, value interface{}, suffix ...string) (*node, error) {
+		tokens := append([]string{key}, suffix...)
+		childPtr, childPath := ptr, path
+		for _, tok := range tokens {
+			childPtr += "/" + escapePointer(tok)
+			childPath += "/" + escapePointer(tok)
+		}
+		return c.compile(value, base, childPtr, childPath)
+	}
+
+	var err error
+	for _, key := range []string{"not", "if", "then", "else", "additionalProperties", "propertyNames",
+		"unevaluatedProperties", "items", "contains", "unevaluatedItems"} {
+		value, exists := kw[key]
+		if !exists {
+			continue
+		}
+		var sub *node
+		if sub, err = child(key, value); err != nil {
+			return nil, err
+		}
+		switch key {
+		case "not":
+			n.not = sub
+		case "if":
+			n.ifNode = sub
+		case "then":
+			n.thenNode = sub
+		case "else":
+			n.elseNode = sub
+		case "additionalProperties":
+			n.additionalProperties = sub
+		case "propertyNames":
+			n.propertyNames = sub
+		case "unevaluatedProperties":
+			n.unevaluatedProperties = sub
+		case "items":
+			n.items = sub
+		case "contains":
+			n.contains = sub
+		case "unevaluatedItems":
+			n.unevaluatedItems = sub
+		}
+	}
+
+	for _, key := range []string{"allOf", "anyOf", "oneOf", "prefixItems"} {
+		value, exists := kw[key]
+		if !exists {
+			continue
+		}
+		list, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s/%s: must be an array of schemas", path, key)
+		}
+		subs := make([]*node, len(list))
+		for i, item := range list {
+			if subs[i], err = child(key, item, strconv.Itoa(i)); err != nil {
+				return nil, err
+			}
+		}
+		switch key {
+		case "allOf":
+			n.allOf = subs
+		case "anyOf":
+			n.anyOf = subs
+		case "oneOf":
+			n.oneOf = subs
+		case "prefixItems":
+			n.prefixItems = subs
+		}
+	}
+
+	for _, key := range []string{"$defs", "definitions", "properties", "patternProperties", "dependentSchemas"} {
+		value, exists := kw[key]
+		if !exists {
+			continue
+		}
+		members, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s/%s: must be an object of schemas", path, key)
+		}
+		subs := make(map[string]*node, len(members))
+		for _, name := range sortedKeys(members) {
+			if subs[name], err = child(key, members[name], name); err != nil {
+				return nil, err
+			}
+		}
+		switch key {
+		case "properties":
+			n.properties = subs
+		case "dependentSchemas":
+			n.dependentSchemas = subs
+		case "patternProperties":
+			for _, name := range sortedKeys(members) {
+				var re *regexp.Regexp
+				if re, err = regexp.Compile(name); err != nil {
+					return nil, fmt.Errorf("%s/patternProperties: invalid pattern %q: %w", path, name, err)
+				}
+				n.patternProperties = append(n.patternProperties, patternNode{re: re, node: subs[name]})
+			}
+		}
+	}
+
+	return n, nil
+}
+
+func (c *compiler) register(res, ptr string, n *node) {
+	key := res + "#" + ptr
+	if _, exists := c.schema.index[key]; !exists {
+		c.schema.index[key] = n
+	}
+}
+
+func (s *Schema) resolveRefs(n *node) error {
+	if n.ref != "" {
+		target, ok := s.lookup(n.ref)
+		if !ok {
+			return fmt.Errorf("%s: unresolvable $ref %s", n.path, n.ref)
+		}
+		n.refNode = target
+	}
+	if n.dynamicRef != "" {
+		target, ok := s.lookup(n.dynamicRef)
+		if !ok {
+			return fmt.Errorf("%s: unresolvable $dynamicRef %s", n.path, n.dynamicRef)
+		}
+		n.dynamicNode = target
+	}
+	return nil
+}
+
+func (s *Schema) lookup(uri string) (*node, bool) {
+	n, ok := s.index[uri]
+	return n, ok
+}
+
+// resolveURI resolves ref against base and normalizes it to "resource#fragment"
+func resolveURI(base *url.URL, ref string) (string, error) {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	resolved := base.ResolveReference(u)
+	return resourceURI(resolved) + "#" + resolved.Fragment, nil
+}
+
+// resourceURI strips the fragment from a URI
+func resourceURI(u *url.URL) string {
+	stripped := *u
+	stripped.Fragment = ""
+	stripped.RawFragment = ""
+	return stripped.String()
+}
+
+func escapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
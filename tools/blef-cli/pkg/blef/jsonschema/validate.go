@@ -0,0 +1,667 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxDepth bounds schema recursion to detect $ref cycles that never consume
+// any part of the instance
+const maxDepth = 512
+
+// Error is a single validation failure
+type Error struct {
+	// InstanceLocation is the RFC 6901 JSON pointer of the failing value
+	InstanceLocation string
+	// KeywordLocation is the location of the failing keyword in the schema document
+	KeywordLocation string
+	Keyword         string
+	Message         string
+	Value           interface{}
+}
+
+func (e Error) Error() string {
+	location := e.InstanceLocation
+	if location == "" {
+		location = "(root)"
+	}
+	return fmt.Sprintf("%s: %s", location, e.Message)
+}
+
+// Result holds the outcome of a validation
+type Result struct {
+	Errors []Error
+}
+
+// Valid reports whether the instance satisfied the schema
+func (r *Result) Valid() bool {
+	return len(r.Errors) == 0
+}
+
+// ValidateJSON decodes a JSON document and validates it
+func (s *Schema) ValidateJSON(data []byte) (*Result, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var instance interface{}
+	if err := dec.Decode(&instance); err != nil {
+		return nil, fmt.Errorf("failed to parse document: %w", err)
+	}
+	return s.Validate(instance), nil
+}
+
+// Validate validates a decoded JSON value. Numbers may be json.Number,
+// float64 or int values.
+func (s *Schema) Validate(instance interface{}) *Result {
+	e := &evaluator{schema: s}
+	errs, _ := e.eval(s.root, instance, "", 0)
+	return &Result{Errors: errs}
+}
+
+// annotations records which parts of an instance were evaluated, for the
+// unevaluatedProperties and unevaluatedItems keywords
+type annotations struct {
+	props    map[string]bool
+	items    map[int]bool
+	allItems bool
+}
+
+func (a *annotations) merge(other *annotations) {
+	if other == nil {
+		return
+	}
+	for k := range other.props {
+		a.props[k] = true
+	}
+	for i := range other.items {
+		a.items[i] = true
+	}
+	a.allItems = a.allItems || other.allItems
+}
+
+func newAnnotations() *annotations {
+	return &annotations{props: make(map[string]bool), items: make(map[int]bool)}
+}
+
+type evaluator struct {
+	schema *Schema
+	scope  []string // dynamic scope: resource URIs entered, outermost first
+}
+
+// eval validates inst against n, returning the errors and the annotations
+// produced by n
+func (e *evaluator) eval(n *node, inst interface{}, ptr string, depth int) ([]Error, *annotations) {
+	ann := newAnnotations()
+
+	if depth > maxDepth {
+		return []Error{{InstanceLocation: ptr, KeywordLocation: n.path, Keyword: "$ref",
+			Message: "maximum schema depth exceeded, the schema may contain a reference cycle"}}, ann
+	}
+
+	if n.boolean != nil {
+		if *n.boolean {
+			return nil, ann
+		}
+		return []Error{{InstanceLocation: ptr, KeywordLocation: n.path, Message: "no value is allowed here", Value: inst}}, ann
+	}
+
+	if len(e.scope) == 0 || e.scope[len(e.scope)-1] != n.res {
+		e.scope = append(e.scope, n.res)
+		defer func() { e.scope = e.scope[:len(e.scope)-1] }()
+	}
+
+	var errs []Error
+	fail := func(keyword, format string, args ...interface{}) {
+		errs = append(errs, Error{
+			InstanceLocation: ptr,
+			KeywordLocation:  n.path + "/" + keyword,
+			Keyword:          keyword,
+			Message:          fmt.Sprintf(format, args...),
+			Value:            inst,
+		})
+	}
+
+	// References
+	if n.refNode != nil {
+		subErrs, subAnn := e.eval(n.refNode, inst, ptr, depth+1)
+		errs = append(errs, subErrs...)
+		ann.merge(subAnn)
+	}
+	if n.dynamicNode != nil {
+		subErrs, subAnn := e.eval(e.resolveDynamic(n), inst, ptr, depth+1)
+		errs = append(errs, subErrs...)
+		ann.merge(subAnn)
+	}
+
+	// Validation keywords for any instance type
+	if t, exists := n.kw["type"]; exists && !matchesType(t, inst) {
+		fail("type", "expected %s, got %s", describeType(t), typeOf(inst))
+	}
+	if values, ok := n.kw["enum"].([]interface{}); ok {
+		found := false
+		for _, v := range values {
+			if equal(v, inst) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("enum", "must be one of %s", formatValues(values))
+		}
+	}
+	if c, exists := n.kw["const"]; exists && !equal(c, inst) {
+		fail("const", "must be %s", formatValue(c))
+	}
+
+	// Applicators
+	errs = append(errs, e.evalCombinators(n, inst, ptr, depth, ann)...)
+
+	switch v := inst.(type) {
+	case string:
+		errs = append(errs, e.evalString(n, v, ptr)...)
+	case []interface{}:
+		errs = append(errs, e.evalArray(n, v, ptr, depth, ann)...)
+	case map[string]interface{}:
+		errs = append(errs, e.evalObject(n, v, ptr, depth, ann)...)
+	default:
+		if num, ok := toRat(inst); ok {
+			errs = append(errs, evalNumber(n, num, inst, ptr)...)
+		}
+	}
+
+	return errs, ann
+}
+
+// resolveDynamic finds the target of a $dynamicRef by looking for the
+// outermost resource in the dynamic scope declaring the same $dynamicAnchor
+func (e *evaluator) resolveDynamic(n *node) *node {
+	target := n.dynamicNode
+	if target.dynamicAnchor == "" {
+		return target
+	}
+	for _, res := range e.scope {
+		if candidate, ok := e.schema.dynamicAnchors[res+"#"+target.dynamicAnchor]; ok {
+			return candidate
+		}
+	}
+	return target
+}
+
+func (e *evaluator) evalCombinators(n *node, inst interface{}, ptr string, depth int, ann *annotations) []Error {
+	var errs []Error
+	fail := func(keyword, message string) {
+		errs = append(errs, Error{InstanceLocation: ptr, KeywordLocation: n.path + "/" + keyword,
+			Keyword: keyword, Message: message, Value: inst})
+	}
+
+	for _, sub := range n.allOf {
+		subErrs, subAnn := e.eval(sub, inst, ptr, depth+1)
+		errs = append(errs, subErrs...)
+		ann.merge(subAnn)
+	}
+
+	if len(n.anyOf) > 0 {
+		matched := false
+		for _, sub := range n.anyOf {
+			// Every branch is evaluated to collect annotations
+			subErrs, subAnn := e.eval(sub, inst, ptr, depth+1)
+			if len(subErrs) == 0 {
+				matched = true
+				ann.merge(subAnn)
+			}
+		}
+		if !matched {
+			fail("anyOf", "must match at least one schema in anyOf")
+		}
+	}
+
+	if len(n.oneOf) > 0 {
+		var matches []int
+		for i, sub := range n.oneOf {
+			subErrs, subAnn := e.eval(sub, inst, ptr, depth+1)
+			if len(subErrs) == 0 {
+				matches = append(matches, i)
+				ann.merge(subAnn)
+			}
+		}
+		if len(matches) != 1 {
+			fail("oneOf", fmt.Sprintf("must match exactly one schema in oneOf, matched %d", len(matches)))
+		}
+	}
+
+	if n.not != nil {
+		if subErrs, _ := e.eval(n.not, inst, ptr, depth+1); len(subErrs) == 0 {
+			fail("not", "must not match the schema in not")
+		}
+	}
+
+	if n.ifNode != nil {
+		ifErrs, ifAnn := e.eval(n.ifNode, inst, ptr, depth+1)
+		if len(ifErrs) == 0 {
+			ann.merge(ifAnn)
+			if n.thenNode != nil {
+				subErrs, subAnn := e.eval(n.thenNode, inst, ptr, depth+1)
+				errs = append(errs, subErrs...)
+				ann.merge(subAnn)
+			}
+		} else if n.elseNode != nil {
+			subErrs, subAnn := e.eval(n.elseNode, inst, ptr, depth+1)
+			errs = append(errs, subErrs...)
+			ann.merge(subAnn)
+		}
+	}
+
+	return errs
+}
+
+func (e *evaluator) evalString(n *node, s string, ptr string) []Error {
+	var errs []Error
+	fail := func(keyword, format string, args ...interface{}) {
+		errs = append(errs, Error{InstanceLocation: ptr, KeywordLocation: n.path + "/" + keyword,
+			Keyword: keyword, Message: fmt.Sprintf(format, args...), Value: s})
+	}
+
+	length := utf8.RuneCountInString(s)
+	if limit, ok := intKeyword(n, "minLength"); ok && length < limit {
+		fail("minLength", "must be at least %d characters long", limit)
+	}
+	if limit, ok := intKeyword(n, "maxLength"); ok && length > limit {
+		fail("maxLength", "must be at most %d characters long", limit)
+	}
+	if n.pattern != nil && !n.pattern.MatchString(s) {
+		fail("pattern", "does not match pattern %s", n.pattern.String())
+	}
+	if format, ok := n.kw["format"].(string); ok {
+		if check, known := formats[format]; known && !check(s) {
+			fail("format", "is not a valid %s", format)
+		}
+	}
+
+	return errs
+}
+
+func evalNumber(n *node, num *big.Rat, inst interface{}, ptr string) []Error {
+	var errs []Error
+	fail := func(keyword, format string, args ...interface{}) {
+		errs = append(errs, Error{InstanceLocation: ptr, KeywordLocation: n.path + "/" + keyword,
+			Keyword: keyword, Message: fmt.Sprintf(format, args...), Value: inst})
+	}
+
+	if limit, ok := ratKeyword(n, "minimum"); ok && num.Cmp(limit) < 0 {
+		fail("minimum", "must be >= %s", limit.RatString())
+	}
+	if limit, ok := ratKeyword(n, "maximum"); ok && num.Cmp(limit) > 0 {
+		fail("maximum", "must be <= %s", limit.RatString())
+	}
+	if limit, ok := ratKeyword(n, "exclusiveMinimum"); ok && num.Cmp(limit) <= 0 {
+		fail("exclusiveMinimum", "must be > %s", limit.RatString())
+	}
+	if limit, ok := ratKeyword(n, "exclusiveMaximum"); ok && num.Cmp(limit) >= 0 {
+		fail("exclusiveMaximum", "must be < %s", limit.RatString())
+	}
+	if divisor, ok := ratKeyword(n, "multipleOf"); ok && divisor.Sign() > 0 {
+		if !new(big.Rat).Quo(num, divisor).IsInt() {
+			fail("multipleOf", "must be a multiple of %s", divisor.RatString())
+		}
+	}
+
+	return errs
+}
+
+func (e *evaluator) evalArray(n *node, items []interface{}, ptr string, depth int, ann *annotations) []Error {
+	var errs []Error
+	fail := func(keyword, format string, args ...interface{}) {
+		errs = append(errs, Error{InstanceLocation: ptr, KeywordLocation: n.path + "/" + keyword,
+			Keyword: keyword, Message: fmt.Sprintf(format, args...), Value: items})
+	}
+
+	if limit, ok := intKeyword(n, "minItems"); ok && len(items) < limit {
+		fail("minItems", "must contain at least %d items", limit)
+	}
+	if limit, ok := intKeyword(n, "maxItems"); ok && len(items) > limit {
+		fail("maxItems", "must contain at most %d items", limit)
+	}
+	if unique, _ := n.kw["uniqueItems"].(bool); unique {
+	outer:
+		for i := range items {
+			for j := i + 1; j < len(items); j++ {
+				if equal(items[i], items[j]) {
+					fail("uniqueItems", "items at %d and %d are equal", i, j)
+					break outer
+				}
+			}
+		}
+	}
+
+	for i, sub := range n.prefixItems {
+		if i >= len(items) {
+			break
+		}
+		subErrs, _ := e.eval(sub, items[i], ptr+"/"+strconv.Itoa(i), depth+1)
+		errs = append(errs, subErrs...)
+		ann.items[i] = true
+	}
+
+	if n.items != nil {
+		for i := len(n.prefixItems); i < len(items); i++ {
+			subErrs, _ := e.eval(n.items, items[i], ptr+"/"+strconv.Itoa(i), depth+1)
+			errs = append(errs, subErrs...)
+		}
+		ann.allItems = true
+	}
+
+	if n.contains != nil {
+		matches := 0
+		for i, item := range items {
+			if subErrs, _ := e.eval(n.contains, item, ptr+"/"+strconv.Itoa(i), depth+1); len(subErrs) == 0 {
+				matches++
+				ann.items[i] = true
+			}
+		}
+
+		minContains := 1
+		if limit, ok := intKeyword(n, "minContains"); ok {
+			minContains = limit
+		}
+		if matches < minContains {
+			fail("contains", "must contain at least %d matching items, found %d", minContains, matches)
+		}
+		if limit, ok := intKeyword(n, "maxContains"); ok && matches > limit {
+			fail("maxContains", "must contain at most %d matching items, found %d", limit, matches)
+		}
+	}
+
+	if n.unevaluatedItems != nil && !ann.allItems {
+		for i, item := range items {
+			if ann.items[i] {
+				continue
+			}
+			if subErrs, _ := e.eval(n.unevaluatedItems, item, ptr+"/"+strconv.Itoa(i), depth+1); len(subErrs) > 0 {
+				if n.unevaluatedItems.boolean != nil {
+					fail("unevaluatedItems", "unevaluated item %d is not allowed", i)
+				} else {
+					errs = append(errs, subErrs...)
+				}
+			}
+		}
+		ann.allItems = true
+	}
+
+	return errs
+}
+
+func (e *evaluator) evalObject(n *node, obj map[string]interface{}, ptr string, depth int, ann *annotations) []Error {
+	var errs []Error
+	fail := func(keyword, format string, args ...interface{}) {
+		errs = append(errs, Error{InstanceLocation: ptr, KeywordLocation: n.path + "/" + keyword,
+			Keyword: keyword, Message: fmt.Sprintf(format, args...), Value: obj})
+	}
+
+	if limit, ok := intKeyword(n, "minProperties"); ok && len(obj) < limit {
+		fail("minProperties", "must have at least %d properties", limit)
+	}
+	if limit, ok := intKeyword(n, "maxProperties"); ok && len(obj) > limit {
+		fail("maxProperties", "must have at most %d properties", limit)
+	}
+	if required, ok := n.kw["required"].([]interface{}); ok {
+		for _, r := range required {
+			if name, ok := r.(string); ok {
+				if _, exists := obj[name]; !exists {
+					fail("required", "missing required property %q", name)
+				}
+			}
+		}
+	}
+	if dependent, ok := n.kw["dependentRequired"].(map[string]interface{}); ok {
+		for _, name := range sortedKeys(dependent) {
+			if _, exists := obj[name]; !exists {
+				continue
+			}
+			deps, _ := dependent[name].([]interface{})
+			for _, d := range deps {
+				if dep, ok := d.(string); ok {
+					if _, exists := obj[dep]; !exists {
+						fail("dependentRequired", "property %q is required when %q is present", dep, name)
+					}
+				}
+			}
+		}
+	}
+
+	keys := sortedKeys(obj)
+
+	if n.propertyNames != nil {
+		for _, key := range keys {
+			if subErrs, _ := e.eval(n.propertyNames, key, ptr+"/"+escapePointer(key), depth+1); len(subErrs) > 0 {
+				fail("propertyNames", "invalid property name %q", key)
+			}
+		}
+	}
+
+	for _, key := range keys {
+		matched := false
+		childPtr := ptr + "/" + escapePointer(key)
+
+		if sub, exists := n.properties[key]; exists {
+			subErrs, _ := e.eval(sub, obj[key], childPtr, depth+1)
+			errs = append(errs, subErrs...)
+			matched = true
+		}
+		for _, pp := range n.patternProperties {
+			if pp.re.MatchString(key) {
+				subErrs, _ := e.eval(pp.node, obj[key], childPtr, depth+1)
+				errs = append(errs, subErrs...)
+				matched = true
+			}
+		}
+		if matched {
+			ann.props[key] = true
+			continue
+		}
+
+		if n.additionalProperties != nil {
+			if subErrs, _ := e.eval(n.additionalProperties, obj[key], childPtr, depth+1); len(subErrs) > 0 {
+				if n.additionalProperties.boolean != nil {
+					fail("additionalProperties", "additional property %q is not allowed", key)
+				} else {
+					errs = append(errs, subErrs...)
+				}
+			}
+			ann.props[key] = true
+		}
+	}
+
+	for _, name := range sortedNodeKeys(n.dependentSchemas) {
+		if _, exists := obj[name]; !exists {
+			continue
+		}
+		subErrs, subAnn := e.eval(n.dependentSchemas[name], obj, ptr, depth+1)
+		errs = append(errs, subErrs...)
+		ann.merge(subAnn)
+	}
+
+	if n.unevaluatedProperties != nil {
+		for _, key := range keys {
+			if ann.props[key] {
+				continue
+			}
+			childPtr := ptr + "/" + escapePointer(key)
+			if subErrs, _ := e.eval(n.unevaluatedProperties, obj[key], childPtr, depth+1); len(subErrs) > 0 {
+				if n.unevaluatedProperties.boolean != nil {
+					fail("unevaluatedProperties", "unevaluated property %q is not allowed", key)
+				} else {
+					errs = append(errs, subErrs...)
+				}
+			}
+			ann.props[key] = true
+		}
+	}
+
+	return errs
+}
+
+// Value helpers
+
+func toRat(v interface{}) (*big.Rat, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		return new(big.Rat).SetString(string(n))
+	case float64:
+		r := new(big.Rat)
+		if r.SetFloat64(n) == nil {
+			return nil, false
+		}
+		return r, true
+	case float32:
+		return toRat(float64(n))
+	case int:
+		return new(big.Rat).SetInt64(int64(n)), true
+	case int64:
+		return new(big.Rat).SetInt64(n), true
+	}
+	return nil, false
+}
+
+func intKeyword(n *node, key string) (int, bool) {
+	r, ok := toRat(n.kw[key])
+	if !ok || !r.IsInt() {
+		return 0, false
+	}
+	return int(r.Num().Int64()), true
+}
+
+func ratKeyword(n *node, key string) (*big.Rat, bool) {
+	return toRat(n.kw[key])
+}
+
+func typeOf(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	if r, ok := toRat(v); ok {
+		if r.IsInt() {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func matchesType(t interface{}, inst interface{}) bool {
+	switch expected := t.(type) {
+	case string:
+		return matchesSingleType(expected, inst)
+	case []interface{}:
+		for _, e := range expected {
+			if name, ok := e.(string); ok && matchesSingleType(name, inst) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func matchesSingleType(expected string, inst interface{}) bool {
+	actual := typeOf(inst)
+	switch expected {
+	case "number":
+		return actual == "number" || actual == "integer"
+	default:
+		return actual == expected
+	}
+}
+
+func describeType(t interface{}) string {
+	if list, ok := t.([]interface{}); ok {
+		names := make([]string, 0, len(list))
+		for _, e := range list {
+			names = append(names, fmt.Sprint(e))
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+// equal compares two JSON values, numbers being compared mathematically
+func equal(a, b interface{}) bool {
+	if ra, ok := toRat(a); ok {
+		rb, ok := toRat(b)
+		return ok && ra.Cmp(rb) == 0
+	}
+
+	switch va := a.(type) {
+	case nil:
+		return b == nil
+	case bool:
+		vb, ok := b.(bool)
+		return ok && va == vb
+	case string:
+		vb, ok := b.(string)
+		return ok && va == vb
+	case []interface{}:
+		vb, ok := b.([]interface{})
+		if !ok || len(va) != len(vb) {
+			return false
+		}
+		for i := range va {
+			if !equal(va[i], vb[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		vb, ok := b.(map[string]interface{})
+		if !ok || len(va) != len(vb) {
+			return false
+		}
+		for k, v := range va {
+			other, exists := vb[k]
+			if !exists || !equal(v, other) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func formatValue(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+func formatValues(values []interface{}) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = formatValue(v)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func sortedNodeKeys(m map[string]*node) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
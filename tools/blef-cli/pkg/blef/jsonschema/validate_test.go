@@ -0,0 +1,200 @@
+package jsonschema
+
+import (
+	"testing"
+)
+
+func validate(t *testing.T, schema, instance string) *Result {
+	t.Helper()
+
+	s, err := Compile([]byte(schema))
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	result, err := s.ValidateJSON([]byte(instance))
+	if err != nil {
+		t.Fatalf("ValidateJSON failed: %v", err)
+	}
+	return result
+}
+
+func TestDefsAndRef(t *testing.T) {
+	schema := `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"$id": "https://example.com/library.json",
+		"type": "object",
+		"properties": {
+			"books": {"type": "array", "items": {"$ref": "#/$defs/book"}},
+			"favorite": {"$ref": "#bookAnchor"}
+		},
+		"$defs": {
+			"book": {
+				"$anchor": "bookAnchor",
+				"type": "object",
+				"required": ["title"],
+				"properties": {"title": {"type": "string", "minLength": 1}}
+			}
+		}
+	}`
+
+	if result := validate(t, schema, `{"books": [{"title": "Dune"}], "favorite": {"title": "Dune"}}`); !result.Valid() {
+		t.Errorf("Expected valid instance, got %v", result.Errors)
+	}
+
+	result := validate(t, schema, `{"books": [{"title": "Dune"}, {}], "favorite": {"title": ""}}`)
+	if len(result.Errors) != 2 {
+		t.Fatalf("Expected 2 errors, got %v", result.Errors)
+	}
+	if result.Errors[0].InstanceLocation != "/books/1" || result.Errors[0].Keyword != "required" {
+		t.Errorf("Unexpected first error: %+v", result.Errors[0])
+	}
+	if result.Errors[1].InstanceLocation != "/favorite/title" || result.Errors[1].KeywordLocation != "#/$defs/book/properties/title/minLength" {
+		t.Errorf("Unexpected second error: %+v", result.Errors[1])
+	}
+}
+
+func TestUnevaluatedProperties(t *testing.T) {
+	schema := `{
+		"allOf": [{"properties": {"name": {"type": "string"}}}],
+		"properties": {"id": {"type": "string"}},
+		"anyOf": [
+			{"properties": {"isbn": {"type": "string"}}, "required": ["isbn"]},
+			{"properties": {"uuid": {"type": "string"}}, "required": ["uuid"]}
+		],
+		"unevaluatedProperties": false
+	}`
+
+	if result := validate(t, schema, `{"id": "1", "name": "x", "isbn": "978"}`); !result.Valid() {
+		t.Errorf("Properties evaluated by allOf and anyOf should be allowed, got %v", result.Errors)
+	}
+
+	// uuid is only evaluated by a failing anyOf branch
+	result := validate(t, schema, `{"id": "1", "isbn": "978", "uuid": 5, "extra": true}`)
+	if len(result.Errors) != 2 {
+		t.Fatalf("Expected 2 errors, got %v", result.Errors)
+	}
+	for _, err := range result.Errors {
+		if err.Keyword != "unevaluatedProperties" {
+			t.Errorf("Unexpected error: %+v", err)
+		}
+	}
+
+	// additionalProperties does not see through allOf, unlike unevaluatedProperties
+	strict := `{"allOf": [{"properties": {"name": {}}}], "additionalProperties": false}`
+	if result := validate(t, strict, `{"name": "x"}`); result.Valid() {
+		t.Error("additionalProperties should reject properties declared in allOf")
+	}
+}
+
+func TestUnevaluatedItems(t *testing.T) {
+	schema := `{"prefixItems": [{"type": "string"}], "contains": {"type": "integer"}, "unevaluatedItems": false}`
+
+	if result := validate(t, schema, `["a", 1, 2]`); !result.Valid() {
+		t.Errorf("Expected valid instance, got %v", result.Errors)
+	}
+	if result := validate(t, schema, `["a", 1, true]`); result.Valid() {
+		t.Error("Item not evaluated by prefixItems or contains should be rejected")
+	}
+}
+
+func TestFormats(t *testing.T) {
+	schema := `{
+		"properties": {
+			"date": {"format": "date"},
+			"dateTime": {"format": "date-time"},
+			"uri": {"format": "uri"},
+			"custom": {"format": "unknown-format"}
+		}
+	}`
+
+	valid := `{"date": "2025-02-28", "dateTime": "2025-10-26T14:30:00Z", "uri": "https://example.com/cover.jpg", "custom": "anything"}`
+	if result := validate(t, schema, valid); !result.Valid() {
+		t.Errorf("Expected valid formats, got %v", result.Errors)
+	}
+
+	invalid := `{"date": "2025-02-30", "dateTime": "2025-10-26 14:30", "uri": "cover.jpg"}`
+	result := validate(t, schema, invalid)
+	if len(result.Errors) != 3 {
+		t.Fatalf("Expected 3 format errors, got %v", result.Errors)
+	}
+	for _, err := range result.Errors {
+		if err.Keyword != "format" {
+			t.Errorf("Unexpected error: %+v", err)
+		}
+	}
+}
+
+func TestValidationKeywords(t *testing.T) {
+	tests := []struct {
+		schema   string
+		instance string
+		valid    bool
+	}{
+		{`{"type": "integer"}`, `1.0`, true},
+		{`{"type": "integer"}`, `1.5`, false},
+		{`{"type": ["number", "string"]}`, `"2"`, true},
+		{`{"type": ["number", "string"]}`, `null`, false},
+		{`{"minimum": 0, "maximum": 5}`, `5`, true},
+		{`{"exclusiveMaximum": 5}`, `5`, false},
+		{`{"multipleOf": 0.1}`, `0.3`, true},
+		{`{"const": {"a": [1, 2]}}`, `{"a": [1.0, 2]}`, true},
+		{`{"enum": ["read", "reading"]}`, `"wishlist"`, false},
+		{`{"uniqueItems": true}`, `[1, 2, 1]`, false},
+		{`{"oneOf": [{"pattern": "^97[89]"}, {"pattern": "^[0-9a-f]{8}-"}]}`, `"9780156013987"`, true},
+		{`{"oneOf": [{"minLength": 1}, {"maxLength": 5}]}`, `"abc"`, false},
+		{`{"not": {"type": "null"}}`, `null`, false},
+		{`{"if": {"required": ["a"]}, "then": {"required": ["b"]}}`, `{"a": 1}`, false},
+		{`{"dependentRequired": {"to": ["status"]}}`, `{"to": "Alice"}`, false},
+		{`{"propertyNames": {"pattern": "^[a-z_]+$"}}`, `{"Bad-Name": 1}`, false},
+		{`{"minContains": 2, "contains": {"const": 1}}`, `[1, 2, 1]`, true},
+		{`false`, `{}`, false},
+	}
+
+	for _, tt := range tests {
+		result := validate(t, tt.schema, tt.instance)
+		if result.Valid() != tt.valid {
+			t.Errorf("schema %s, instance %s: valid = %v, want %v (%v)", tt.schema, tt.instance, result.Valid(), tt.valid, result.Errors)
+		}
+	}
+}
+
+func TestDynamicRef(t *testing.T) {
+	schema := `{
+		"$id": "https://example.com/strict-tree",
+		"$dynamicAnchor": "node",
+		"$ref": "tree",
+		"unevaluatedProperties": false,
+		"$defs": {
+			"tree": {
+				"$id": "tree",
+				"$dynamicAnchor": "node",
+				"type": "object",
+				"properties": {
+					"data": true,
+					"children": {"type": "array", "items": {"$dynamicRef": "#node"}}
+				}
+			}
+		}
+	}`
+
+	if result := validate(t, schema, `{"children": [{"data": 1}]}`); !result.Valid() {
+		t.Errorf("Expected valid tree, got %v", result.Errors)
+	}
+	// The strict root is used for children through the dynamic scope
+	if result := validate(t, schema, `{"children": [{"daat": 1}]}`); result.Valid() {
+		t.Error("Misspelled property in a child node should be rejected")
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, schema := range []string{
+		`{"$ref": "#/$defs/missing"}`,
+		`{"pattern": "("}`,
+		`{"allOf": {}}`,
+		`[]`,
+	} {
+		if _, err := Compile([]byte(schema)); err == nil {
+			t.Errorf("Compile(%s) should fail", schema)
+		}
+	}
+}
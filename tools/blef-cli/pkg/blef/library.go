@@ -0,0 +1,312 @@
+package blef
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Library wraps a BLEF document with map-backed indexes for constant-time lookups.
+// All mutations must go through the Library so the indexes stay consistent with
+// the underlying document.
+type Library struct {
+	doc *BLEFDocument
+
+	books       map[string]int    // book ID -> position in doc.Books
+	collections map[string]int    // collection ID -> position in doc.Collections
+	isbn13      map[string]string // ISBN-13 -> book ID
+	isbn10      map[string]string // ISBN-10 -> book ID
+	entries     map[string][]int  // book ID -> positions in doc.Entries
+	collEntries map[string][]int  // collection ID -> positions in doc.Entries
+}
+
+// NewLibrary creates an indexed library around an existing document
+func NewLibrary(doc *BLEFDocument) *Library {
+	if doc == nil {
+		doc = NewDocument()
+	}
+	l := &Library{doc: doc}
+	l.Reindex()
+	return l
+}
+
+// Document returns the underlying BLEF document
+func (l *Library) Document() *BLEFDocument {
+	return l.doc
+}
+
+// Reindex rebuilds every index from the underlying document.
+// Call it after mutating the document directly.
+func (l *Library) Reindex() {
+	l.reindexBooks()
+	l.reindexCollections()
+	l.reindexEntries()
+}
+
+func (l *Library) reindexBooks() {
+	l.books = make(map[string]int, len(l.doc.Books))
+	l.isbn13 = make(map[string]string)
+	l.isbn10 = make(map[string]string)
+	for i := range l.doc.Books {
+		book := &l.doc.Books[i]
+		if _, exists := l.books[book.ID]; !exists {
+			l.books[book.ID] = i
+		}
+		l.indexISBNs(book)
+	}
+}
+
+func (l *Library) reindexCollections() {
+	l.collections = make(map[string]int, len(l.doc.Collections))
+	for i := range l.doc.Collections {
+		if _, exists := l.collections[l.doc.Collections[i].ID]; !exists {
+			l.collections[l.doc.Collections[i].ID] = i
+		}
+	}
+}
+
+func (l *Library) reindexEntries() {
+	l.entries = make(map[string][]int, len(l.doc.Entries))
+	l.collEntries = make(map[string][]int)
+	for i := range l.doc.Entries {
+		l.indexEntry(i)
+	}
+}
+
+func (l *Library) indexISBNs(book *Book) {
+	if isbn := book.Identifiers.ISBN13; isbn != "" {
+		if _, exists := l.isbn13[isbn]; !exists {
+			l.isbn13[isbn] = book.ID
+		}
+	}
+	if isbn := book.Identifiers.ISBN10; isbn != "" {
+		if _, exists := l.isbn10[isbn]; !exists {
+			l.isbn10[isbn] = book.ID
+		}
+	}
+}
+
+func (l *Library) unindexISBNs(book *Book) {
+	if l.isbn13[book.Identifiers.ISBN13] == book.ID {
+		delete(l.isbn13, book.Identifiers.ISBN13)
+	}
+	if l.isbn10[book.Identifiers.ISBN10] == book.ID {
+		delete(l.isbn10, book.Identifiers.ISBN10)
+	}
+}
+
+func (l *Library) indexEntry(i int) {
+	entry := &l.doc.Entries[i]
+	l.entries[entry.BookID] = append(l.entries[entry.BookID], i)
+	for j, collID := range entry.CollectionIDs {
+		if !containsString(entry.CollectionIDs[:j], collID) {
+			l.collEntries[collID] = append(l.collEntries[collID], i)
+		}
+	}
+}
+
+// AddBook adds a book to the library if it doesn't already exist
+func (l *Library) AddBook(book Book) error {
+	if _, exists := l.books[book.ID]; exists {
+		return fmt.Errorf("book with ID %s already exists", book.ID)
+	}
+	l.doc.Books = append(l.doc.Books, book)
+	l.books[book.ID] = len(l.doc.Books) - 1
+	l.indexISBNs(&l.doc.Books[len(l.doc.Books)-1])
+	return nil
+}
+
+// UpdateBook replaces the book with the same ID
+func (l *Library) UpdateBook(book Book) error {
+	pos, exists := l.books[book.ID]
+	if !exists {
+		return fmt.Errorf("book with ID %s does not exist", book.ID)
+	}
+	l.unindexISBNs(&l.doc.Books[pos])
+	l.doc.Books[pos] = book
+	l.indexISBNs(&l.doc.Books[pos])
+	return nil
+}
+
+// RemoveBook removes a book and every entry referencing it
+func (l *Library) RemoveBook(id string) error {
+	pos, exists := l.books[id]
+	if !exists {
+		return fmt.Errorf("book with ID %s does not exist", id)
+	}
+	l.doc.Books = append(l.doc.Books[:pos], l.doc.Books[pos+1:]...)
+	l.reindexBooks()
+
+	if _, hasEntries := l.entries[id]; hasEntries {
+		l.RemoveEntries(id)
+	}
+	return nil
+}
+
+// AddCollection adds a collection to the library
+func (l *Library) AddCollection(collection Collection) error {
+	if _, exists := l.collections[collection.ID]; exists {
+		return fmt.Errorf("collection with ID %s already exists", collection.ID)
+	}
+	l.doc.Collections = append(l.doc.Collections, collection)
+	l.collections[collection.ID] = len(l.doc.Collections) - 1
+	return nil
+}
+
+// UpdateCollection replaces the collection with the same ID
+func (l *Library) UpdateCollection(collection Collection) error {
+	pos, exists := l.collections[collection.ID]
+	if !exists {
+		return fmt.Errorf("collection with ID %s does not exist", collection.ID)
+	}
+	l.doc.Collections[pos] = collection
+	return nil
+}
+
+// RemoveCollection removes a collection and drops its ID from every entry.
+// Entries are kept even if they end up without any collection.
+func (l *Library) RemoveCollection(id string) error {
+	pos, exists := l.collections[id]
+	if !exists {
+		return fmt.Errorf("collection with ID %s does not exist", id)
+	}
+	l.doc.Collections = append(l.doc.Collections[:pos], l.doc.Collections[pos+1:]...)
+	l.reindexCollections()
+
+	for _, i := range l.collEntries[id] {
+		entry := &l.doc.Entries[i]
+		kept := make([]string, 0, len(entry.CollectionIDs))
+		for _, collID := range entry.CollectionIDs {
+			if collID != id {
+				kept = append(kept, collID)
+			}
+		}
+		entry.CollectionIDs = kept
+	}
+	delete(l.collEntries, id)
+	return nil
+}
+
+// AddEntry adds an entry to the library after checking its references
+func (l *Library) AddEntry(entry Entry) error {
+	if err := l.checkEntryReferences(entry); err != nil {
+		return err
+	}
+	l.doc.Entries = append(l.doc.Entries, entry)
+	l.indexEntry(len(l.doc.Entries) - 1)
+	return nil
+}
+
+// UpdateEntry replaces the first entry for the entry's book ID
+func (l *Library) UpdateEntry(entry Entry) error {
+	positions := l.entries[entry.BookID]
+	if len(positions) == 0 {
+		return fmt.Errorf("no entry for book ID %s", entry.BookID)
+	}
+	if err := l.checkEntryReferences(entry); err != nil {
+		return err
+	}
+	l.doc.Entries[positions[0]] = entry
+	l.reindexEntries()
+	return nil
+}
+
+// RemoveEntries removes every entry for a book and returns how many were removed
+func (l *Library) RemoveEntries(bookID string) int {
+	positions := l.entries[bookID]
+	if len(positions) == 0 {
+		return 0
+	}
+	kept := l.doc.Entries[:0]
+	for _, entry := range l.doc.Entries {
+		if entry.BookID != bookID {
+			kept = append(kept, entry)
+		}
+	}
+	l.doc.Entries = kept
+	l.reindexEntries()
+	return len(positions)
+}
+
+func (l *Library) checkEntryReferences(entry Entry) error {
+	if _, exists := l.books[entry.BookID]; !exists {
+		return fmt.Errorf("book with ID %s does not exist", entry.BookID)
+	}
+	for _, collID := range entry.CollectionIDs {
+		if _, exists := l.collections[collID]; !exists {
+			return fmt.Errorf("collection with ID %s does not exist", collID)
+		}
+	}
+	return nil
+}
+
+// GetBookByID retrieves a book by its ID
+func (l *Library) GetBookByID(id string) *Book {
+	if pos, exists := l.books[id]; exists {
+		return &l.doc.Books[pos]
+	}
+	return nil
+}
+
+// GetBookByISBN retrieves a book by its ISBN-13 or ISBN-10 identifier
+func (l *Library) GetBookByISBN(isbn string) *Book {
+	if id, exists := l.isbn13[isbn]; exists {
+		return l.GetBookByID(id)
+	}
+	if id, exists := l.isbn10[isbn]; exists {
+		return l.GetBookByID(id)
+	}
+	return nil
+}
+
+// GetCollectionByID retrieves a collection by its ID
+func (l *Library) GetCollectionByID(id string) *Collection {
+	if pos, exists := l.collections[id]; exists {
+		return &l.doc.Collections[pos]
+	}
+	return nil
+}
+
+// GetEntriesForBook retrieves all entries for a specific book
+func (l *Library) GetEntriesForBook(bookID string) []Entry {
+	positions := l.entries[bookID]
+	if len(positions) == 0 {
+		return nil
+	}
+	entries := make([]Entry, len(positions))
+	for i, pos := range positions {
+		entries[i] = l.doc.Entries[pos]
+	}
+	return entries
+}
+
+// GetEntryForBook returns a pointer to the first entry for a book, or nil
+func (l *Library) GetEntryForBook(bookID string) *Entry {
+	if positions := l.entries[bookID]; len(positions) > 0 {
+		return &l.doc.Entries[positions[0]]
+	}
+	return nil
+}
+
+// CountEntriesInCollection returns the number of entries filed in a collection
+func (l *Library) CountEntriesInCollection(collID string) int {
+	return len(l.collEntries[collID])
+}
+
+// MarshalJSON serializes the library as its underlying document
+func (l *Library) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.doc)
+}
+
+// ToJSON converts the library's document to JSON bytes with indentation
+func (l *Library) ToJSON() ([]byte, error) {
+	return l.doc.ToJSON()
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package blef
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func newTestLibrary(t *testing.T) *Library {
+	t.Helper()
+
+	lib := NewLibrary(NewDocument())
+	books := []Book{
+		{ID: "9780156013987", Title: "The Little Prince", Authors: []Author{{Name: "Antoine de Saint-Exupéry"}},
+			Identifiers: Identifiers{ISBN13: "9780156013987", ISBN10: "0156013983"}},
+		{ID: "9780451524935", Title: "1984", Authors: []Author{{Name: "George Orwell"}},
+			Identifiers: Identifiers{ISBN13: "9780451524935"}},
+	}
+	for _, book := range books {
+		if err := lib.AddBook(book); err != nil {
+			t.Fatalf("AddBook failed: %v", err)
+		}
+	}
+	for _, id := range []string{"read", "favorites"} {
+		if err := lib.AddCollection(Collection{ID: id, Name: id, Type: "custom"}); err != nil {
+			t.Fatalf("AddCollection failed: %v", err)
+		}
+	}
+	entries := []Entry{
+		{BookID: "9780156013987", CollectionIDs: []string{"read", "favorites"}, UserData: UserData{Status: "read"}},
+		{BookID: "9780451524935", CollectionIDs: []string{"read"}, UserData: UserData{Status: "reading"}},
+	}
+	for _, entry := range entries {
+		if err := lib.AddEntry(entry); err != nil {
+			t.Fatalf("AddEntry failed: %v", err)
+		}
+	}
+	return lib
+}
+
+func TestLibraryLookups(t *testing.T) {
+	lib := newTestLibrary(t)
+
+	if book := lib.GetBookByID("9780451524935"); book == nil || book.Title != "1984" {
+		t.Errorf("GetBookByID returned %v", book)
+	}
+	if book := lib.GetBookByISBN("0156013983"); book == nil || book.ID != "9780156013987" {
+		t.Errorf("GetBookByISBN(isbn10) returned %v", book)
+	}
+	if coll := lib.GetCollectionByID("favorites"); coll == nil {
+		t.Error("GetCollectionByID should find 'favorites'")
+	}
+	if entry := lib.GetEntryForBook("9780451524935"); entry == nil || entry.UserData.Status != "reading" {
+		t.Errorf("GetEntryForBook returned %v", entry)
+	}
+	if count := lib.CountEntriesInCollection("read"); count != 2 {
+		t.Errorf("Expected 2 entries in 'read', got %d", count)
+	}
+
+	if err := lib.AddBook(Book{ID: "9780156013987"}); err == nil {
+		t.Error("AddBook should reject duplicate IDs")
+	}
+	if err := lib.AddEntry(Entry{BookID: "missing", CollectionIDs: []string{"read"}}); err == nil {
+		t.Error("AddEntry should reject unknown book IDs")
+	}
+	if err := lib.AddEntry(Entry{BookID: "9780156013987", CollectionIDs: []string{"missing"}}); err == nil {
+		t.Error("AddEntry should reject unknown collection IDs")
+	}
+}
+
+func TestLibraryMutationsKeepIndexes(t *testing.T) {
+	lib := newTestLibrary(t)
+
+	// Update changes the ISBN index
+	book := *lib.GetBookByID("9780156013987")
+	book.Identifiers.ISBN10 = ""
+	if err := lib.UpdateBook(book); err != nil {
+		t.Fatalf("UpdateBook failed: %v", err)
+	}
+	if lib.GetBookByISBN("0156013983") != nil {
+		t.Error("GetBookByISBN should not find a removed ISBN-10")
+	}
+
+	// Removing the first book shifts positions of the remaining ones
+	if err := lib.RemoveBook("9780156013987"); err != nil {
+		t.Fatalf("RemoveBook failed: %v", err)
+	}
+	if lib.GetBookByID("9780156013987") != nil {
+		t.Error("Removed book should not be found")
+	}
+	if book := lib.GetBookByID("9780451524935"); book == nil || book.Title != "1984" {
+		t.Errorf("Remaining book lookup returned %v", book)
+	}
+	if len(lib.Document().Entries) != 1 {
+		t.Errorf("Expected entries of removed book to be dropped, got %d entries", len(lib.Document().Entries))
+	}
+	if count := lib.CountEntriesInCollection("favorites"); count != 0 {
+		t.Errorf("Expected 0 entries in 'favorites', got %d", count)
+	}
+
+	// Removing a collection drops it from entries
+	if err := lib.RemoveCollection("read"); err != nil {
+		t.Fatalf("RemoveCollection failed: %v", err)
+	}
+	if ids := lib.GetEntryForBook("9780451524935").CollectionIDs; len(ids) != 0 {
+		t.Errorf("Expected entry to lose removed collection, got %v", ids)
+	}
+
+	// Updating an entry
+	entry := Entry{BookID: "9780451524935", CollectionIDs: []string{"favorites"}, UserData: UserData{Status: "read"}}
+	if err := lib.UpdateEntry(entry); err != nil {
+		t.Fatalf("UpdateEntry failed: %v", err)
+	}
+	if count := lib.CountEntriesInCollection("favorites"); count != 1 {
+		t.Errorf("Expected 1 entry in 'favorites', got %d", count)
+	}
+}
+
+func TestLibraryMarshalJSON(t *testing.T) {
+	lib := newTestLibrary(t)
+
+	fromLibrary, err := json.Marshal(lib)
+	if err != nil {
+		t.Fatalf("Marshal library failed: %v", err)
+	}
+	fromDocument, err := json.Marshal(lib.Document())
+	if err != nil {
+		t.Fatalf("Marshal document failed: %v", err)
+	}
+	if !bytes.Equal(fromLibrary, fromDocument) {
+		t.Error("Library should serialize to the same JSON as its document")
+	}
+}
@@ -0,0 +1,137 @@
+package blef
+
+import "fmt"
+
+// LintRule is a consistency check run on every entry of a document. Unlike
+// validation rules, lint rules flag data that is allowed by the spec but
+// contradicts itself.
+type LintRule struct {
+	Code  string
+	Check func(lib *Library, i int, entry *Entry) []ValidationError
+}
+
+// Linter runs a set of lint rules
+type Linter struct {
+	rules []LintRule
+}
+
+// NewLinter creates a linter without any rule
+func NewLinter() *Linter {
+	return &Linter{}
+}
+
+// Register adds a lint rule
+func (l *Linter) Register(rule LintRule) {
+	l.rules = append(l.rules, rule)
+}
+
+// Lint runs every rule against every entry of the document
+func (l *Linter) Lint(doc *BLEFDocument) []error {
+	var errors []error
+
+	lib := NewLibrary(doc)
+	for i := range doc.Entries {
+		for _, rule := range l.rules {
+			for _, finding := range rule.Check(lib, i, &doc.Entries[i]) {
+				errors = append(errors, finding)
+			}
+		}
+	}
+
+	return errors
+}
+
+// LintDocument runs the default lint rules
+func LintDocument(doc *BLEFDocument) []error {
+	return DefaultLinter.Lint(doc)
+}
+
+// DefaultLinter is the global linter with the built-in rules
+var DefaultLinter = NewLinter()
+
+func init() {
+	DefaultLinter.Register(LintRule{Code: RuleReadWithoutFinishDate, Check: lintReadWithoutFinishDate})
+	DefaultLinter.Register(LintRule{Code: RuleFinishedBeforeStarted, Check: lintFinishedBeforeStarted})
+	DefaultLinter.Register(LintRule{Code: RuleLoanedNotOwned, Check: lintLoanedNotOwned})
+	DefaultLinter.Register(LintRule{Code: RuleRatingOnUnread, Check: lintRatingOnUnread})
+	DefaultLinter.Register(LintRule{Code: RuleCompleteWhileReading, Check: lintCompleteWhileReading})
+	DefaultLinter.Register(LintRule{Code: RuleStatusCollectionMismatch, Check: lintStatusCollectionMismatch})
+}
+
+func lintReadWithoutFinishDate(lib *Library, i int, entry *Entry) []ValidationError {
+	if entry.UserData.Status != "read" {
+		return nil
+	}
+	for _, readDate := range entry.UserData.ReadDates {
+		if !readDate.Finished.IsZero() {
+			return nil
+		}
+	}
+	return []ValidationError{newValidationError(RuleReadWithoutFinishDate, jsonPointer("entries", i, "user_data", "status"),
+		entry.UserData.Status, "book is marked as read but has no finished date")}
+}
+
+func lintFinishedBeforeStarted(lib *Library, i int, entry *Entry) []ValidationError {
+	var findings []ValidationError
+	for j, readDate := range entry.UserData.ReadDates {
+		// Malformed dates are reported by ValidateDocument
+		if !readDate.Started.IsValid() || !readDate.Finished.IsValid() {
+			continue
+		}
+		if readDate.Finished.Before(readDate.Started) {
+			findings = append(findings, newValidationError(RuleFinishedBeforeStarted,
+				jsonPointer("entries", i, "user_data", "read_dates", j, "finished"), readDate.Finished.String(),
+				fmt.Sprintf("finished date is before started date %s", readDate.Started)))
+		}
+	}
+	return findings
+}
+
+func lintLoanedNotOwned(lib *Library, i int, entry *Entry) []ValidationError {
+	ownership := entry.Ownership
+	if ownership == nil || ownership.Owned || ownership.Loaned == nil || !ownership.Loaned.Status {
+		return nil
+	}
+	return []ValidationError{newValidationError(RuleLoanedNotOwned, jsonPointer("entries", i, "ownership", "loaned", "status"),
+		true, "book is loaned out but not marked as owned")}
+}
+
+func lintRatingOnUnread(lib *Library, i int, entry *Entry) []ValidationError {
+	status := entry.UserData.Status
+	if entry.UserData.Rating == 0 || (status != "to-read" && status != "wishlist") {
+		return nil
+	}
+	return []ValidationError{newValidationError(RuleRatingOnUnread, jsonPointer("entries", i, "user_data", "rating"),
+		entry.UserData.Rating, fmt.Sprintf("book is rated but its status is %s", status))}
+}
+
+func lintCompleteWhileReading(lib *Library, i int, entry *Entry) []ValidationError {
+	readDates := entry.UserData.ReadDates
+	if entry.UserData.Status != "reading" || len(readDates) == 0 {
+		return nil
+	}
+	j := len(readDates) - 1
+	if readDates[j].Progress < 100 {
+		return nil
+	}
+	return []ValidationError{newValidationError(RuleCompleteWhileReading, jsonPointer("entries", i, "user_data", "read_dates", j, "progress"),
+		readDates[j].Progress, "progress is 100% but the book is still marked as reading")}
+}
+
+// statusCollectionTypes are the collection types that imply a reading status
+var statusCollectionTypes = map[string]bool{
+	"read": true, "reading": true, "to-read": true, "wishlist": true,
+}
+
+func lintStatusCollectionMismatch(lib *Library, i int, entry *Entry) []ValidationError {
+	var findings []ValidationError
+	for j, collID := range entry.CollectionIDs {
+		collection := lib.GetCollectionByID(collID)
+		if collection == nil || !statusCollectionTypes[collection.Type] || collection.Type == entry.UserData.Status {
+			continue
+		}
+		findings = append(findings, newValidationError(RuleStatusCollectionMismatch, jsonPointer("entries", i, "collection_ids", j), collID,
+			fmt.Sprintf("status %s disagrees with collection %s of type %s", entry.UserData.Status, collID, collection.Type)))
+	}
+	return findings
+}
@@ -0,0 +1,79 @@
+package blef
+
+import "testing"
+
+func TestLintDocument(t *testing.T) {
+	newDoc := func() *BLEFDocument {
+		doc := NewDocument()
+		doc.Books = []Book{{ID: "9780156013987", Title: "The Little Prince", Authors: []Author{{Name: "Antoine de Saint-Exupéry"}}}}
+		doc.Collections = []Collection{
+			{ID: "read", Name: "Read", Type: "read"},
+			{ID: "to-read", Name: "To Read", Type: "to-read"},
+			{ID: "favorites", Name: "Favorites", Type: "custom"},
+		}
+		doc.Entries = []Entry{{
+			BookID:        "9780156013987",
+			CollectionIDs: []string{"read", "favorites"},
+			UserData: UserData{
+				Status:    "read",
+				Rating:    5,
+				ReadDates: []ReadDate{{Started: MustParseDate("2024-01-02"), Finished: MustParseDate("2024-01-10"), Progress: 100}},
+			},
+			Ownership: &Ownership{Owned: true, Loaned: &Loaned{Status: true, To: "Alice"}},
+		}}
+		return doc
+	}
+
+	if findings := LintDocument(newDoc()); len(findings) > 0 {
+		t.Fatalf("LintDocument returned findings for a consistent document: %v", findings)
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(entry *Entry)
+		code    string
+		pointer string
+	}{
+		{"read without finish date", func(e *Entry) { e.UserData.ReadDates[0].Finished = PartialDate{} },
+			RuleReadWithoutFinishDate, "/entries/0/user_data/status"},
+		{"finished before started", func(e *Entry) { e.UserData.ReadDates[0].Finished = MustParseDate("2023-12-31") },
+			RuleFinishedBeforeStarted, "/entries/0/user_data/read_dates/0/finished"},
+		{"loaned not owned", func(e *Entry) { e.Ownership.Owned = false },
+			RuleLoanedNotOwned, "/entries/0/ownership/loaned/status"},
+		{"rating on to-read", func(e *Entry) {
+			e.UserData.Status = "to-read"
+			e.UserData.ReadDates = nil
+			e.CollectionIDs = []string{"to-read"}
+		}, RuleRatingOnUnread, "/entries/0/user_data/rating"},
+		{"complete while reading", func(e *Entry) {
+			e.UserData.Status = "reading"
+			e.UserData.ReadDates[0].Finished = PartialDate{}
+			e.CollectionIDs = []string{"favorites"}
+		}, RuleCompleteWhileReading, "/entries/0/user_data/read_dates/0/progress"},
+		{"status collection mismatch", func(e *Entry) { e.CollectionIDs = append(e.CollectionIDs, "to-read") },
+			RuleStatusCollectionMismatch, "/entries/0/collection_ids/2"},
+	}
+
+	for _, tt := range tests {
+		doc := newDoc()
+		tt.mutate(&doc.Entries[0])
+		findings := LintDocument(doc)
+		if len(findings) != 1 {
+			t.Errorf("%s: expected 1 finding, got %v", tt.name, findings)
+			continue
+		}
+		finding := findings[0].(ValidationError)
+		if finding.Code != tt.code || finding.Pointer != tt.pointer || finding.Severity != SeverityWarning {
+			t.Errorf("%s: got %s (%s) at %s, want %s at %s", tt.name, finding.Code, finding.Severity, finding.Pointer, tt.code, tt.pointer)
+		}
+	}
+
+	// Lint warnings do not make a report invalid
+	doc := newDoc()
+	doc.Entries[0].Ownership.Owned = false
+	report := ValidateDocumentReport(doc)
+	report.Add(LintDocument(doc)...)
+	if !report.Valid() || report.Count(SeverityWarning) != 1 {
+		t.Errorf("Expected a valid report with 1 warning, got %v", report.Findings)
+	}
+}
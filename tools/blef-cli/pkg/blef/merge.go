@@ -0,0 +1,716 @@
+package blef
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/isbn"
+)
+
+// MergeStrategy decides which value wins when both documents set a field
+type MergeStrategy string
+
+const (
+	// PreferNewest keeps the value of the entry with the latest added_at.
+	// For books and collections, which have no date, it behaves like PreferLeft.
+	PreferNewest MergeStrategy = "prefer-newest"
+	PreferLeft   MergeStrategy = "prefer-left"
+	PreferRight  MergeStrategy = "prefer-right"
+	// MaxRating keeps the highest rating
+	MaxRating MergeStrategy = "max"
+	// Union combines both lists without duplicates
+	Union MergeStrategy = "union"
+)
+
+// Fields that accept a per-field strategy in MergeOptions.Fields
+const (
+	MergeFieldBook         = "book"
+	MergeFieldCollection   = "collection"
+	MergeFieldStatus       = "status"
+	MergeFieldRating       = "rating"
+	MergeFieldReview       = "review"
+	MergeFieldPrivateNotes = "private_notes"
+	MergeFieldFavorite     = "favorite"
+	MergeFieldOwnership    = "ownership"
+	MergeFieldTags         = "tags"
+	MergeFieldReadDates    = "read_dates"
+	MergeFieldCollections  = "collection_ids"
+)
+
+// mergeFieldStrategies lists the strategies each field accepts
+var mergeFieldStrategies = map[string][]MergeStrategy{
+	MergeFieldBook:         {PreferNewest, PreferLeft, PreferRight},
+	MergeFieldCollection:   {PreferNewest, PreferLeft, PreferRight},
+	MergeFieldStatus:       {PreferNewest, PreferLeft, PreferRight},
+	MergeFieldRating:       {MaxRating, PreferNewest, PreferLeft, PreferRight},
+	MergeFieldReview:       {PreferNewest, PreferLeft, PreferRight},
+	MergeFieldPrivateNotes: {PreferNewest, PreferLeft, PreferRight},
+	MergeFieldFavorite:     {PreferNewest, PreferLeft, PreferRight},
+	MergeFieldOwnership:    {PreferNewest, PreferLeft, PreferRight},
+	MergeFieldTags:         {Union, PreferNewest, PreferLeft, PreferRight},
+	MergeFieldReadDates:    {Union, PreferNewest, PreferLeft, PreferRight},
+	MergeFieldCollections:  {Union, PreferNewest, PreferLeft, PreferRight},
+}
+
+// MergeOptions configures conflict resolution
+type MergeOptions struct {
+	// Default applies to every field without an override: prefer-newest,
+	// prefer-left or prefer-right
+	Default MergeStrategy
+
+	// Fields overrides the strategy of individual fields (see MergeField*)
+	Fields map[string]MergeStrategy
+}
+
+// DefaultMergeOptions prefers the newest entry, keeps the highest rating
+// and unions tags, read dates and collections
+func DefaultMergeOptions() MergeOptions {
+	return MergeOptions{
+		Default: PreferNewest,
+		Fields: map[string]MergeStrategy{
+			MergeFieldRating:      MaxRating,
+			MergeFieldTags:        Union,
+			MergeFieldReadDates:   Union,
+			MergeFieldCollections: Union,
+		},
+	}
+}
+
+// MergeFields returns the names of the fields accepting a strategy
+func MergeFields() []string {
+	fields := make([]string, 0, len(mergeFieldStrategies))
+	for field := range mergeFieldStrategies {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// Validate checks that every strategy is allowed for its field
+func (o MergeOptions) Validate() error {
+	switch o.Default {
+	case PreferNewest, PreferLeft, PreferRight:
+	default:
+		return fmt.Errorf("invalid default strategy %q (expected prefer-newest, prefer-left or prefer-right)", o.Default)
+	}
+
+	for field, strategy := range o.Fields {
+		allowed, ok := mergeFieldStrategies[field]
+		if !ok {
+			return fmt.Errorf("unknown merge field %q (known: %s)", field, strings.Join(MergeFields(), ", "))
+		}
+		valid := false
+		for _, s := range allowed {
+			valid = valid || s == strategy
+		}
+		if !valid {
+			names := make([]string, len(allowed))
+			for i, s := range allowed {
+				names[i] = string(s)
+			}
+			return fmt.Errorf("invalid strategy %q for %s (expected %s)", strategy, field, strings.Join(names, ", "))
+		}
+	}
+
+	return nil
+}
+
+func (o MergeOptions) strategy(field string) MergeStrategy {
+	if strategy, ok := o.Fields[field]; ok {
+		return strategy
+	}
+	return o.Default
+}
+
+// MergeConflict records a field set differently in both documents and how it
+// was resolved
+type MergeConflict struct {
+	Kind     ElementKind // KindBook, KindCollection, KindEntry, KindUser or KindDocument
+	ID       string      // book or collection ID in the merged document, empty for the user and the document
+	Field    string
+	Left     interface{}
+	Right    interface{}
+	Resolved interface{}
+	Strategy MergeStrategy
+}
+
+func (c MergeConflict) String() string {
+	subject := c.Kind.Singular()
+	if c.ID != "" {
+		subject += " " + c.ID
+	}
+	return fmt.Sprintf("%s %s: %s | %s → %s (%s)", subject, c.Field,
+		formatValue(c.Left), formatValue(c.Right), formatValue(c.Resolved), c.Strategy)
+}
+
+// formatValue renders a field value for reports: strings are quoted, nil is
+// "none" and other values are written as JSON
+func formatValue(v interface{}) string {
+	switch value := v.(type) {
+	case string:
+		return fmt.Sprintf("%q", value)
+	case nil:
+		return "none"
+	default:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Sprint(value)
+		}
+		return string(data)
+	}
+}
+
+// MergeResult is the outcome of Merge
+type MergeResult struct {
+	Document         *BLEFDocument
+	Conflicts        []MergeConflict
+	BooksMatched     int
+	BooksAdded       int
+	CollectionsAdded int
+	EntriesMerged    int
+	EntriesAdded     int
+}
+
+// Merge combines two documents into a new one. Books are matched by ID,
+// ISBN-13, ISBN-10 and the other identifiers; collections are united by ID;
+// entries of matched books are reconciled field by field according to opts.
+// A field left empty in one document (no rating, no review, favorite false)
+// does not replace a value set in the other: when the strategy picks the empty
+// side, the set value is kept and the conflict is reported. An entry that
+// cannot be added, for instance because it references a collection missing
+// from right, fails the merge. Members unknown to this version of BLEF are
+// united like metadata, a member set differently on both sides being a
+// conflict. Neither input document is modified.
+func Merge(left, right *BLEFDocument, opts MergeOptions) (*MergeResult, error) {
+	return merge(left, right, opts, make(map[string]time.Time))
+}
+
+// MergeAll merges documents from left to right, like chained calls to Merge,
+// and returns one result per document after the first, each holding the
+// documents merged so far. Unlike chained calls, prefer-newest compares an
+// entry with the latest added_at of the entries already merged for its book,
+// rather than with the earliest one kept in the merged document.
+func MergeAll(docs []*BLEFDocument, opts MergeOptions) ([]*MergeResult, error) {
+	if len(docs) < 2 {
+		return nil, fmt.Errorf("at least two documents are needed, got %d", len(docs))
+	}
+	newest := make(map[string]time.Time)
+	merged := docs[0]
+	var results []*MergeResult
+	for _, doc := range docs[1:] {
+		result, err := merge(merged, doc, opts, newest)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+		merged = result.Document
+	}
+	return results, nil
+}
+
+// merge merges right into a copy of left. newest maps merged book IDs to the
+// latest added_at of the entries merged so far, and is updated.
+func merge(left, right *BLEFDocument, opts MergeOptions, newest map[string]time.Time) (*MergeResult, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if left.Version != right.Version {
+		return nil, fmt.Errorf("documents have different versions (%s and %s), migrate them first", left.Version, right.Version)
+	}
+
+	merged, err := cloneDocument(left)
+	if err != nil {
+		return nil, err
+	}
+	other, err := cloneDocument(right)
+	if err != nil {
+		return nil, err
+	}
+	merged.ExportedAt = time.Now().UTC()
+
+	m := &merger{
+		opts:   opts,
+		lib:    NewLibrary(merged),
+		result: &MergeResult{Document: merged},
+		bookID: make(map[string]string),
+		newest: newest,
+	}
+	m.indexIdentifiers()
+
+	preferRight := opts.Default == PreferRight
+	merged.Extensions = m.mergeExtensions(KindDocument, "", "", merged.Extensions, other.Extensions, opts.Default, preferRight)
+	switch {
+	case merged.User == nil:
+		merged.User = other.User
+	case other.User != nil:
+		merged.User.Extensions = m.mergeExtensions(KindUser, "", "", merged.User.Extensions, other.User.Extensions, opts.Default, preferRight)
+	}
+
+	for i := range other.Books {
+		m.mergeBook(&other.Books[i])
+	}
+	for i := range other.Collections {
+		m.mergeCollection(&other.Collections[i])
+	}
+	for i := range other.Entries {
+		if err := m.mergeEntry(&other.Entries[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return m.result, nil
+}
+
+// cloneDocument deep copies a document so merging never aliases the inputs
+func cloneDocument(doc *BLEFDocument) (*BLEFDocument, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy document: %w", err)
+	}
+	var clone BLEFDocument
+	if err := json.Unmarshal(data, &clone); err != nil {
+		return nil, fmt.Errorf("failed to copy document: %w", err)
+	}
+	return &clone, nil
+}
+
+type merger struct {
+	opts   MergeOptions
+	lib    *Library
+	result *MergeResult
+
+	// bookID maps right book IDs to merged book IDs
+	bookID map[string]string
+
+	// identifiers maps "scheme:value" of the other identifiers to book IDs
+	identifiers map[string]string
+
+	// newest maps merged book IDs to the latest added_at of their merged
+	// entries, as the merged entry keeps the earliest
+	newest map[string]time.Time
+}
+
+func (m *merger) indexIdentifiers() {
+	m.identifiers = make(map[string]string)
+	for i := range m.lib.Document().Books {
+		m.addIdentifiers(&m.lib.Document().Books[i])
+	}
+}
+
+func (m *merger) addIdentifiers(book *Book) {
+	for key := range otherIdentifiers(book) {
+		if _, exists := m.identifiers[key]; !exists {
+			m.identifiers[key] = book.ID
+		}
+	}
+}
+
+// otherIdentifiers returns the identifiers other than ISBNs as "scheme:value" keys
+func otherIdentifiers(book *Book) map[string]bool {
+	ids := make(map[string]bool)
+	add := func(scheme, value string) {
+		if value != "" {
+			ids[scheme+":"+value] = true
+		}
+	}
+	add("asin", book.Identifiers.ASIN)
+	add("openlibrary", book.Identifiers.OpenLibrary)
+	add("wikidata", book.Identifiers.Wikidata)
+	add("goodreads", book.Identifiers.Goodreads)
+	for scheme, value := range book.Identifiers.Other {
+		if s, ok := value.(string); ok {
+			add(scheme, s)
+		}
+	}
+	return ids
+}
+
+// findBook returns the merged book matching a right book, if any
+func (m *merger) findBook(book *Book) *Book {
+	if match := m.lib.GetBookByID(book.ID); match != nil {
+		return match
+	}
+	// ISBNs are compared in both forms, so an ISBN-10 matches its ISBN-13
+	for _, value := range []string{book.Identifiers.ISBN13, book.ID, book.Identifiers.ISBN10} {
+		isbn13, isbn10, ok := normalizeBookID(value)
+		if !ok {
+			continue
+		}
+		if isbn10 == "" {
+			isbn10, _ = isbn.To10(isbn13)
+		}
+		for _, candidate := range []string{isbn13, isbn10} {
+			if candidate == "" {
+				continue
+			}
+			if match := m.lib.GetBookByISBN(candidate); match != nil {
+				return match
+			}
+			if match := m.lib.GetBookByID(candidate); match != nil {
+				return match
+			}
+		}
+	}
+	for key := range otherIdentifiers(book) {
+		if id, ok := m.identifiers[key]; ok {
+			return m.lib.GetBookByID(id)
+		}
+	}
+	return nil
+}
+
+func (m *merger) mergeBook(book *Book) {
+	match := m.findBook(book)
+	if match == nil {
+		m.bookID[book.ID] = book.ID
+		if m.lib.AddBook(*book) == nil {
+			m.addIdentifiers(book)
+			m.result.BooksAdded++
+		}
+		return
+	}
+
+	m.bookID[book.ID] = match.ID
+	m.result.BooksMatched++
+	m.mergeBookInto(match, book)
+}
+
+// mergeBookInto reconciles the fields of book into match, which is kept
+func (m *merger) mergeBookInto(match, book *Book) {
+	merged := *match
+	right := m.opts.strategy(MergeFieldBook) == PreferRight
+	resolve := func(field string, left, other interface{}) bool {
+		if isEmptyValue(left) || isEmptyValue(other) || reflect.DeepEqual(left, other) {
+			return isEmptyValue(left) && !isEmptyValue(other)
+		}
+		resolved := left
+		if right {
+			resolved = other
+		}
+		m.conflict(KindBook, match.ID, field, left, other, resolved, m.opts.strategy(MergeFieldBook))
+		return right
+	}
+
+	if resolve("title", merged.Title, book.Title) {
+		merged.Title = book.Title
+	}
+	if resolve("subtitle", merged.Subtitle, book.Subtitle) {
+		merged.Subtitle = book.Subtitle
+	}
+	if resolve("authors", merged.Authors, book.Authors) {
+		merged.Authors = book.Authors
+	}
+	if resolve("language", merged.Language, book.Language) {
+		merged.Language = book.Language
+	}
+	if resolve("description", merged.Description, book.Description) {
+		merged.Description = book.Description
+	}
+	if resolve("cover_url", merged.CoverURL, book.CoverURL) {
+		merged.CoverURL = book.CoverURL
+	}
+	if resolve("edition", merged.Edition, book.Edition) {
+		merged.Edition = book.Edition
+	}
+	if resolve("series", merged.Series, book.Series) {
+		merged.Series = book.Series
+	}
+	merged.Subjects = unionStrings(merged.Subjects, book.Subjects)
+	identifiers := mergeIdentifiers(merged.Identifiers, book.Identifiers)
+	identifiers.Extensions = m.mergeExtensions(KindBook, match.ID, "identifiers.", merged.Identifiers.Extensions, book.Identifiers.Extensions, m.opts.strategy(MergeFieldBook), right)
+	merged.Identifiers = identifiers
+	merged.Metadata = mergeMetadata(merged.Metadata, book.Metadata, right)
+	merged.Extensions = m.mergeExtensions(KindBook, match.ID, "", merged.Extensions, book.Extensions, m.opts.strategy(MergeFieldBook), right)
+
+	_ = m.lib.UpdateBook(merged)
+	m.addIdentifiers(&merged)
+}
+
+// mergeIdentifiers fills the identifiers missing from left
+func mergeIdentifiers(left, right Identifiers) Identifiers {
+	fill := func(dst *string, src string) {
+		if *dst == "" {
+			*dst = src
+		}
+	}
+	fill(&left.ISBN13, right.ISBN13)
+	fill(&left.ISBN10, right.ISBN10)
+	fill(&left.ASIN, right.ASIN)
+	fill(&left.OpenLibrary, right.OpenLibrary)
+	fill(&left.Wikidata, right.Wikidata)
+	fill(&left.Goodreads, right.Goodreads)
+	left.Other = mergeMetadata(left.Other, right.Other, false)
+	return left
+}
+
+// mergeMetadata unions two metadata maps, preferRight deciding shared keys
+func mergeMetadata(left, right map[string]interface{}, preferRight bool) map[string]interface{} {
+	if len(right) == 0 {
+		return left
+	}
+	merged := make(map[string]interface{}, len(left)+len(right))
+	for key, value := range left {
+		merged[key] = value
+	}
+	for key, value := range right {
+		if _, exists := merged[key]; !exists || preferRight {
+			merged[key] = value
+		}
+	}
+	return merged
+}
+
+func (m *merger) mergeCollection(collection *Collection) {
+	match := m.lib.GetCollectionByID(collection.ID)
+	if match == nil {
+		if m.lib.AddCollection(*collection) == nil {
+			m.result.CollectionsAdded++
+		}
+		return
+	}
+
+	strategy := m.opts.strategy(MergeFieldCollection)
+	merged := *match
+	for _, field := range []struct {
+		name        string
+		left, right *string
+	}{
+		{"name", &merged.Name, &collection.Name},
+		{"type", &merged.Type, &collection.Type},
+		{"description", &merged.Description, &collection.Description},
+	} {
+		switch {
+		case *field.right == "" || *field.left == *field.right:
+		case *field.left == "":
+			*field.left = *field.right
+		default:
+			resolved := *field.left
+			if strategy == PreferRight {
+				resolved = *field.right
+			}
+			m.conflict(KindCollection, match.ID, field.name, *field.left, *field.right, resolved, strategy)
+			*field.left = resolved
+		}
+	}
+	merged.Metadata = mergeMetadata(merged.Metadata, collection.Metadata, strategy == PreferRight)
+	merged.Extensions = m.mergeExtensions(KindCollection, match.ID, "", merged.Extensions, collection.Extensions, strategy, strategy == PreferRight)
+
+	_ = m.lib.UpdateCollection(merged)
+}
+
+func (m *merger) mergeEntry(entry *Entry) error {
+	if id, ok := m.bookID[entry.BookID]; ok {
+		entry.BookID = id
+	}
+
+	existing := m.lib.GetEntryForBook(entry.BookID)
+	if existing == nil {
+		if err := m.lib.AddEntry(*entry); err != nil {
+			return fmt.Errorf("cannot add entry for book %s: %w", entry.BookID, err)
+		}
+		m.result.EntriesAdded++
+		return nil
+	}
+
+	merged := *existing
+	left, right := &merged.UserData, &entry.UserData
+	leftNewest := left.AddedAt
+	if newest, ok := m.newest[merged.BookID]; ok {
+		leftNewest = &newest
+	}
+
+	// preferRight resolves a prefer-* strategy for this pair of entries
+	preferRight := func(strategy MergeStrategy) bool {
+		switch strategy {
+		case PreferRight:
+			return true
+		case PreferNewest:
+			return leftNewest == nil && right.AddedAt != nil ||
+				leftNewest != nil && right.AddedAt != nil && right.AddedAt.After(*leftNewest)
+		default:
+			return false
+		}
+	}
+	// scalar reports whether the right value should replace the left one.
+	// Empty values never replace set ones, whatever the strategy.
+	scalar := func(field string, leftValue, rightValue interface{}) bool {
+		if reflect.DeepEqual(leftValue, rightValue) || isEmptyValue(leftValue) && isEmptyValue(rightValue) {
+			return false
+		}
+		if isEmptyValue(leftValue) {
+			return true
+		}
+		strategy := m.opts.strategy(field)
+		useRight := preferRight(strategy)
+		if isEmptyValue(rightValue) {
+			if useRight {
+				m.conflict(KindEntry, merged.BookID, field, leftValue, rightValue, leftValue, strategy)
+			}
+			return false
+		}
+		resolved := leftValue
+		if useRight {
+			resolved = rightValue
+		}
+		m.conflict(KindEntry, merged.BookID, field, leftValue, rightValue, resolved, strategy)
+		return useRight
+	}
+
+	if scalar(MergeFieldStatus, left.Status, right.Status) {
+		left.Status = right.Status
+	}
+	if m.opts.strategy(MergeFieldRating) == MaxRating {
+		if right.Rating != left.Rating && left.Rating != 0 && right.Rating != 0 {
+			resolved := left.Rating
+			if right.Rating > resolved {
+				resolved = right.Rating
+			}
+			m.conflict(KindEntry, merged.BookID, MergeFieldRating, left.Rating, right.Rating, resolved, MaxRating)
+		}
+		if right.Rating > left.Rating {
+			left.Rating = right.Rating
+		}
+	} else if scalar(MergeFieldRating, left.Rating, right.Rating) {
+		left.Rating = right.Rating
+	}
+	if scalar(MergeFieldReview, left.Review, right.Review) {
+		left.Review = right.Review
+	}
+	if scalar(MergeFieldPrivateNotes, left.PrivateNotes, right.PrivateNotes) {
+		left.PrivateNotes = right.PrivateNotes
+	}
+	if scalar(MergeFieldFavorite, left.Favorite, right.Favorite) {
+		left.Favorite = right.Favorite
+	}
+	if scalar(MergeFieldOwnership, merged.Ownership, entry.Ownership) {
+		merged.Ownership = entry.Ownership
+	}
+
+	// list merges the list fields, either by union or by picking a side
+	list := func(field string, leftValue, rightValue interface{}) bool {
+		if m.opts.strategy(field) == Union {
+			return false
+		}
+		return scalar(field, leftValue, rightValue)
+	}
+
+	if m.opts.strategy(MergeFieldTags) == Union {
+		left.Tags = unionStrings(left.Tags, right.Tags)
+	} else if list(MergeFieldTags, left.Tags, right.Tags) {
+		left.Tags = right.Tags
+	}
+	if m.opts.strategy(MergeFieldReadDates) == Union {
+		left.ReadDates = unionReadDates(left.ReadDates, right.ReadDates)
+	} else if list(MergeFieldReadDates, left.ReadDates, right.ReadDates) {
+		left.ReadDates = right.ReadDates
+	}
+	if m.opts.strategy(MergeFieldCollections) == Union {
+		merged.CollectionIDs = unionStrings(merged.CollectionIDs, entry.CollectionIDs)
+	} else if list(MergeFieldCollections, merged.CollectionIDs, entry.CollectionIDs) {
+		merged.CollectionIDs = entry.CollectionIDs
+	}
+
+	// The merged entry was added when the book was first added anywhere
+	switch {
+	case right.AddedAt != nil && (leftNewest == nil || right.AddedAt.After(*leftNewest)):
+		m.newest[merged.BookID] = *right.AddedAt
+	case leftNewest != nil:
+		m.newest[merged.BookID] = *leftNewest
+	}
+	if right.AddedAt != nil && (left.AddedAt == nil || right.AddedAt.Before(*left.AddedAt)) {
+		left.AddedAt = right.AddedAt
+	}
+	merged.Metadata = mergeMetadata(merged.Metadata, entry.Metadata, false)
+	useRight := preferRight(m.opts.Default)
+	merged.Extensions = m.mergeExtensions(KindEntry, merged.BookID, "", merged.Extensions, entry.Extensions, m.opts.Default, useRight)
+	left.Extensions = m.mergeExtensions(KindEntry, merged.BookID, "user_data.", left.Extensions, right.Extensions, m.opts.Default, useRight)
+
+	if err := m.lib.UpdateEntry(merged); err != nil {
+		return fmt.Errorf("cannot merge entry for book %s: %w", merged.BookID, err)
+	}
+	m.result.EntriesMerged++
+	return nil
+}
+
+// mergeExtensions unions the unknown members of two matched elements. A
+// member set to different values on both sides is a conflict, resolved by
+// preferRight; prefix is added to its name in the conflict.
+func (m *merger) mergeExtensions(kind ElementKind, id, prefix string, left, right Extensions, strategy MergeStrategy, preferRight bool) Extensions {
+	if len(right) == 0 {
+		return left
+	}
+	merged := append(Extensions(nil), left...)
+	for _, member := range right {
+		value := merged.Get(member.Name)
+		switch {
+		case value == nil:
+			merged = append(merged, member)
+		case !bytes.Equal(value, member.Value) && preferRight:
+			m.conflict(kind, id, prefix+member.Name, value, member.Value, member.Value, strategy)
+			for i := range merged {
+				if merged[i].Name == member.Name {
+					merged[i].Value = member.Value
+				}
+			}
+		case !bytes.Equal(value, member.Value):
+			m.conflict(kind, id, prefix+member.Name, value, member.Value, value, strategy)
+		}
+	}
+	return merged
+}
+
+func (m *merger) conflict(kind ElementKind, id, field string, left, right, resolved interface{}, strategy MergeStrategy) {
+	m.result.Conflicts = append(m.result.Conflicts, MergeConflict{
+		Kind:     kind,
+		ID:       id,
+		Field:    field,
+		Left:     left,
+		Right:    right,
+		Resolved: resolved,
+		Strategy: strategy,
+	})
+}
+
+// isEmptyValue reports whether v is a zero value, a nil pointer or an empty slice
+func isEmptyValue(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	value := reflect.ValueOf(v)
+	switch value.Kind() {
+	case reflect.Slice, reflect.Map:
+		return value.Len() == 0
+	default:
+		return value.IsZero()
+	}
+}
+
+func unionStrings(left, right []string) []string {
+	for _, value := range right {
+		if !containsString(left, value) {
+			left = append(left, value)
+		}
+	}
+	return left
+}
+
+func unionReadDates(left, right []ReadDate) []ReadDate {
+	for _, readDate := range right {
+		found := false
+		for _, existing := range left {
+			if reflect.DeepEqual(existing, readDate) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			left = append(left, readDate)
+		}
+	}
+	return left
+}
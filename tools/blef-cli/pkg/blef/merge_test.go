@@ -0,0 +1,310 @@
+package blef
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func mergeTestDocuments() (*BLEFDocument, *BLEFDocument) {
+	older := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	left := NewDocument()
+	left.Books = []Book{
+		{ID: "9780156013987", Title: "The Little Prince", Authors: []Author{{Name: "Antoine de Saint-Exupéry"}},
+			Identifiers: Identifiers{ISBN13: "9780156013987"}},
+		{ID: "9780451524935", Title: "1984", Authors: []Author{{Name: "George Orwell"}},
+			Identifiers: Identifiers{ISBN13: "9780451524935"}},
+	}
+	left.Collections = []Collection{{ID: "read", Name: "Read", Type: "read"}}
+	left.Entries = []Entry{
+		{BookID: "9780156013987", CollectionIDs: []string{"read"}, UserData: UserData{
+			Status: "read", Rating: 4, Tags: []string{"classic"}, AddedAt: &older,
+			ReadDates: []ReadDate{{Started: MustParseDate("2023-01-01"), Finished: MustParseDate("2023-01-10")}},
+		}},
+		{BookID: "9780451524935", CollectionIDs: []string{"read"}, UserData: UserData{Status: "read"}},
+	}
+
+	right := NewDocument()
+	right.Books = []Book{
+		// Same book under its ISBN-10
+		{ID: "book-1", Title: "Le Petit Prince", Authors: []Author{{Name: "Antoine de Saint-Exupéry"}},
+			Language: "fr", Identifiers: Identifiers{ISBN10: "0156013983"}},
+		// Only in right
+		{ID: "9780547928227", Title: "The Hobbit", Authors: []Author{{Name: "J.R.R. Tolkien"}}},
+	}
+	right.Collections = []Collection{
+		{ID: "read", Name: "Books read", Type: "read"},
+		{ID: "favorites", Name: "Favorites", Type: "custom"},
+		{ID: "to-read", Name: "To read", Type: "to-read"},
+	}
+	right.Entries = []Entry{
+		{BookID: "book-1", CollectionIDs: []string{"favorites"}, UserData: UserData{
+			Status: "reading", Rating: 5, Tags: []string{"french", "classic"}, AddedAt: &newer,
+			ReadDates: []ReadDate{{Started: MustParseDate("2024-06-01")}},
+		}},
+		{BookID: "9780547928227", CollectionIDs: []string{"to-read"}, UserData: UserData{Status: "to-read"}},
+	}
+
+	return left, right
+}
+
+func TestMerge(t *testing.T) {
+	left, right := mergeTestDocuments()
+
+	result, err := Merge(left, right, DefaultMergeOptions())
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	doc := result.Document
+
+	if result.BooksMatched != 1 || result.BooksAdded != 1 || result.CollectionsAdded != 2 ||
+		result.EntriesMerged != 1 || result.EntriesAdded != 1 {
+		t.Errorf("Unexpected counts: %+v", result)
+	}
+	if errors := ValidateDocument(doc); len(errors) > 0 {
+		t.Fatalf("Merged document is invalid: %v", errors)
+	}
+
+	book := doc.GetBookByID("9780156013987")
+	if book == nil || book.Title != "The Little Prince" || book.Language != "fr" || book.Identifiers.ISBN10 != "0156013983" {
+		t.Errorf("Expected matched book to keep its title and gain language and ISBN-10, got %+v", book)
+	}
+
+	entries := doc.GetEntriesForBook("9780156013987")
+	if len(entries) != 1 {
+		t.Fatalf("Expected a single entry for the matched book, got %d", len(entries))
+	}
+	data := entries[0].UserData
+	if data.Status != "reading" {
+		t.Errorf("Expected newest status reading, got %s", data.Status)
+	}
+	if data.Rating != 5 {
+		t.Errorf("Expected max rating 5, got %g", data.Rating)
+	}
+	if len(data.Tags) != 2 || len(data.ReadDates) != 2 || len(entries[0].CollectionIDs) != 2 {
+		t.Errorf("Expected lists to be united, got tags %v, read dates %v, collections %v",
+			data.Tags, data.ReadDates, entries[0].CollectionIDs)
+	}
+	if !data.AddedAt.Equal(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected earliest added_at to be kept, got %v", data.AddedAt)
+	}
+
+	conflicts := make(map[string]MergeConflict)
+	for _, conflict := range result.Conflicts {
+		conflicts[conflict.Field] = conflict
+	}
+	for _, field := range []string{"title", "name", MergeFieldStatus, MergeFieldRating} {
+		if _, ok := conflicts[field]; !ok {
+			t.Errorf("Expected a %s conflict, got %v", field, result.Conflicts)
+		}
+	}
+	if conflict := conflicts[MergeFieldStatus]; conflict.Resolved != "reading" || conflict.Strategy != PreferNewest {
+		t.Errorf("Unexpected status conflict: %+v", conflict)
+	}
+
+	// Inputs are left untouched
+	if len(left.Books) != 2 || left.Entries[0].UserData.Status != "read" || len(left.Entries[0].UserData.Tags) != 1 {
+		t.Errorf("Merge modified its left input: %+v", left)
+	}
+}
+
+func TestMergeStrategies(t *testing.T) {
+	left, right := mergeTestDocuments()
+
+	opts := MergeOptions{
+		Default: PreferLeft,
+		Fields: map[string]MergeStrategy{
+			MergeFieldRating: PreferRight,
+			MergeFieldTags:   PreferRight,
+			MergeFieldBook:   PreferRight,
+		},
+	}
+	result, err := Merge(left, right, opts)
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+
+	entry := result.Document.GetEntriesForBook("9780156013987")[0]
+	if entry.UserData.Status != "read" || entry.UserData.Rating != 5 {
+		t.Errorf("Expected left status and right rating, got %s and %g", entry.UserData.Status, entry.UserData.Rating)
+	}
+	if len(entry.UserData.Tags) != 2 || entry.UserData.Tags[0] != "french" {
+		t.Errorf("Expected right tags, got %v", entry.UserData.Tags)
+	}
+	if len(entry.UserData.ReadDates) != 1 {
+		t.Errorf("Expected left read dates with prefer-left default, got %v", entry.UserData.ReadDates)
+	}
+	if book := result.Document.GetBookByID("9780156013987"); book.Title != "Le Petit Prince" {
+		t.Errorf("Expected right book title, got %s", book.Title)
+	}
+}
+
+func TestMergeKeepsSetValues(t *testing.T) {
+	left, right := mergeTestDocuments()
+	left.Entries[0].UserData.Favorite = true
+
+	opts := MergeOptions{Default: PreferRight}
+	result, err := Merge(left, right, opts)
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+
+	entry := result.Document.GetEntriesForBook("9780156013987")[0]
+	if !entry.UserData.Favorite {
+		t.Error("Expected the favorite flag set on the left to be kept")
+	}
+	var reported bool
+	for _, conflict := range result.Conflicts {
+		if conflict.Field == MergeFieldFavorite {
+			reported = conflict.Resolved == true && conflict.Right == false
+		}
+	}
+	if !reported {
+		t.Errorf("Expected the kept favorite flag to be reported, got %v", result.Conflicts)
+	}
+}
+
+func TestMergeUnknownMembers(t *testing.T) {
+	member := func(name, value string) Member { return Member{Name: name, Value: json.RawMessage(value)} }
+	left, right := mergeTestDocuments()
+	left.Extensions = Extensions{member("x_source", `"goodreads"`)}
+	left.Collections[0].Extensions = Extensions{member("x_color", `"red"`)}
+	right.Extensions = Extensions{member("x_source", `"babelio"`), member("x_synced", `true`)}
+	right.User = &User{ID: "reader", Extensions: Extensions{member("x_theme", `"dark"`)}}
+	right.Books[0].Extensions = Extensions{member("x_shelf", `3`)}
+	right.Books[0].Identifiers.Extensions = Extensions{member("x_bnf", `"cb11915437"`)}
+	right.Collections[0].Extensions = Extensions{member("x_color", `"blue"`)}
+	right.Entries[0].Extensions = Extensions{member("x_device", `"kobo"`)}
+	right.Entries[0].UserData.Extensions = Extensions{member("x_mood", `"cozy"`)}
+
+	result, err := Merge(left, right, DefaultMergeOptions())
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	want := []string{
+		"/x_source", "/x_synced", "/user/x_theme",
+		"/books/0/x_shelf", "/books/0/identifiers/x_bnf",
+		"/collections/0/x_color",
+		"/entries/0/x_device", "/entries/0/user_data/x_mood",
+	}
+	if got := UnknownFields(result.Document); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected every unknown member to be kept, got %v", got)
+	}
+	if got := string(result.Document.Extensions.Get("x_source")); got != `"goodreads"` {
+		t.Errorf("Expected the left value to be kept, got %s", got)
+	}
+
+	conflicts := make(map[string]MergeConflict)
+	for _, conflict := range result.Conflicts {
+		conflicts[conflict.Kind.Singular()+" "+conflict.Field] = conflict
+	}
+	if c, ok := conflicts["document x_source"]; !ok || string(c.Right.(json.RawMessage)) != `"babelio"` {
+		t.Errorf("Expected the clashing root member to be reported, got %v", result.Conflicts)
+	}
+	if _, ok := conflicts["collection x_color"]; !ok {
+		t.Errorf("Expected the clashing collection member to be reported, got %v", result.Conflicts)
+	}
+
+	result, err = Merge(left, right, MergeOptions{Default: PreferRight})
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if got := string(result.Document.Collections[0].Extensions.Get("x_color")); got != `"blue"` {
+		t.Errorf("Expected prefer-right to keep the right value, got %s", got)
+	}
+}
+
+func TestMergeAllPrefersNewest(t *testing.T) {
+	library := func(status string, added time.Time) *BLEFDocument {
+		doc := NewDocument()
+		doc.Books = []Book{{ID: "9780547928227", Title: "The Hobbit", Authors: []Author{{Name: "J.R.R. Tolkien"}}}}
+		doc.Collections = []Collection{{ID: "library", Name: "Library", Type: "custom"}}
+		doc.Entries = []Entry{{BookID: "9780547928227", CollectionIDs: []string{"library"}, UserData: UserData{Status: status, AddedAt: &added}}}
+		return doc
+	}
+	oldest := library("to-read", time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC))
+	newest := library("reading", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	middle := library("read", time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC))
+
+	opts := MergeOptions{Default: PreferNewest}
+	for _, docs := range [][]*BLEFDocument{{oldest, newest, middle}, {oldest, middle, newest}, {middle, newest, oldest}} {
+		results, err := MergeAll(docs, opts)
+		if err != nil {
+			t.Fatalf("MergeAll failed: %v", err)
+		}
+		if len(results) != 2 {
+			t.Fatalf("Expected one result per merged document, got %d", len(results))
+		}
+		entry := results[1].Document.Entries[0]
+		if entry.UserData.Status != "reading" {
+			t.Errorf("Expected the status of the newest entry whatever the order, got %q", entry.UserData.Status)
+		}
+		if !entry.UserData.AddedAt.Equal(*oldest.Entries[0].UserData.AddedAt) {
+			t.Errorf("Expected the earliest added_at to be kept, got %v", entry.UserData.AddedAt)
+		}
+	}
+}
+
+func TestMergeInvalidEntry(t *testing.T) {
+	left, right := mergeTestDocuments()
+	right.Entries[1].CollectionIDs = []string{"missing"}
+
+	if _, err := Merge(left, right, DefaultMergeOptions()); err == nil {
+		t.Error("Expected an error for an entry referencing a missing collection")
+	}
+}
+
+func TestMergeMatchesOtherIdentifiers(t *testing.T) {
+	left := NewDocument()
+	left.Books = []Book{{ID: "hobbit", Title: "The Hobbit", Authors: []Author{{Name: "J.R.R. Tolkien"}},
+		Identifiers: Identifiers{OpenLibrary: "OL262758W"}}}
+	right := NewDocument()
+	right.Books = []Book{{ID: "tolkien-hobbit", Title: "The Hobbit", Authors: []Author{{Name: "J.R.R. Tolkien"}},
+		Identifiers: Identifiers{OpenLibrary: "OL262758W", ASIN: "B007978NPG"}}}
+	right.Collections = []Collection{{ID: "read", Name: "Read", Type: "read"}}
+	right.Entries = []Entry{{BookID: "tolkien-hobbit", CollectionIDs: []string{"read"}, UserData: UserData{Status: "read"}}}
+
+	result, err := Merge(left, right, DefaultMergeOptions())
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if len(result.Document.Books) != 1 || result.Document.Books[0].Identifiers.ASIN != "B007978NPG" {
+		t.Errorf("Expected books to be matched by Open Library ID, got %+v", result.Document.Books)
+	}
+	if entries := result.Document.GetEntriesForBook("hobbit"); len(entries) != 1 {
+		t.Errorf("Expected the right entry to be attached to the matched book, got %v", result.Document.Entries)
+	}
+}
+
+func TestMergeOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  MergeOptions
+		valid bool
+	}{
+		{"defaults", DefaultMergeOptions(), true},
+		{"max default", MergeOptions{Default: MaxRating}, false},
+		{"union on status", MergeOptions{Default: PreferLeft, Fields: map[string]MergeStrategy{MergeFieldStatus: Union}}, false},
+		{"max on rating", MergeOptions{Default: PreferLeft, Fields: map[string]MergeStrategy{MergeFieldRating: MaxRating}}, true},
+		{"unknown field", MergeOptions{Default: PreferLeft, Fields: map[string]MergeStrategy{"color": PreferLeft}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.opts.Validate(); (err == nil) != tt.valid {
+				t.Errorf("Validate() = %v, expected valid=%v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestMergeVersionMismatch(t *testing.T) {
+	left, right := mergeTestDocuments()
+	right.Version = "0.2.0"
+	if _, err := Merge(left, right, DefaultMergeOptions()); err == nil {
+		t.Error("Expected an error when merging documents of different versions")
+	}
+}
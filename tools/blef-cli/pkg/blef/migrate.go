@@ -0,0 +1,163 @@
+package blef
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MigrationLoss describes data that could not be carried over by a migration step
+type MigrationLoss struct {
+	From    string
+	To      string
+	Field   string
+	Message string
+}
+
+func (l MigrationLoss) String() string {
+	return fmt.Sprintf("%s → %s: %s: %s", l.From, l.To, l.Field, l.Message)
+}
+
+// Migration converts a document between two spec versions. Register one
+// Migration per direction: an upgrade step and a downgrade step are two
+// separate migrations.
+type Migration struct {
+	From        string
+	To          string
+	Description string
+
+	// Apply transforms the document in place and returns the lossy changes it
+	// made. It must not touch doc.Version, the registry sets it afterwards.
+	Apply func(doc *BLEFDocument) ([]MigrationLoss, error)
+}
+
+// MigrationResult summarizes a completed migration
+type MigrationResult struct {
+	From  string
+	To    string
+	Steps []Migration
+	Loss  []MigrationLoss
+}
+
+// Lossy reports whether any step dropped or altered data
+func (r *MigrationResult) Lossy() bool {
+	return len(r.Loss) > 0
+}
+
+// MigrationRegistry manages the known versions and the steps between them
+type MigrationRegistry struct {
+	versions   map[string]bool
+	migrations []Migration
+}
+
+// NewMigrationRegistry creates a registry knowing only CurrentVersion
+func NewMigrationRegistry() *MigrationRegistry {
+	return &MigrationRegistry{
+		versions: map[string]bool{CurrentVersion: true},
+	}
+}
+
+// Register adds a migration step. Both of its versions become known.
+func (r *MigrationRegistry) Register(m Migration) {
+	r.versions[m.From] = true
+	r.versions[m.To] = true
+	r.migrations = append(r.migrations, m)
+}
+
+// Versions returns the known versions in ascending order
+func (r *MigrationRegistry) Versions() []string {
+	versions := make([]string, 0, len(r.versions))
+	for v := range r.versions {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		a, errA := ParseVersion(versions[i])
+		b, errB := ParseVersion(versions[j])
+		if errA != nil || errB != nil {
+			return versions[i] < versions[j]
+		}
+		return a.Compare(b) < 0
+	})
+	return versions
+}
+
+// Path returns the shortest chain of migrations leading from one version to another
+func (r *MigrationRegistry) Path(from, to string) ([]Migration, error) {
+	if !r.versions[from] {
+		return nil, fmt.Errorf("unknown source version %s (known: %s)", from, strings.Join(r.Versions(), ", "))
+	}
+	if !r.versions[to] {
+		return nil, fmt.Errorf("unknown target version %s (known: %s)", to, strings.Join(r.Versions(), ", "))
+	}
+	if from == to {
+		return nil, nil
+	}
+
+	// Breadth-first search over the migration graph
+	previous := map[string]int{from: -1}
+	queue := []string{from}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for i, m := range r.migrations {
+			if m.From != current {
+				continue
+			}
+			if _, visited := previous[m.To]; visited {
+				continue
+			}
+			previous[m.To] = i
+			if m.To == to {
+				return r.buildPath(previous, to), nil
+			}
+			queue = append(queue, m.To)
+		}
+	}
+
+	return nil, fmt.Errorf("no migration path from %s to %s", from, to)
+}
+
+func (r *MigrationRegistry) buildPath(previous map[string]int, to string) []Migration {
+	var path []Migration
+	for version := to; previous[version] >= 0; {
+		m := r.migrations[previous[version]]
+		path = append([]Migration{m}, path...)
+		version = m.From
+	}
+	return path
+}
+
+// Migrate converts a document to the target version, running every step in
+// the chain. The document is modified in place; if a step fails, the document
+// is left at the version of the last successful step.
+func (r *MigrationRegistry) Migrate(doc *BLEFDocument, to string) (*MigrationResult, error) {
+	if doc.Version == "" {
+		return nil, fmt.Errorf("document has no version")
+	}
+
+	path, err := r.Path(doc.Version, to)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &MigrationResult{From: doc.Version, To: to}
+	for _, m := range path {
+		loss, err := m.Apply(doc)
+		if err != nil {
+			return result, fmt.Errorf("migration %s → %s failed: %w", m.From, m.To, err)
+		}
+		for i := range loss {
+			loss[i].From, loss[i].To = m.From, m.To
+		}
+		doc.Version = m.To
+		result.Steps = append(result.Steps, m)
+		result.Loss = append(result.Loss, loss...)
+	}
+
+	return result, nil
+}
+
+// DefaultMigrations is the global registry with the built-in migration steps.
+// BLEF 0.1.0 is the first released version, so there are no steps yet: each
+// new spec version registers an upgrade and a downgrade step here.
+var DefaultMigrations = NewMigrationRegistry()
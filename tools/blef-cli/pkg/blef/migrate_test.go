@@ -0,0 +1,114 @@
+package blef
+
+import (
+	"fmt"
+	"testing"
+)
+
+func newTestMigrationRegistry() *MigrationRegistry {
+	registry := NewMigrationRegistry()
+
+	registry.Register(Migration{
+		From: "0.1.0", To: "0.2.0",
+		Apply: func(doc *BLEFDocument) ([]MigrationLoss, error) {
+			return nil, nil
+		},
+	})
+	registry.Register(Migration{
+		From: "0.2.0", To: "0.3.0",
+		Apply: func(doc *BLEFDocument) ([]MigrationLoss, error) {
+			return nil, nil
+		},
+	})
+	registry.Register(Migration{
+		From: "0.3.0", To: "0.1.0",
+		Apply: func(doc *BLEFDocument) ([]MigrationLoss, error) {
+			var loss []MigrationLoss
+			for i := range doc.Books {
+				if doc.Books[i].Subtitle != "" {
+					doc.Books[i].Subtitle = ""
+					loss = append(loss, MigrationLoss{
+						Field:   fmt.Sprintf("books[%d].subtitle", i),
+						Message: "not supported, dropped",
+					})
+				}
+			}
+			return loss, nil
+		},
+	})
+
+	return registry
+}
+
+func TestMigrationPath(t *testing.T) {
+	registry := newTestMigrationRegistry()
+
+	path, err := registry.Path("0.1.0", "0.3.0")
+	if err != nil {
+		t.Fatalf("Path failed: %v", err)
+	}
+	if len(path) != 2 || path[0].To != "0.2.0" || path[1].To != "0.3.0" {
+		t.Errorf("Unexpected upgrade path: %v", path)
+	}
+
+	// Downgrade uses the direct step rather than going through 0.2.0
+	path, err = registry.Path("0.3.0", "0.1.0")
+	if err != nil {
+		t.Fatalf("Path failed: %v", err)
+	}
+	if len(path) != 1 {
+		t.Errorf("Expected a single downgrade step, got %d", len(path))
+	}
+
+	if _, err := registry.Path("0.2.0", "9.9.9"); err == nil {
+		t.Error("Path should fail for unknown versions")
+	}
+
+	versions := registry.Versions()
+	if len(versions) != 3 || versions[0] != "0.1.0" || versions[2] != "0.3.0" {
+		t.Errorf("Unexpected versions: %v", versions)
+	}
+}
+
+func TestMigrateReportsLoss(t *testing.T) {
+	registry := newTestMigrationRegistry()
+
+	doc := NewDocument()
+	doc.Version = "0.3.0"
+	doc.Books = []Book{{ID: "9780156013987", Title: "Book", Subtitle: "A subtitle"}}
+
+	result, err := registry.Migrate(doc, "0.1.0")
+	if err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if doc.Version != "0.1.0" {
+		t.Errorf("Expected version 0.1.0, got %s", doc.Version)
+	}
+	if !result.Lossy() || len(result.Loss) != 1 {
+		t.Fatalf("Expected one lossy change, got %v", result.Loss)
+	}
+	if result.Loss[0].From != "0.3.0" || result.Loss[0].Field != "books[0].subtitle" {
+		t.Errorf("Unexpected loss: %v", result.Loss[0])
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	v, err := ParseVersion("1.10.2")
+	if err != nil {
+		t.Fatalf("ParseVersion failed: %v", err)
+	}
+	if v.Major != 1 || v.Minor != 10 || v.Patch != 2 {
+		t.Errorf("Unexpected version: %+v", v)
+	}
+
+	older, _ := ParseVersion("1.9.7")
+	if v.Compare(older) != 1 || older.Compare(v) != -1 || v.Compare(v) != 0 {
+		t.Error("Compare returned unexpected ordering")
+	}
+
+	for _, invalid := range []string{"", "1.0", "1.a.0", "1.0.0.0"} {
+		if _, err := ParseVersion(invalid); err == nil {
+			t.Errorf("ParseVersion(%q) should fail", invalid)
+		}
+	}
+}
@@ -0,0 +1,636 @@
+package blef
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PatchFormat identifies BLEF patch documents
+const PatchFormat = "BLEF-patch"
+
+// PatchOp is the type of a patch operation
+type PatchOp string
+
+const (
+	OpAddBook          PatchOp = "add-book"
+	OpUpdateBook       PatchOp = "update-book"
+	OpRemoveBook       PatchOp = "remove-book"
+	OpAddCollection    PatchOp = "add-collection"
+	OpUpdateCollection PatchOp = "update-collection"
+	OpRemoveCollection PatchOp = "remove-collection"
+	OpAddEntry         PatchOp = "add-entry"
+	OpUpdateEntry      PatchOp = "update-entry"
+	OpRemoveEntry      PatchOp = "remove-entry"
+)
+
+// Patch is a portable list of operations turning one BLEF document into
+// another. It targets a single spec version.
+type Patch struct {
+	Format     string           `json:"format"`
+	Version    string           `json:"version"`
+	CreatedAt  time.Time        `json:"created_at"`
+	Operations []PatchOperation `json:"operations"`
+}
+
+// PatchOperation adds, updates or removes a book, a collection or an entry.
+// Books and entries are addressed by BookID (a book has a single entry),
+// collections by CollectionID.
+//
+// Add operations carry the whole element in Value. Update operations set the
+// JSON field at Field, a dotted path such as "title" or "user_data.status",
+// to Value; a null Value unsets the field. When Expected is present the
+// operation only applies if the current value (the field for updates, the
+// whole element for removals) is equal to it.
+type PatchOperation struct {
+	Op           PatchOp         `json:"op"`
+	BookID       string          `json:"book_id,omitempty"`
+	CollectionID string          `json:"collection_id,omitempty"`
+	Field        string          `json:"field,omitempty"`
+	Expected     json.RawMessage `json:"expected,omitempty"`
+	Value        json.RawMessage `json:"value,omitempty"`
+}
+
+func (o PatchOperation) String() string {
+	target := o.BookID
+	if target == "" {
+		target = o.CollectionID
+	}
+	if o.Field != "" {
+		return fmt.Sprintf("%s %s %s", o.Op, target, o.Field)
+	}
+	return fmt.Sprintf("%s %s", o.Op, target)
+}
+
+// PatchConflictError reports an operation whose preconditions do not hold
+type PatchConflictError struct {
+	Index     int
+	Operation PatchOperation
+	Err       error
+}
+
+func (e *PatchConflictError) Error() string {
+	return fmt.Sprintf("operation %d (%s): %v", e.Index+1, e.Operation, e.Err)
+}
+
+func (e *PatchConflictError) Unwrap() error {
+	return e.Err
+}
+
+// NewPatch creates an empty patch for documents of the given version
+func NewPatch(version string) *Patch {
+	return &Patch{
+		Format:     PatchFormat,
+		Version:    version,
+		CreatedAt:  time.Now().UTC(),
+		Operations: []PatchOperation{},
+	}
+}
+
+// ParsePatch parses and checks a patch document
+func ParsePatch(data []byte) (*Patch, error) {
+	var patch Patch
+	if err := json.Unmarshal(data, &patch); err != nil {
+		return nil, fmt.Errorf("failed to parse patch: %w", err)
+	}
+	if err := patch.Validate(); err != nil {
+		return nil, err
+	}
+	return &patch, nil
+}
+
+// LoadPatch loads a patch document from a file
+func LoadPatch(filename string) (*Patch, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return ParsePatch(data)
+}
+
+// ToJSON converts the patch to JSON bytes with indentation
+func (p *Patch) ToJSON() ([]byte, error) {
+	return json.MarshalIndent(p, "", "  ")
+}
+
+// SaveToFile writes the patch to a file
+func (p *Patch) SaveToFile(filename string) error {
+	data, err := p.ToJSON()
+	if err != nil {
+		return fmt.Errorf("failed to serialize patch: %w", err)
+	}
+	if err := os.WriteFile(filename, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
+// Validate checks the structure of the patch, not whether it applies
+func (p *Patch) Validate() error {
+	if p.Format != PatchFormat {
+		return fmt.Errorf("format must be '%s', got '%s'", PatchFormat, p.Format)
+	}
+	if p.Version == "" {
+		return fmt.Errorf("version is required")
+	}
+
+	for i, op := range p.Operations {
+		var err error
+		switch op.Op {
+		case OpAddBook, OpUpdateBook, OpRemoveBook, OpAddEntry, OpUpdateEntry, OpRemoveEntry:
+			if op.BookID == "" {
+				err = fmt.Errorf("book_id is required")
+			}
+		case OpAddCollection, OpUpdateCollection, OpRemoveCollection:
+			if op.CollectionID == "" {
+				err = fmt.Errorf("collection_id is required")
+			}
+		default:
+			err = fmt.Errorf("unknown operation %q", op.Op)
+		}
+
+		switch {
+		case err != nil:
+		case strings.HasPrefix(string(op.Op), "add-") && len(op.Value) == 0:
+			err = fmt.Errorf("value is required")
+		case strings.HasPrefix(string(op.Op), "update-") && (op.Field == "" || len(op.Value) == 0):
+			err = fmt.Errorf("field and value are required")
+		}
+
+		if err != nil {
+			return fmt.Errorf("operation %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+// ApplyPatch applies every operation of the patch in order. The document
+// is only modified if all of them succeed; otherwise a *PatchConflictError
+// names the first failing operation.
+func ApplyPatch(doc *BLEFDocument, patch *Patch) error {
+	if err := patch.Validate(); err != nil {
+		return err
+	}
+	if patch.Version != doc.Version {
+		return fmt.Errorf("patch targets version %s but the document is version %s", patch.Version, doc.Version)
+	}
+
+	clone, err := cloneDocument(doc)
+	if err != nil {
+		return err
+	}
+	lib := NewLibrary(clone)
+	for i, op := range patch.Operations {
+		if err := applyOperation(lib, op); err != nil {
+			return &PatchConflictError{Index: i, Operation: op, Err: err}
+		}
+	}
+
+	*doc = *clone
+	return nil
+}
+
+func applyOperation(lib *Library, op PatchOperation) error {
+	switch op.Op {
+	case OpAddBook:
+		var book Book
+		if err := decodeElement(op.Value, &book); err != nil {
+			return err
+		}
+		if book.ID != op.BookID {
+			return fmt.Errorf("value has book ID %s", book.ID)
+		}
+		return lib.AddBook(book)
+
+	case OpUpdateBook:
+		book := lib.GetBookByID(op.BookID)
+		if book == nil {
+			return fmt.Errorf("book does not exist")
+		}
+		var updated Book
+		if err := updateElement(book, &updated, op); err != nil {
+			return err
+		}
+		if updated.ID != op.BookID {
+			return fmt.Errorf("the book ID cannot be changed")
+		}
+		return lib.UpdateBook(updated)
+
+	case OpRemoveBook:
+		book := lib.GetBookByID(op.BookID)
+		if book == nil {
+			return fmt.Errorf("book does not exist")
+		}
+		if err := checkExpected(book, nil, op.Expected); err != nil {
+			return err
+		}
+		if lib.GetEntryForBook(op.BookID) != nil {
+			return fmt.Errorf("book still has an entry, remove it first")
+		}
+		return lib.RemoveBook(op.BookID)
+
+	case OpAddCollection:
+		var collection Collection
+		if err := decodeElement(op.Value, &collection); err != nil {
+			return err
+		}
+		if collection.ID != op.CollectionID {
+			return fmt.Errorf("value has collection ID %s", collection.ID)
+		}
+		return lib.AddCollection(collection)
+
+	case OpUpdateCollection:
+		collection := lib.GetCollectionByID(op.CollectionID)
+		if collection == nil {
+			return fmt.Errorf("collection does not exist")
+		}
+		var updated Collection
+		if err := updateElement(collection, &updated, op); err != nil {
+			return err
+		}
+		if updated.ID != op.CollectionID {
+			return fmt.Errorf("the collection ID cannot be changed")
+		}
+		return lib.UpdateCollection(updated)
+
+	case OpRemoveCollection:
+		collection := lib.GetCollectionByID(op.CollectionID)
+		if collection == nil {
+			return fmt.Errorf("collection does not exist")
+		}
+		if err := checkExpected(collection, nil, op.Expected); err != nil {
+			return err
+		}
+		if count := lib.CountEntriesInCollection(op.CollectionID); count > 0 {
+			return fmt.Errorf("collection still has %d entries", count)
+		}
+		return lib.RemoveCollection(op.CollectionID)
+
+	case OpAddEntry:
+		var entry Entry
+		if err := decodeElement(op.Value, &entry); err != nil {
+			return err
+		}
+		if entry.BookID != op.BookID {
+			return fmt.Errorf("value has book ID %s", entry.BookID)
+		}
+		if lib.GetEntryForBook(op.BookID) != nil {
+			return fmt.Errorf("book already has an entry")
+		}
+		return lib.AddEntry(entry)
+
+	case OpUpdateEntry:
+		entry := lib.GetEntryForBook(op.BookID)
+		if entry == nil {
+			return fmt.Errorf("entry does not exist")
+		}
+		var updated Entry
+		if err := updateElement(entry, &updated, op); err != nil {
+			return err
+		}
+		if updated.BookID != op.BookID {
+			return fmt.Errorf("the entry book ID cannot be changed")
+		}
+		return lib.UpdateEntry(updated)
+
+	case OpRemoveEntry:
+		entry := lib.GetEntryForBook(op.BookID)
+		if entry == nil {
+			return fmt.Errorf("entry does not exist")
+		}
+		if err := checkExpected(entry, nil, op.Expected); err != nil {
+			return err
+		}
+		lib.RemoveEntries(op.BookID)
+		return nil
+
+	default:
+		return fmt.Errorf("unknown operation %q", op.Op)
+	}
+}
+
+// decodeElement decodes the value of an add operation
+func decodeElement(value json.RawMessage, element interface{}) error {
+	if err := json.Unmarshal(value, element); err != nil {
+		return fmt.Errorf("invalid value: %w", err)
+	}
+	return nil
+}
+
+// updateElement sets op.Field of current to op.Value, after checking
+// op.Expected, and decodes the result into updated
+func updateElement(current, updated interface{}, op PatchOperation) error {
+	fields, err := toJSONMap(current)
+	if err != nil {
+		return err
+	}
+	path := strings.Split(op.Field, ".")
+
+	if err := checkExpected(fields, path, op.Expected); err != nil {
+		return err
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(op.Value, &value); err != nil {
+		return fmt.Errorf("invalid value: %w", err)
+	}
+	if err := setPath(fields, path, value); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, updated); err != nil {
+		return fmt.Errorf("invalid value for %s: %w", op.Field, err)
+	}
+	return nil
+}
+
+// checkExpected compares the value at path in current (the whole element
+// for an empty path) with the expected JSON value, if any
+func checkExpected(current interface{}, path []string, expected json.RawMessage) error {
+	if expected == nil {
+		return nil
+	}
+
+	fields, ok := current.(map[string]interface{})
+	if !ok {
+		var err error
+		if fields, err = toJSONMap(current); err != nil {
+			return err
+		}
+	}
+
+	var want interface{}
+	if err := json.Unmarshal(expected, &want); err != nil {
+		return fmt.Errorf("invalid expected value: %w", err)
+	}
+
+	var got interface{} = fields
+	if len(path) > 0 {
+		got = getPath(fields, path)
+	}
+	if !reflect.DeepEqual(got, want) {
+		if len(path) == 0 {
+			return fmt.Errorf("element was modified since the patch was created")
+		}
+		return fmt.Errorf("%s is %s, expected %s", strings.Join(path, "."), formatValue(got), formatValue(want))
+	}
+	return nil
+}
+
+// toJSONMap converts an element to its generic JSON representation
+func toJSONMap(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func getPath(fields map[string]interface{}, path []string) interface{} {
+	var current interface{} = fields
+	for _, key := range path {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = object[key]
+	}
+	return current
+}
+
+// setPath sets the value at path, creating intermediate objects. A nil
+// value removes the field.
+func setPath(fields map[string]interface{}, path []string, value interface{}) error {
+	object := fields
+	for i, key := range path[:len(path)-1] {
+		next, exists := object[key]
+		if !exists || next == nil {
+			if value == nil {
+				return nil
+			}
+			next = make(map[string]interface{})
+			object[key] = next
+		}
+		child, ok := next.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s is not an object", strings.Join(path[:i+1], "."))
+		}
+		object = child
+	}
+
+	key := path[len(path)-1]
+	if value == nil {
+		delete(object, key)
+	} else {
+		object[key] = value
+	}
+	return nil
+}
+
+// MakePatch computes a patch turning old into new. Elements are matched by
+// ID; updates are made field by field (and per user_data field for entries)
+// with the old value as precondition, and removals carry the whole old
+// element. Operations are ordered so that references stay valid: additions
+// first, then updates, then removals of entries, books and collections.
+func MakePatch(old, new *BLEFDocument) (*Patch, error) {
+	patch := NewPatch(new.Version)
+	if old.Version != new.Version {
+		return nil, fmt.Errorf("documents have different versions (%s and %s), migrate them first", old.Version, new.Version)
+	}
+
+	var adds, updates, removals []PatchOperation
+	emit := func(list *[]PatchOperation, op PatchOperation, expected, value interface{}) error {
+		var err error
+		if expected != nil {
+			if op.Expected, err = json.Marshal(expected); err != nil {
+				return err
+			}
+		}
+		if value != nil || strings.HasPrefix(string(op.Op), "update-") {
+			if op.Value, err = json.Marshal(value); err != nil {
+				return err
+			}
+		}
+		*list = append(*list, op)
+		return nil
+	}
+	// diffFields emits an update for every top-level field that differs
+	diffFields := func(op PatchOperation, prefix string, oldFields, newFields map[string]interface{}, skip string) error {
+		keys := make([]string, 0, len(oldFields)+len(newFields))
+		for key := range oldFields {
+			keys = append(keys, key)
+		}
+		for key := range newFields {
+			if _, exists := oldFields[key]; !exists {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if key == skip || reflect.DeepEqual(oldFields[key], newFields[key]) {
+				continue
+			}
+			op.Field = prefix + key
+			// An absent field is expected as null
+			expected := oldFields[key]
+			if expected == nil {
+				expected = json.RawMessage("null")
+			}
+			if err := emit(&updates, op, expected, newFields[key]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Collections
+	oldCollections := make(map[string]*Collection, len(old.Collections))
+	for i := range old.Collections {
+		oldCollections[old.Collections[i].ID] = &old.Collections[i]
+	}
+	newCollections := make(map[string]bool, len(new.Collections))
+	for i := range new.Collections {
+		collection := &new.Collections[i]
+		newCollections[collection.ID] = true
+		op := PatchOperation{CollectionID: collection.ID}
+		previous, exists := oldCollections[collection.ID]
+		if !exists {
+			op.Op = OpAddCollection
+			if err := emit(&adds, op, nil, collection); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		op.Op = OpUpdateCollection
+		oldFields, newFields, err := jsonMaps(previous, collection)
+		if err != nil {
+			return nil, err
+		}
+		if err := diffFields(op, "", oldFields, newFields, "id"); err != nil {
+			return nil, err
+		}
+	}
+	var collectionRemovals []PatchOperation
+	for i := range old.Collections {
+		if !newCollections[old.Collections[i].ID] {
+			op := PatchOperation{Op: OpRemoveCollection, CollectionID: old.Collections[i].ID}
+			if err := emit(&collectionRemovals, op, &old.Collections[i], nil); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Books
+	oldBooks := make(map[string]*Book, len(old.Books))
+	for i := range old.Books {
+		oldBooks[old.Books[i].ID] = &old.Books[i]
+	}
+	newBooks := make(map[string]bool, len(new.Books))
+	for i := range new.Books {
+		book := &new.Books[i]
+		newBooks[book.ID] = true
+		op := PatchOperation{BookID: book.ID}
+		previous, exists := oldBooks[book.ID]
+		if !exists {
+			op.Op = OpAddBook
+			if err := emit(&adds, op, nil, book); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		op.Op = OpUpdateBook
+		oldFields, newFields, err := jsonMaps(previous, book)
+		if err != nil {
+			return nil, err
+		}
+		if err := diffFields(op, "", oldFields, newFields, "id"); err != nil {
+			return nil, err
+		}
+	}
+	var bookRemovals []PatchOperation
+	for i := range old.Books {
+		if !newBooks[old.Books[i].ID] {
+			op := PatchOperation{Op: OpRemoveBook, BookID: old.Books[i].ID}
+			if err := emit(&bookRemovals, op, &old.Books[i], nil); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Entries, after the books and collections they reference were added
+	oldEntries, newEntries := firstEntries(old), firstEntries(new)
+	var entryAdds []PatchOperation
+	for i := range new.Entries {
+		entry := &new.Entries[i]
+		if newEntries[entry.BookID] != entry {
+			continue
+		}
+		op := PatchOperation{BookID: entry.BookID}
+		previous, exists := oldEntries[entry.BookID]
+		if !exists {
+			op.Op = OpAddEntry
+			if err := emit(&entryAdds, op, nil, entry); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		op.Op = OpUpdateEntry
+		oldFields, newFields, err := jsonMaps(previous, entry)
+		if err != nil {
+			return nil, err
+		}
+		if err := diffFields(op, "", oldFields, newFields, "user_data"); err != nil {
+			return nil, err
+		}
+		oldData, _ := oldFields["user_data"].(map[string]interface{})
+		newData, _ := newFields["user_data"].(map[string]interface{})
+		if err := diffFields(op, "user_data.", oldData, newData, ""); err != nil {
+			return nil, err
+		}
+	}
+	for i := range old.Entries {
+		entry := &old.Entries[i]
+		if oldEntries[entry.BookID] != entry || newEntries[entry.BookID] != nil {
+			continue
+		}
+		op := PatchOperation{Op: OpRemoveEntry, BookID: entry.BookID}
+		if err := emit(&removals, op, entry, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	patch.Operations = append(patch.Operations, adds...)
+	patch.Operations = append(patch.Operations, entryAdds...)
+	patch.Operations = append(patch.Operations, updates...)
+	patch.Operations = append(patch.Operations, removals...)
+	patch.Operations = append(patch.Operations, bookRemovals...)
+	patch.Operations = append(patch.Operations, collectionRemovals...)
+	return patch, nil
+}
+
+// jsonMaps converts two elements to their generic JSON representation
+func jsonMaps(old, new interface{}) (map[string]interface{}, map[string]interface{}, error) {
+	oldFields, err := toJSONMap(old)
+	if err != nil {
+		return nil, nil, err
+	}
+	newFields, err := toJSONMap(new)
+	if err != nil {
+		return nil, nil, err
+	}
+	return oldFields, newFields, nil
+}
@@ -0,0 +1,127 @@
+package blef
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestMakePatchRoundTrip(t *testing.T) {
+	old, new := diffTestDocuments()
+	// Also remove a collection once no entry uses it
+	new.Collections = new.Collections[1:]
+	new.Entries[1].CollectionIDs = []string{"read"}
+
+	patch, err := MakePatch(old, new)
+	if err != nil {
+		t.Fatalf("MakePatch failed: %v", err)
+	}
+
+	data, err := patch.ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParsePatch(data)
+	if err != nil {
+		t.Fatalf("ParsePatch failed: %v\n%s", err, data)
+	}
+
+	if err := ApplyPatch(old, parsed); err != nil {
+		t.Fatalf("ApplyPatch failed: %v\n%s", err, data)
+	}
+	if diff := DiffDocuments(old, new); !diff.Empty() {
+		t.Errorf("Patched document differs from the target: %v", diff.Changes)
+	}
+}
+
+func TestApplyPatchOperations(t *testing.T) {
+	old, _ := diffTestDocuments()
+
+	patch := NewPatch(old.Version)
+	patch.Operations = []PatchOperation{
+		{Op: OpUpdateEntry, BookID: "9780156013987", Field: "user_data.status",
+			Expected: json.RawMessage(`"to-read"`), Value: json.RawMessage(`"reading"`)},
+		{Op: OpUpdateEntry, BookID: "9780156013987", Field: "ownership.loaned", Value: json.RawMessage(`null`)},
+		{Op: OpUpdateBook, BookID: "9780451524935", Field: "edition.pages", Value: json.RawMessage(`328`)},
+		{Op: OpAddCollection, CollectionID: "favorites", Value: json.RawMessage(`{"id": "favorites", "name": "Favorites", "type": "custom"}`)},
+		{Op: OpUpdateEntry, BookID: "9780451524935", Field: "collection_ids", Value: json.RawMessage(`["read", "favorites"]`)},
+	}
+
+	if err := ApplyPatch(old, patch); err != nil {
+		t.Fatalf("ApplyPatch failed: %v", err)
+	}
+
+	entry := old.GetEntriesForBook("9780156013987")[0]
+	if entry.UserData.Status != "reading" || entry.Ownership.Loaned != nil || !entry.Ownership.Owned {
+		t.Errorf("Unexpected entry after patch: %+v", entry)
+	}
+	if book := old.GetBookByID("9780451524935"); book.Edition == nil || book.Edition.PageCount() != 328 {
+		t.Errorf("Expected edition.pages to be created, got %+v", book.Edition)
+	}
+	if ids := old.GetEntriesForBook("9780451524935")[0].CollectionIDs; len(ids) != 2 {
+		t.Errorf("Expected entry to join favorites, got %v", ids)
+	}
+}
+
+func TestApplyPatchConflicts(t *testing.T) {
+	tests := []struct {
+		name string
+		op   PatchOperation
+	}{
+		{"stale expected value", PatchOperation{Op: OpUpdateEntry, BookID: "9780156013987", Field: "user_data.status",
+			Expected: json.RawMessage(`"reading"`), Value: json.RawMessage(`"read"`)}},
+		{"missing book", PatchOperation{Op: OpUpdateBook, BookID: "9780547928227", Field: "title", Value: json.RawMessage(`"The Hobbit"`)}},
+		{"existing book", PatchOperation{Op: OpAddBook, BookID: "9780451524935",
+			Value: json.RawMessage(`{"id": "9780451524935", "title": "1984", "authors": [{"name": "George Orwell"}]}`)}},
+		{"book with entry", PatchOperation{Op: OpRemoveBook, BookID: "9780451524935"}},
+		{"collection with entries", PatchOperation{Op: OpRemoveCollection, CollectionID: "read"}},
+		{"unknown collection reference", PatchOperation{Op: OpUpdateEntry, BookID: "9780451524935", Field: "collection_ids",
+			Value: json.RawMessage(`["shelf"]`)}},
+		{"ID change", PatchOperation{Op: OpUpdateBook, BookID: "9780451524935", Field: "id", Value: json.RawMessage(`"9780547928227"`)}},
+		{"wrong type", PatchOperation{Op: OpUpdateEntry, BookID: "9780451524935", Field: "user_data.rating", Value: json.RawMessage(`"five"`)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, _ := diffTestDocuments()
+			before, _ := cloneDocument(doc)
+
+			patch := NewPatch(doc.Version)
+			patch.Operations = []PatchOperation{
+				{Op: OpUpdateBook, BookID: "9780156013987", Field: "subtitle", Value: json.RawMessage(`"Illustrated"`)},
+				tt.op,
+			}
+
+			err := ApplyPatch(doc, patch)
+			var conflict *PatchConflictError
+			if !errors.As(err, &conflict) || conflict.Index != 1 {
+				t.Fatalf("Expected a conflict on the second operation, got %v", err)
+			}
+			if diff := DiffDocuments(before, doc); !diff.Empty() {
+				t.Errorf("Document was modified despite the conflict: %v", diff.Changes)
+			}
+		})
+	}
+}
+
+func TestParsePatchErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"invalid JSON", `{`},
+		{"wrong format", `{"format": "BLEF", "version": "0.1.0", "operations": []}`},
+		{"missing version", `{"format": "BLEF-patch", "operations": []}`},
+		{"unknown operation", `{"format": "BLEF-patch", "version": "0.1.0", "operations": [{"op": "rename-book", "book_id": "x"}]}`},
+		{"missing ID", `{"format": "BLEF-patch", "version": "0.1.0", "operations": [{"op": "remove-collection"}]}`},
+		{"update without value", `{"format": "BLEF-patch", "version": "0.1.0", "operations": [{"op": "update-book", "book_id": "x", "field": "title"}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParsePatch([]byte(tt.data)); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,533 @@
+package blef
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Query is a parsed query over the books of a document, each joined with
+// its entry and collections
+type Query struct {
+	Where   queryExpr // nil matches every book
+	OrderBy []QueryOrder
+	Limit   int      // 0 means no limit
+	Fields  []string // projection, empty for the default fields
+}
+
+// QueryOrder is a sort key
+type QueryOrder struct {
+	Field      string
+	Descending bool
+}
+
+// DefaultQueryFields are projected when a query does not select fields
+var DefaultQueryFields = []string{"id", "title", "authors", "status", "rating"}
+
+// QueryRecord is a book joined with its entry, if any, and the collections
+// of that entry
+type QueryRecord struct {
+	Book        *Book
+	Entry       *Entry
+	Collections []*Collection
+}
+
+// Value returns the value of a query field: a string, a float64, a bool,
+// a []string or nil when unset
+func (r *QueryRecord) Value(field string) interface{} {
+	f, ok := queryFields[field]
+	if !ok {
+		return nil
+	}
+	return normalizeQueryValue(f.get(r))
+}
+
+// queryField describes a field available in queries
+type queryField struct {
+	description string
+	get         func(r *QueryRecord) interface{}
+}
+
+// queryFields is the catalog of query fields, keyed by name
+var queryFields = map[string]queryField{}
+
+func registerQueryField(name, description string, get func(r *QueryRecord) interface{}) {
+	queryFields[name] = queryField{description: description, get: get}
+}
+
+// QueryFields returns the field names available in queries with their
+// description, sorted by name
+func QueryFields() [][2]string {
+	fields := make([][2]string, 0, len(queryFields))
+	for name, field := range queryFields {
+		fields = append(fields, [2]string{name, field.description})
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i][0] < fields[j][0] })
+	return fields
+}
+
+func init() {
+	book := func(get func(b *Book) interface{}) func(r *QueryRecord) interface{} {
+		return func(r *QueryRecord) interface{} { return get(r.Book) }
+	}
+	entry := func(get func(e *Entry) interface{}) func(r *QueryRecord) interface{} {
+		return func(r *QueryRecord) interface{} {
+			if r.Entry == nil {
+				return nil
+			}
+			return get(r.Entry)
+		}
+	}
+	edition := func(get func(e *Edition) interface{}) func(r *QueryRecord) interface{} {
+		return func(r *QueryRecord) interface{} {
+			if r.Book.Edition == nil {
+				return nil
+			}
+			return get(r.Book.Edition)
+		}
+	}
+	lastRead := func(get func(d ReadDate) interface{}) func(r *QueryRecord) interface{} {
+		return func(r *QueryRecord) interface{} {
+			if r.Entry == nil || len(r.Entry.UserData.ReadDates) == 0 {
+				return nil
+			}
+			return get(r.Entry.UserData.ReadDates[len(r.Entry.UserData.ReadDates)-1])
+		}
+	}
+
+	// Book
+	registerQueryField("id", "book ID", book(func(b *Book) interface{} { return b.ID }))
+	registerQueryField("title", "book title", book(func(b *Book) interface{} { return b.Title }))
+	registerQueryField("subtitle", "book subtitle", book(func(b *Book) interface{} { return b.Subtitle }))
+	registerQueryField("authors", "author names", book(func(b *Book) interface{} {
+		names := make([]string, len(b.Authors))
+		for i, author := range b.Authors {
+			names[i] = author.Name
+		}
+		return names
+	}))
+	registerQueryField("author", "first author name", book(func(b *Book) interface{} {
+		if len(b.Authors) == 0 {
+			return nil
+		}
+		return b.Authors[0].Name
+	}))
+	registerQueryField("language", "ISO 639-1 language code", book(func(b *Book) interface{} { return b.Language }))
+	registerQueryField("description", "book description", book(func(b *Book) interface{} { return b.Description }))
+	registerQueryField("subjects", "subjects and genres", book(func(b *Book) interface{} { return b.Subjects }))
+	registerQueryField("isbn13", "ISBN-13", book(func(b *Book) interface{} { return b.Identifiers.ISBN13 }))
+	registerQueryField("isbn10", "ISBN-10", book(func(b *Book) interface{} { return b.Identifiers.ISBN10 }))
+	registerQueryField("series", "series name", book(func(b *Book) interface{} {
+		if b.Series == nil {
+			return nil
+		}
+		return b.Series.Name
+	}))
+	registerQueryField("volume", "volume in the series", book(func(b *Book) interface{} {
+		if b.Series == nil {
+			return nil
+		}
+		return b.Series.Volume
+	}))
+	registerQueryField("publisher", "publisher", edition(func(e *Edition) interface{} { return e.Publisher }))
+	registerQueryField("published", "publication date", edition(func(e *Edition) interface{} { return e.PublishedDate.String() }))
+	registerQueryField("year", "publication year", edition(func(e *Edition) interface{} {
+		if !e.PublishedDate.IsValid() {
+			return nil
+		}
+		return e.PublishedDate.Year()
+	}))
+	registerQueryField("format", "edition format", edition(func(e *Edition) interface{} { return e.Format }))
+	registerQueryField("pages", "page count", edition(func(e *Edition) interface{} {
+		if e.Pages == nil {
+			return nil
+		}
+		return *e.Pages
+	}))
+
+	// Entry
+	registerQueryField("status", "reading status", entry(func(e *Entry) interface{} { return e.UserData.Status }))
+	registerQueryField("rating", "rating from 0 to 5", entry(func(e *Entry) interface{} { return e.UserData.Rating }))
+	registerQueryField("review", "review text", entry(func(e *Entry) interface{} { return e.UserData.Review }))
+	registerQueryField("notes", "private notes", entry(func(e *Entry) interface{} { return e.UserData.PrivateNotes }))
+	registerQueryField("tags", "tags", entry(func(e *Entry) interface{} { return e.UserData.Tags }))
+	registerQueryField("favorite", "marked as favorite", entry(func(e *Entry) interface{} { return e.UserData.Favorite }))
+	registerQueryField("added", "date added (YYYY-MM-DD)", entry(func(e *Entry) interface{} {
+		if e.UserData.AddedAt == nil {
+			return nil
+		}
+		return e.UserData.AddedAt.Format("2006-01-02")
+	}))
+	registerQueryField("reads", "number of reads", entry(func(e *Entry) interface{} { return len(e.UserData.ReadDates) }))
+	registerQueryField("started", "last started date", lastRead(func(d ReadDate) interface{} { return d.Started.String() }))
+	registerQueryField("finished", "last finished date", lastRead(func(d ReadDate) interface{} { return d.Finished.String() }))
+	registerQueryField("progress", "progress of the last read, in percent", lastRead(func(d ReadDate) interface{} { return d.Progress }))
+	registerQueryField("owned", "owned", entry(func(e *Entry) interface{} { return e.Ownership != nil && e.Ownership.Owned }))
+	registerQueryField("loaned", "currently loaned out", entry(func(e *Entry) interface{} { return activeLoan(e) != nil }))
+	registerQueryField("loaned_to", "borrower of a current loan", entry(func(e *Entry) interface{} {
+		if loan := activeLoan(e); loan != nil {
+			return loan.To
+		}
+		return nil
+	}))
+
+	// Collections
+	registerQueryField("collections", "collection IDs", func(r *QueryRecord) interface{} {
+		ids := make([]string, len(r.Collections))
+		for i, collection := range r.Collections {
+			ids[i] = collection.ID
+		}
+		return ids
+	})
+	registerQueryField("collection_names", "collection names", func(r *QueryRecord) interface{} {
+		names := make([]string, len(r.Collections))
+		for i, collection := range r.Collections {
+			names[i] = collection.Name
+		}
+		return names
+	})
+}
+
+// normalizeQueryValue converts numbers to float64 and empty values to nil
+func normalizeQueryValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case int:
+		if value == 0 {
+			return nil
+		}
+		return float64(value)
+	case float64:
+		if value == 0 {
+			return nil
+		}
+		return value
+	case string:
+		if value == "" {
+			return nil
+		}
+		return value
+	case []string:
+		if len(value) == 0 {
+			return nil
+		}
+		return value
+	default:
+		return v
+	}
+}
+
+// Records joins every book of the document with its entry and collections
+func Records(doc *BLEFDocument) []QueryRecord {
+	lib := NewLibrary(doc)
+	records := make([]QueryRecord, len(doc.Books))
+	for i := range doc.Books {
+		record := QueryRecord{Book: &doc.Books[i], Entry: lib.GetEntryForBook(doc.Books[i].ID)}
+		if record.Entry != nil {
+			for _, id := range record.Entry.CollectionIDs {
+				if collection := lib.GetCollectionByID(id); collection != nil {
+					record.Collections = append(record.Collections, collection)
+				}
+			}
+		}
+		records[i] = record
+	}
+	return records
+}
+
+// Run returns the records of the document matching the query, sorted and
+// limited
+func (q *Query) Run(doc *BLEFDocument) []QueryRecord {
+	var matched []QueryRecord
+	for _, record := range Records(doc) {
+		if q.Where == nil || truthy(q.Where.eval(&record)) {
+			matched = append(matched, record)
+		}
+	}
+
+	if len(q.OrderBy) > 0 {
+		sort.SliceStable(matched, func(i, j int) bool {
+			for _, order := range q.OrderBy {
+				a, b := matched[i].Value(order.Field), matched[j].Value(order.Field)
+				c := compareQueryValues(a, b)
+				if c == 0 {
+					continue
+				}
+				if order.Descending && a != nil && b != nil {
+					return c > 0
+				}
+				return c < 0
+			}
+			return false
+		})
+	}
+
+	if q.Limit > 0 && len(matched) > q.Limit {
+		matched = matched[:q.Limit]
+	}
+	return matched
+}
+
+// Projection returns the fields selected by the query
+func (q *Query) Projection() []string {
+	if len(q.Fields) > 0 {
+		return q.Fields
+	}
+	return DefaultQueryFields
+}
+
+// Project returns the selected fields of a record, keyed by field name
+func (q *Query) Project(record *QueryRecord) map[string]interface{} {
+	row := make(map[string]interface{}, len(q.Projection()))
+	for _, field := range q.Projection() {
+		row[field] = record.Value(field)
+	}
+	return row
+}
+
+// FilterDocument returns a document holding the books of the records, their
+// entries and the collections those entries use. Every collection is kept
+// when the records reference none, as a document needs at least one.
+func FilterDocument(doc *BLEFDocument, records []QueryRecord) *BLEFDocument {
+	filtered := NewDocument()
+	filtered.Version = doc.Version
+	filtered.User = doc.User
+
+	used := make(map[string]bool)
+	for _, record := range records {
+		filtered.Books = append(filtered.Books, *record.Book)
+		if record.Entry != nil {
+			filtered.Entries = append(filtered.Entries, *record.Entry)
+		}
+		for _, collection := range record.Collections {
+			used[collection.ID] = true
+		}
+	}
+	for _, collection := range doc.Collections {
+		if used[collection.ID] || len(used) == 0 {
+			filtered.Collections = append(filtered.Collections, collection)
+		}
+	}
+
+	return filtered
+}
+
+// Expressions
+
+type queryExpr interface {
+	eval(r *QueryRecord) interface{}
+}
+
+type literalExpr struct {
+	value interface{}
+}
+
+func (e *literalExpr) eval(r *QueryRecord) interface{} {
+	return e.value
+}
+
+// valueOrNil returns the literal value, or nil for a nil expression
+func (e *literalExpr) valueOrNil() interface{} {
+	if e == nil {
+		return nil
+	}
+	return e.value
+}
+
+type fieldExpr struct {
+	name string
+}
+
+func (e *fieldExpr) eval(r *QueryRecord) interface{} {
+	return r.Value(e.name)
+}
+
+type listExpr struct {
+	items []queryExpr
+}
+
+func (e *listExpr) eval(r *QueryRecord) interface{} {
+	values := make([]string, 0, len(e.items))
+	for _, item := range e.items {
+		if value := item.eval(r); value != nil {
+			values = append(values, queryString(value))
+		}
+	}
+	return values
+}
+
+type logicalExpr struct {
+	or          bool
+	left, right queryExpr
+}
+
+func (e *logicalExpr) eval(r *QueryRecord) interface{} {
+	left := truthy(e.left.eval(r))
+	if e.or {
+		return left || truthy(e.right.eval(r))
+	}
+	return left && truthy(e.right.eval(r))
+}
+
+type notExpr struct {
+	operand queryExpr
+}
+
+func (e *notExpr) eval(r *QueryRecord) interface{} {
+	return !truthy(e.operand.eval(r))
+}
+
+type matchExpr struct {
+	operand queryExpr
+	pattern *regexp.Regexp
+}
+
+func (e *matchExpr) eval(r *QueryRecord) interface{} {
+	switch value := e.operand.eval(r).(type) {
+	case nil:
+		return false
+	case []string:
+		for _, item := range value {
+			if e.pattern.MatchString(item) {
+				return true
+			}
+		}
+		return false
+	default:
+		return e.pattern.MatchString(queryString(value))
+	}
+}
+
+type compareExpr struct {
+	operator    string
+	left, right queryExpr
+}
+
+func (e *compareExpr) eval(r *QueryRecord) interface{} {
+	left, right := e.left.eval(r), e.right.eval(r)
+
+	switch e.operator {
+	case "=":
+		return queryEqual(left, right)
+	case "!=":
+		return !queryEqual(left, right)
+	case "in":
+		return queryContains(right, left)
+	case "contains":
+		return queryContains(left, right)
+	}
+
+	// Ordering comparisons are false when a side is unset
+	if left == nil || right == nil {
+		return false
+	}
+	c := compareQueryValues(left, right)
+	switch e.operator {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	default:
+		return c >= 0
+	}
+}
+
+func truthy(v interface{}) bool {
+	switch value := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return value
+	case float64:
+		return value != 0
+	case string:
+		return value != ""
+	case []string:
+		return len(value) > 0
+	default:
+		return true
+	}
+}
+
+// queryString converts a value to a string for comparisons
+func queryString(v interface{}) string {
+	switch value := v.(type) {
+	case string:
+		return value
+	case float64:
+		return fmt.Sprintf("%g", value)
+	case []string:
+		return strings.Join(value, ", ")
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// queryEqual compares strings case-insensitively. An unset field equals
+// null, false and 0.
+func queryEqual(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return !truthy(a) && !truthy(b)
+	}
+	if x, ok := a.(float64); ok {
+		y, ok := b.(float64)
+		return ok && x == y
+	}
+	if x, ok := a.(bool); ok {
+		y, ok := b.(bool)
+		return ok && x == y
+	}
+	return strings.EqualFold(queryString(a), queryString(b))
+}
+
+// queryContains reports whether container holds item: a list element equal
+// to item, or a substring for strings
+func queryContains(container, item interface{}) bool {
+	if item == nil {
+		return false
+	}
+	switch value := container.(type) {
+	case []string:
+		for _, element := range value {
+			if queryEqual(element, item) {
+				return true
+			}
+		}
+		return false
+	case string:
+		return strings.Contains(strings.ToLower(value), strings.ToLower(queryString(item)))
+	default:
+		return false
+	}
+}
+
+// compareQueryValues orders two values: numbers numerically, everything else
+// as case-insensitive strings. Unset values sort last.
+func compareQueryValues(a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+
+	x, okX := a.(float64)
+	y, okY := b.(float64)
+	if okX && okY {
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		default:
+			return 0
+		}
+	}
+	return strings.Compare(strings.ToLower(queryString(a)), strings.ToLower(queryString(b)))
+}
@@ -0,0 +1,408 @@
+package blef
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Query language grammar:
+//
+//	query    = [ "select" field { "," field } [ "where" expr ] | expr ]
+//	           [ "order" "by" field [ "asc" | "desc" ] { "," ... } ] [ "limit" number ]
+//	expr     = and { "or" and }
+//	and      = not { "and" not }
+//	not      = "not" not | compare
+//	compare  = operand [ operator operand ]
+//	operator = "=" | "!=" | "<" | "<=" | ">" | ">=" | "in" | "contains" | "matches" | "~"
+//	operand  = string | number | "true" | "false" | "null" | field | list | "(" expr ")"
+//	list     = "[" [ operand { "," operand } ] "]"
+//
+// Keywords are case-insensitive. A field alone is true when it is set
+// (true, non-zero, non-empty).
+
+type queryTokenKind int
+
+const (
+	tokenEOF queryTokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOperator
+	tokenPunct
+)
+
+type queryToken struct {
+	kind  queryTokenKind
+	text  string
+	pos   int
+	value interface{} // decoded string or number
+}
+
+// QuerySyntaxError reports a parse error and its position in the query
+type QuerySyntaxError struct {
+	Pos     int
+	Message string
+}
+
+func (e *QuerySyntaxError) Error() string {
+	return fmt.Sprintf("query syntax error at position %d: %s", e.Pos+1, e.Message)
+}
+
+func tokenizeQuery(input string) ([]queryToken, error) {
+	var tokens []queryToken
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '"' || r == '\'':
+			start := i
+			var b strings.Builder
+			i++
+			for ; i < len(runes) && runes[i] != r; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				b.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, &QuerySyntaxError{start, "unterminated string"}
+			}
+			i++
+			tokens = append(tokens, queryToken{kind: tokenString, text: string(runes[start:i]), pos: start, value: b.String()})
+
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			text := string(runes[start:i])
+			number, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, &QuerySyntaxError{start, fmt.Sprintf("invalid number %q", text)}
+			}
+			tokens = append(tokens, queryToken{kind: tokenNumber, text: text, pos: start, value: number})
+
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, queryToken{kind: tokenIdent, text: string(runes[start:i]), pos: start})
+
+		case strings.ContainsRune("=!<>~", r):
+			start := i
+			i++
+			if i < len(runes) && runes[i] == '=' && r != '=' && r != '~' {
+				i++
+			}
+			text := string(runes[start:i])
+			if text == "!" {
+				return nil, &QuerySyntaxError{start, "unexpected '!', use != or not"}
+			}
+			tokens = append(tokens, queryToken{kind: tokenOperator, text: text, pos: start})
+
+		case strings.ContainsRune("()[],", r):
+			tokens = append(tokens, queryToken{kind: tokenPunct, text: string(r), pos: i})
+			i++
+
+		default:
+			return nil, &QuerySyntaxError{i, fmt.Sprintf("unexpected character %q", r)}
+		}
+	}
+
+	return append(tokens, queryToken{kind: tokenEOF, pos: len(runes)}), nil
+}
+
+// queryKeywords cannot be used as field names
+var queryKeywords = map[string]bool{
+	"select": true, "where": true, "order": true, "by": true, "asc": true, "desc": true, "limit": true,
+	"and": true, "or": true, "not": true, "in": true, "contains": true, "matches": true,
+	"true": true, "false": true, "null": true,
+}
+
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+}
+
+func (p *queryParser) peek() queryToken {
+	return p.tokens[p.pos]
+}
+
+func (p *queryParser) next() queryToken {
+	token := p.tokens[p.pos]
+	if token.kind != tokenEOF {
+		p.pos++
+	}
+	return token
+}
+
+// keyword reports whether the next token is the keyword, and consumes it if so
+func (p *queryParser) keyword(word string) bool {
+	token := p.peek()
+	if token.kind == tokenIdent && strings.EqualFold(token.text, word) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *queryParser) punct(text string) bool {
+	token := p.peek()
+	if token.kind == tokenPunct && token.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *queryParser) errorf(format string, args ...interface{}) error {
+	token := p.peek()
+	message := fmt.Sprintf(format, args...)
+	if token.kind == tokenEOF {
+		message += " at end of query"
+	} else {
+		message += fmt.Sprintf(" near %q", token.text)
+	}
+	return &QuerySyntaxError{token.pos, message}
+}
+
+// field parses a field name and checks that it exists
+func (p *queryParser) field() (string, error) {
+	token := p.peek()
+	if token.kind != tokenIdent || queryKeywords[strings.ToLower(token.text)] {
+		return "", p.errorf("expected a field name")
+	}
+	name := strings.ToLower(token.text)
+	if _, ok := queryFields[name]; !ok {
+		return "", &QuerySyntaxError{token.pos, fmt.Sprintf("unknown field %q", token.text)}
+	}
+	p.pos++
+	return name, nil
+}
+
+// ParseQuery parses a query such as
+//
+//	status = "to-read" and owned and "Fantasy" in subjects and pages < 400 order by pages limit 10
+func ParseQuery(input string) (*Query, error) {
+	tokens, err := tokenizeQuery(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &queryParser{tokens: tokens}
+	q := &Query{}
+
+	if p.keyword("select") {
+		for {
+			field, err := p.field()
+			if err != nil {
+				return nil, err
+			}
+			q.Fields = append(q.Fields, field)
+			if !p.punct(",") {
+				break
+			}
+		}
+		if p.keyword("where") {
+			if q.Where, err = p.expr(); err != nil {
+				return nil, err
+			}
+		}
+	} else if token := p.peek(); token.kind != tokenEOF && !(token.kind == tokenIdent &&
+		(strings.EqualFold(token.text, "order") || strings.EqualFold(token.text, "limit"))) {
+		p.keyword("where")
+		if q.Where, err = p.expr(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.keyword("order") {
+		if !p.keyword("by") {
+			return nil, p.errorf("expected 'by' after 'order'")
+		}
+		for {
+			field, err := p.field()
+			if err != nil {
+				return nil, err
+			}
+			order := QueryOrder{Field: field}
+			if p.keyword("desc") {
+				order.Descending = true
+			} else {
+				p.keyword("asc")
+			}
+			q.OrderBy = append(q.OrderBy, order)
+			if !p.punct(",") {
+				break
+			}
+		}
+	}
+
+	if p.keyword("limit") {
+		token := p.next()
+		number, ok := token.value.(float64)
+		if token.kind != tokenNumber || !ok || number < 0 || number != float64(int(number)) {
+			return nil, &QuerySyntaxError{token.pos, "limit must be a non-negative integer"}
+		}
+		q.Limit = int(number)
+	}
+
+	if p.peek().kind != tokenEOF {
+		return nil, p.errorf("unexpected input")
+	}
+	return q, nil
+}
+
+func (p *queryParser) expr() (queryExpr, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalExpr{or: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) and() (queryExpr, error) {
+	left, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		right, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) not() (queryExpr, error) {
+	if p.keyword("not") {
+		operand, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{operand: operand}, nil
+	}
+	return p.compare()
+}
+
+func (p *queryParser) compare() (queryExpr, error) {
+	left, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+
+	token := p.peek()
+	var operator string
+	switch {
+	case token.kind == tokenOperator:
+		operator = token.text
+	case token.kind == tokenIdent && queryOperatorKeywords[strings.ToLower(token.text)]:
+		operator = strings.ToLower(token.text)
+	default:
+		return left, nil
+	}
+	p.pos++
+	if operator == "~" {
+		operator = "matches"
+	}
+
+	right, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+
+	if operator == "matches" {
+		literal, ok := right.(*literalExpr)
+		pattern, isString := literal.valueOrNil().(string)
+		if !ok || !isString {
+			return nil, &QuerySyntaxError{token.pos, "matches expects a string pattern"}
+		}
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return nil, &QuerySyntaxError{token.pos, fmt.Sprintf("invalid pattern: %v", err)}
+		}
+		return &matchExpr{operand: left, pattern: re}, nil
+	}
+	return &compareExpr{operator: operator, left: left, right: right}, nil
+}
+
+var queryOperatorKeywords = map[string]bool{"in": true, "contains": true, "matches": true}
+
+func (p *queryParser) operand() (queryExpr, error) {
+	token := p.peek()
+	switch token.kind {
+	case tokenString, tokenNumber:
+		p.pos++
+		return &literalExpr{value: token.value}, nil
+
+	case tokenPunct:
+		switch token.text {
+		case "(":
+			p.pos++
+			inner, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			if !p.punct(")") {
+				return nil, p.errorf("expected ')'")
+			}
+			return inner, nil
+		case "[":
+			p.pos++
+			list := &listExpr{}
+			if p.punct("]") {
+				return list, nil
+			}
+			for {
+				item, err := p.operand()
+				if err != nil {
+					return nil, err
+				}
+				list.items = append(list.items, item)
+				if p.punct("]") {
+					return list, nil
+				}
+				if !p.punct(",") {
+					return nil, p.errorf("expected ',' or ']'")
+				}
+			}
+		}
+
+	case tokenIdent:
+		switch strings.ToLower(token.text) {
+		case "true":
+			p.pos++
+			return &literalExpr{value: true}, nil
+		case "false":
+			p.pos++
+			return &literalExpr{value: false}, nil
+		case "null":
+			p.pos++
+			return &literalExpr{value: nil}, nil
+		}
+		field, err := p.field()
+		if err != nil {
+			return nil, err
+		}
+		return &fieldExpr{name: field}, nil
+	}
+
+	return nil, p.errorf("expected a value, a field or '('")
+}
@@ -0,0 +1,140 @@
+package blef
+
+import (
+	"errors"
+	"testing"
+)
+
+func queryTestDocument() *BLEFDocument {
+	doc := NewDocument()
+	doc.Books = []Book{
+		{ID: "hobbit", Title: "The Hobbit", Authors: []Author{{Name: "J.R.R. Tolkien"}}, Subjects: []string{"Fantasy"}, Edition: &Edition{Pages: pages(310), PublishedDate: MustParseDate("1937-09-21")}},
+		{ID: "lotr", Title: "The Lord of the Rings", Authors: []Author{{Name: "J.R.R. Tolkien"}}, Subjects: []string{"Fantasy", "Epic"}, Edition: &Edition{Pages: pages(1178)}},
+		{ID: "earthsea", Title: "A Wizard of Earthsea", Authors: []Author{{Name: "Ursula K. Le Guin"}}, Subjects: []string{"fantasy"}, Edition: &Edition{Pages: pages(183)}},
+		{ID: "dune", Title: "Dune", Authors: []Author{{Name: "Frank Herbert"}}, Subjects: []string{"Science Fiction"}, Edition: &Edition{Pages: pages(412)}},
+	}
+	doc.Collections = []Collection{
+		{ID: "to-read", Name: "To Read", Type: "to-read"},
+		{ID: "read", Name: "Read", Type: "read"},
+	}
+	doc.Entries = []Entry{
+		{BookID: "hobbit", CollectionIDs: []string{"to-read"}, UserData: UserData{Status: "to-read"}, Ownership: &Ownership{Owned: true}},
+		{BookID: "lotr", CollectionIDs: []string{"to-read"}, UserData: UserData{Status: "to-read"}, Ownership: &Ownership{Owned: true}},
+		{BookID: "earthsea", CollectionIDs: []string{"read"}, UserData: UserData{Status: "read", Rating: 4, Tags: []string{"classic"}}},
+		{BookID: "dune", CollectionIDs: []string{"to-read"}, UserData: UserData{Status: "to-read"}, Ownership: &Ownership{Owned: true}},
+	}
+	return doc
+}
+
+func runQuery(t *testing.T, input string) []string {
+	t.Helper()
+	query, err := ParseQuery(input)
+	if err != nil {
+		t.Fatalf("ParseQuery(%q) failed: %v", input, err)
+	}
+	var ids []string
+	for _, record := range query.Run(queryTestDocument()) {
+		ids = append(ids, record.Book.ID)
+	}
+	return ids
+}
+
+func TestQueryFilters(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{`status = "to-read" and owned and "Fantasy" in subjects and pages < 400`, []string{"hobbit"}},
+		{`"fantasy" in subjects and not owned`, []string{"earthsea"}},
+		{`author ~ "tolkien" or rating >= 4`, []string{"hobbit", "lotr", "earthsea"}},
+		{`status in ["read", "reading"]`, []string{"earthsea"}},
+		{`title contains "of"`, []string{"lotr", "earthsea"}},
+		{`rating = null and year = 1937`, []string{"hobbit"}},
+		{`tags`, []string{"earthsea"}},
+		{`not (pages > 300 and pages < 500)`, []string{"lotr", "earthsea"}},
+		{`collections contains "read"`, []string{"earthsea"}},
+	}
+
+	for _, tt := range tests {
+		got := runQuery(t, tt.query)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.query, tt.want, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: expected %v, got %v", tt.query, tt.want, got)
+				break
+			}
+		}
+	}
+}
+
+func TestQueryOrderAndLimit(t *testing.T) {
+	got := runQuery(t, `"Fantasy" in subjects order by pages desc limit 2`)
+	if len(got) != 2 || got[0] != "lotr" || got[1] != "hobbit" {
+		t.Errorf("Expected [lotr hobbit], got %v", got)
+	}
+
+	// Unset values sort last in both directions
+	got = runQuery(t, `order by rating desc, title`)
+	if len(got) != 4 || got[0] != "earthsea" || got[1] != "dune" {
+		t.Errorf("Expected earthsea then dune, got %v", got)
+	}
+}
+
+func TestQueryProjection(t *testing.T) {
+	query, err := ParseQuery(`select title, pages where id = "dune"`)
+	if err != nil {
+		t.Fatalf("ParseQuery failed: %v", err)
+	}
+	records := query.Run(queryTestDocument())
+	if len(records) != 1 {
+		t.Fatalf("Expected 1 record, got %d", len(records))
+	}
+	row := query.Project(&records[0])
+	if len(row) != 2 || row["title"] != "Dune" || row["pages"] != float64(412) {
+		t.Errorf("Unexpected projection: %v", row)
+	}
+
+	query, _ = ParseQuery(`owned`)
+	if len(query.Projection()) != len(DefaultQueryFields) {
+		t.Errorf("Expected the default fields, got %v", query.Projection())
+	}
+}
+
+func TestQuerySyntaxErrors(t *testing.T) {
+	for _, input := range []string{
+		`status = "to-read`,
+		`unknown = 1`,
+		`status =`,
+		`(owned`,
+		`title matches 42`,
+		`title ~ "("`,
+		`owned limit -1`,
+		`owned order pages`,
+		`status ! "read"`,
+	} {
+		_, err := ParseQuery(input)
+		var syntaxErr *QuerySyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("Expected a syntax error for %q, got %v", input, err)
+		}
+	}
+}
+
+func TestFilterDocument(t *testing.T) {
+	doc := queryTestDocument()
+	query, _ := ParseQuery(`author ~ "tolkien"`)
+	filtered := FilterDocument(doc, query.Run(doc))
+
+	if len(filtered.Books) != 2 || len(filtered.Entries) != 2 {
+		t.Errorf("Expected 2 books and 2 entries, got %d and %d", len(filtered.Books), len(filtered.Entries))
+	}
+	if len(filtered.Collections) != 1 || filtered.Collections[0].ID != "to-read" {
+		t.Errorf("Expected only the to-read collection, got %v", filtered.Collections)
+	}
+	if filtered.Version != doc.Version {
+		t.Errorf("Expected version %s, got %s", doc.Version, filtered.Version)
+	}
+}
@@ -0,0 +1,305 @@
+package blef
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Built-in redaction profiles
+const (
+	RedactPrivate      = "private"
+	RedactPublic       = "public"
+	RedactPseudonymize = "pseudonymize"
+	RedactShare        = "share"
+)
+
+// Redaction describes data removed or altered by a redaction profile
+type Redaction struct {
+	Profile string
+	Element string // "user", "collection <id>", "entry <book id>", "book <id>"
+	Field   string // empty when the whole element was removed
+	Message string
+}
+
+func (r Redaction) String() string {
+	if r.Field == "" {
+		return fmt.Sprintf("[%s] %s: %s", r.Profile, r.Element, r.Message)
+	}
+	return fmt.Sprintf("[%s] %s: %s %s", r.Profile, r.Element, r.Field, r.Message)
+}
+
+// ErrNoPublicCollection is returned by the public profile when no collection
+// is public: a document without collections is invalid
+var ErrNoPublicCollection = errors.New("no collection is public")
+
+// RedactionOptions are the options of the redaction profiles
+type RedactionOptions struct {
+	// PseudonymKey is the secret key of the pseudonyms. Without one, a random
+	// key is used and pseudonyms differ from one redaction to the next.
+	PseudonymKey []byte
+}
+
+// RedactionProfile removes or masks sensitive data before a document is
+// shared, as recommended by section 9.1 of the spec
+type RedactionProfile struct {
+	Name        string
+	Description string
+
+	// Apply redacts the document in place and returns what it changed. It
+	// must not change the document when it fails.
+	Apply func(doc *BLEFDocument, opts RedactionOptions) ([]Redaction, error)
+}
+
+// RedactionRegistry manages the known redaction profiles
+type RedactionRegistry struct {
+	profiles map[string]RedactionProfile
+}
+
+// NewRedactionRegistry creates a registry without any profile
+func NewRedactionRegistry() *RedactionRegistry {
+	return &RedactionRegistry{profiles: make(map[string]RedactionProfile)}
+}
+
+// Register adds a profile, replacing any profile with the same name
+func (r *RedactionRegistry) Register(profile RedactionProfile) {
+	r.profiles[profile.Name] = profile
+}
+
+// Get returns a profile by name
+func (r *RedactionRegistry) Get(name string) (RedactionProfile, bool) {
+	profile, ok := r.profiles[name]
+	return profile, ok
+}
+
+// Profiles returns the registered profiles sorted by name
+func (r *RedactionRegistry) Profiles() []RedactionProfile {
+	profiles := make([]RedactionProfile, 0, len(r.profiles))
+	for _, profile := range r.profiles {
+		profiles = append(profiles, profile)
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name < profiles[j].Name })
+	return profiles
+}
+
+// Redact applies the named profiles in order. The document is modified in
+// place; unknown profile names are reported before anything is changed, but
+// the profiles applied before a failing one are kept.
+func (r *RedactionRegistry) Redact(doc *BLEFDocument, opts RedactionOptions, names ...string) ([]Redaction, error) {
+	profiles := make([]RedactionProfile, 0, len(names))
+	for _, name := range names {
+		profile, ok := r.Get(name)
+		if !ok {
+			var known []string
+			for _, p := range r.Profiles() {
+				known = append(known, p.Name)
+			}
+			return nil, fmt.Errorf("unknown redaction profile %q (known: %s)", name, strings.Join(known, ", "))
+		}
+		profiles = append(profiles, profile)
+	}
+
+	var redactions []Redaction
+	for _, profile := range profiles {
+		changes, err := profile.Apply(doc, opts)
+		if err != nil {
+			return redactions, fmt.Errorf("redaction profile %s failed: %w", profile.Name, err)
+		}
+		for i := range changes {
+			if changes[i].Profile == "" {
+				changes[i].Profile = profile.Name
+			}
+		}
+		redactions = append(redactions, changes...)
+	}
+	return redactions, nil
+}
+
+// Redact applies the named profiles of DefaultRedactions
+func Redact(doc *BLEFDocument, opts RedactionOptions, names ...string) ([]Redaction, error) {
+	return DefaultRedactions.Redact(doc, opts, names...)
+}
+
+// DefaultRedactions is the global registry with the built-in profiles
+var DefaultRedactions = NewRedactionRegistry()
+
+func init() {
+	DefaultRedactions.Register(RedactionProfile{
+		Name:        RedactPrivate,
+		Description: "Remove private notes, loan borrowers, the user's email and metadata",
+		Apply:       redactPrivate,
+	})
+	DefaultRedactions.Register(RedactionProfile{
+		Name:        RedactPublic,
+		Description: "Drop non-public collections and the entries and books found only in them (fails if none is public)",
+		Apply:       redactPublic,
+	})
+	DefaultRedactions.Register(RedactionProfile{
+		Name:        RedactPseudonymize,
+		Description: "Replace the user ID by a pseudonym made with a secret key and remove the user's name",
+		Apply:       redactPseudonymize,
+	})
+	DefaultRedactions.Register(RedactionProfile{
+		Name:        RedactShare,
+		Description: "Everything needed to share a library publicly: private, public and pseudonymize",
+		Apply: func(doc *BLEFDocument, opts RedactionOptions) ([]Redaction, error) {
+			// public is the only profile that can fail, apply it first
+			redactions, err := redactPublic(doc, opts)
+			if err != nil {
+				return nil, err
+			}
+			for _, apply := range []func(*BLEFDocument, RedactionOptions) ([]Redaction, error){redactPrivate, redactPseudonymize} {
+				changes, err := apply(doc, opts)
+				if err != nil {
+					return nil, err
+				}
+				redactions = append(redactions, changes...)
+			}
+			return redactions, nil
+		},
+	})
+}
+
+func redactPrivate(doc *BLEFDocument, _ RedactionOptions) ([]Redaction, error) {
+	var redactions []Redaction
+	removed := func(element, field string) {
+		redactions = append(redactions, Redaction{Profile: RedactPrivate, Element: element, Field: field, Message: "removed"})
+	}
+
+	if doc.User != nil {
+		if doc.User.Email != "" {
+			doc.User.Email = ""
+			removed("user", "email")
+		}
+		if len(doc.User.Metadata) > 0 {
+			doc.User.Metadata = nil
+			removed("user", "metadata")
+		}
+	}
+
+	for i := range doc.Entries {
+		entry := &doc.Entries[i]
+		element := "entry " + entry.BookID
+		if entry.UserData.PrivateNotes != "" {
+			entry.UserData.PrivateNotes = ""
+			removed(element, "user_data.private_notes")
+		}
+		if entry.Ownership == nil || entry.Ownership.Loaned == nil {
+			continue
+		}
+		// Loan notes often name the borrower too
+		if entry.Ownership.Loaned.To != "" {
+			entry.Ownership.Loaned.To = ""
+			removed(element, "ownership.loaned.to")
+		}
+		if entry.Ownership.Loaned.Notes != "" {
+			entry.Ownership.Loaned.Notes = ""
+			removed(element, "ownership.loaned.notes")
+		}
+	}
+	return redactions, nil
+}
+
+func redactPublic(doc *BLEFDocument, _ RedactionOptions) ([]Redaction, error) {
+	var redactions []Redaction
+
+	public := false
+	for _, collection := range doc.Collections {
+		public = public || collection.IsPublic
+	}
+	if !public && len(doc.Collections) > 0 {
+		return nil, ErrNoPublicCollection
+	}
+
+	private := make(map[string]bool)
+	collections := doc.Collections[:0]
+	for _, collection := range doc.Collections {
+		if collection.IsPublic {
+			collections = append(collections, collection)
+			continue
+		}
+		private[collection.ID] = true
+		redactions = append(redactions, Redaction{Profile: RedactPublic, Element: "collection " + collection.ID, Message: "removed (not public)"})
+	}
+	doc.Collections = collections
+	if len(private) == 0 {
+		return redactions, nil
+	}
+
+	hadEntries := make(map[string]bool)
+	kept := make(map[string]bool)
+	entries := doc.Entries[:0]
+	for _, entry := range doc.Entries {
+		hadEntries[entry.BookID] = true
+		var ids []string
+		for _, id := range entry.CollectionIDs {
+			if !private[id] {
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) == 0 && len(entry.CollectionIDs) > 0 {
+			redactions = append(redactions, Redaction{Profile: RedactPublic, Element: "entry " + entry.BookID, Message: "removed (only in non-public collections)"})
+			continue
+		}
+		if len(ids) != len(entry.CollectionIDs) {
+			entry.CollectionIDs = ids
+			redactions = append(redactions, Redaction{Profile: RedactPublic, Element: "entry " + entry.BookID, Field: "collection_ids", Message: "non-public collections removed"})
+		}
+		kept[entry.BookID] = true
+		entries = append(entries, entry)
+	}
+	doc.Entries = entries
+
+	// A book whose entries were all removed would still reveal its title
+	books := doc.Books[:0]
+	for _, book := range doc.Books {
+		if hadEntries[book.ID] && !kept[book.ID] {
+			redactions = append(redactions, Redaction{Profile: RedactPublic, Element: "book " + book.ID, Message: "removed (only in non-public collections)"})
+			continue
+		}
+		books = append(books, book)
+	}
+	doc.Books = books
+
+	return redactions, nil
+}
+
+// Pseudonym returns the pseudonym of an identifier, an HMAC-SHA256 with a
+// secret key. The same ID and key always give the same pseudonym, so
+// redacted exports of one user can still be matched with each other, but
+// without the key a guessable ID such as an email cannot be recovered.
+func Pseudonym(id string, key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("blef-user:" + id))
+	return "user-" + hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+func redactPseudonymize(doc *BLEFDocument, opts RedactionOptions) ([]Redaction, error) {
+	if doc.User == nil {
+		return nil, nil
+	}
+
+	var redactions []Redaction
+	if doc.User.ID != "" {
+		key, message := opts.PseudonymKey, "pseudonymized"
+		if len(key) == 0 {
+			key = make([]byte, 32)
+			if _, err := rand.Read(key); err != nil {
+				return nil, fmt.Errorf("failed to generate a pseudonym key: %w", err)
+			}
+			message = "pseudonymized with a random key"
+		}
+		doc.User.ID = Pseudonym(doc.User.ID, key)
+		redactions = append(redactions, Redaction{Profile: RedactPseudonymize, Element: "user", Field: "id", Message: message})
+	}
+	if doc.User.Name != "" {
+		doc.User.Name = ""
+		redactions = append(redactions, Redaction{Profile: RedactPseudonymize, Element: "user", Field: "name", Message: "removed"})
+	}
+	return redactions, nil
+}
@@ -0,0 +1,146 @@
+package blef
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func redactTestDocument() *BLEFDocument {
+	doc, _ := diffTestDocuments()
+	doc.User = &User{ID: "jane@example.com", Name: "Jane", Email: "jane@example.com", Metadata: map[string]interface{}{"plan": "premium"}}
+	doc.Collections = append(doc.Collections, Collection{ID: "secret", Name: "Guilty pleasures", Type: "custom"})
+	for i := range doc.Collections {
+		doc.Collections[i].IsPublic = doc.Collections[i].ID != "secret"
+	}
+	doc.Books = append(doc.Books, Book{ID: "9780547928227", Title: "The Hobbit", Authors: []Author{{Name: "J.R.R. Tolkien"}}})
+	doc.Entries = append(doc.Entries, Entry{BookID: "9780547928227", CollectionIDs: []string{"secret"}, UserData: UserData{Status: "read"}})
+	doc.Entries[0].CollectionIDs = append(doc.Entries[0].CollectionIDs, "secret")
+	doc.Entries[0].UserData.PrivateNotes = "Gift from Paul"
+	doc.Entries[0].Ownership = &Ownership{Owned: true, Loaned: &Loaned{Status: true, To: "Paul", Notes: "Paul's copy"}}
+	return doc
+}
+
+func TestRedactPrivate(t *testing.T) {
+	doc := redactTestDocument()
+	redactions, err := Redact(doc, RedactionOptions{}, RedactPrivate)
+	if err != nil {
+		t.Fatalf("Redact failed: %v", err)
+	}
+	if len(redactions) != 5 {
+		t.Errorf("Expected 5 redactions, got %v", redactions)
+	}
+
+	if doc.User.Email != "" || doc.User.Metadata != nil || doc.User.ID == "" {
+		t.Errorf("Expected email and metadata to be removed only, got %+v", doc.User)
+	}
+	entry := doc.Entries[0]
+	if entry.UserData.PrivateNotes != "" || entry.Ownership.Loaned.To != "" || entry.Ownership.Loaned.Notes != "" {
+		t.Errorf("Expected notes and borrower to be removed, got %+v", entry)
+	}
+	if !entry.Ownership.Loaned.Status {
+		t.Error("Expected the loan itself to be kept")
+	}
+}
+
+func TestRedactPublic(t *testing.T) {
+	doc := redactTestDocument()
+	if _, err := Redact(doc, RedactionOptions{}, RedactPublic); err != nil {
+		t.Fatalf("Redact failed: %v", err)
+	}
+
+	if doc.GetCollectionByID("secret") != nil {
+		t.Error("Expected the non-public collection to be removed")
+	}
+	if doc.GetBookByID("9780547928227") != nil || len(doc.GetEntriesForBook("9780547928227")) > 0 {
+		t.Error("Expected the book found only in the non-public collection to be removed")
+	}
+	if ids := doc.Entries[0].CollectionIDs; containsString(ids, "secret") || len(ids) == 0 {
+		t.Errorf("Expected the entry to stay in its public collections only, got %v", ids)
+	}
+	if errs := ValidateDocument(doc); len(errs) > 0 {
+		t.Errorf("Redacted document is invalid: %v", errs)
+	}
+}
+
+func TestRedactPublicDefault(t *testing.T) {
+	doc, err := FromJSON([]byte(`{
+		"version": "1.0.0", "exported_at": "2024-01-01T00:00:00Z",
+		"collections": [
+			{"id": "read", "name": "Read", "type": "read"},
+			{"id": "secret", "name": "Secret", "type": "custom", "is_public": false}
+		],
+		"books": [{"id": "9780547928227", "title": "The Hobbit", "authors": [{"name": "J.R.R. Tolkien"}]}],
+		"entries": [{"book_id": "9780547928227", "collection_ids": ["read"], "user_data": {"status": "read"}}]
+	}`))
+	if err != nil {
+		t.Fatalf("FromJSON failed: %v", err)
+	}
+	if _, err := Redact(doc, RedactionOptions{}, RedactPublic); err != nil {
+		t.Fatalf("Redact failed: %v", err)
+	}
+	if doc.GetCollectionByID("read") == nil || doc.GetCollectionByID("secret") != nil || len(doc.Entries) != 1 {
+		t.Errorf("Expected a collection without is_public to be public, got %+v", doc.Collections)
+	}
+}
+
+func TestRedactNoPublicCollection(t *testing.T) {
+	doc := redactTestDocument()
+	for i := range doc.Collections {
+		doc.Collections[i].IsPublic = false
+	}
+	if _, err := Redact(doc, RedactionOptions{}, RedactPublic); !errors.Is(err, ErrNoPublicCollection) {
+		t.Fatalf("Expected ErrNoPublicCollection, got %v", err)
+	}
+	if len(doc.Collections) != 3 {
+		t.Errorf("Expected the document to be left as is, got %d collections", len(doc.Collections))
+	}
+}
+
+func TestRedactPseudonymize(t *testing.T) {
+	key := []byte("secret")
+	doc := redactTestDocument()
+	if _, err := Redact(doc, RedactionOptions{PseudonymKey: key}, RedactPseudonymize); err != nil {
+		t.Fatalf("Redact failed: %v", err)
+	}
+	if doc.User.ID != Pseudonym("jane@example.com", key) || !strings.HasPrefix(doc.User.ID, "user-") || doc.User.Name != "" {
+		t.Errorf("Expected a pseudonymized user, got %+v", doc.User)
+	}
+	if Pseudonym("jane@example.com", key) == Pseudonym("john@example.com", key) {
+		t.Error("Expected different IDs to get different pseudonyms")
+	}
+	if Pseudonym("jane@example.com", key) == Pseudonym("jane@example.com", []byte("other")) {
+		t.Error("Expected different keys to give different pseudonyms")
+	}
+
+	// Without a key, pseudonyms cannot be matched with the ID
+	doc = redactTestDocument()
+	if _, err := Redact(doc, RedactionOptions{}, RedactPseudonymize); err != nil {
+		t.Fatalf("Redact failed: %v", err)
+	}
+	if doc.User.ID == Pseudonym("jane@example.com", nil) || !strings.HasPrefix(doc.User.ID, "user-") {
+		t.Errorf("Expected a pseudonym made with a random key, got %q", doc.User.ID)
+	}
+}
+
+func TestRedactProfiles(t *testing.T) {
+	doc := redactTestDocument()
+	if _, err := Redact(doc, RedactionOptions{}, RedactPrivate, "unknown"); err == nil {
+		t.Fatal("Expected an error for an unknown profile")
+	}
+	if doc.User.Email == "" {
+		t.Error("Expected nothing to be redacted when a profile is unknown")
+	}
+
+	redactions, err := Redact(doc, RedactionOptions{PseudonymKey: []byte("secret")}, RedactShare)
+	if err != nil {
+		t.Fatalf("Redact failed: %v", err)
+	}
+	profiles := make(map[string]bool)
+	for _, redaction := range redactions {
+		profiles[redaction.Profile] = true
+	}
+	if !profiles[RedactPrivate] || !profiles[RedactPublic] || !profiles[RedactPseudonymize] {
+		t.Errorf("Expected the share profile to apply the three others, got %v", redactions)
+	}
+}
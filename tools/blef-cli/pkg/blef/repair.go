@@ -0,0 +1,293 @@
+package blef
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/isbn"
+)
+
+// maxRepairPasses bounds Repair: a fix may reveal another fixable problem,
+// e.g. normalizing a book ID can make two entries duplicates
+const maxRepairPasses = 5
+
+// Fix is an automatic repair proposed for a finding
+type Fix struct {
+	Description string
+
+	// apply performs the repair and reports whether it changed the document
+	apply func(doc *BLEFDocument) bool
+
+	// removes is the index of the entry the fix deletes, -1 otherwise.
+	// Removals are applied last, from the highest index down, so that the
+	// indexes captured by other fixes stay valid.
+	removes int
+}
+
+// RepairChange records a fix applied by Repair
+type RepairChange struct {
+	Code        string
+	Pointer     string
+	Description string
+}
+
+func (c RepairChange) String() string {
+	location := c.Pointer
+	if location == "" {
+		location = "(root)"
+	}
+	return fmt.Sprintf("[%s] %s: %s", c.Code, location, c.Description)
+}
+
+// Repair applies the fixes proposed by ValidateDocument, revalidating until
+// no fixable finding is left, and returns the changes made in order.
+// Findings without a fix are left untouched.
+func Repair(doc *BLEFDocument) []RepairChange {
+	var changes []RepairChange
+
+	for pass := 0; pass < maxRepairPasses; pass++ {
+		var fixable []ValidationError
+		for _, err := range ValidateDocument(doc) {
+			if finding, ok := err.(ValidationError); ok && finding.Fix != nil {
+				fixable = append(fixable, finding)
+			}
+		}
+		if len(fixable) == 0 {
+			break
+		}
+
+		sort.SliceStable(fixable, func(i, j int) bool {
+			return fixable[i].Fix.removes < fixable[j].Fix.removes
+		})
+		// Edits (removes == -1) now come first, sort removals from the end
+		first := sort.Search(len(fixable), func(i int) bool { return fixable[i].Fix.removes >= 0 })
+		removals := fixable[first:]
+		sort.SliceStable(removals, func(i, j int) bool {
+			return removals[i].Fix.removes > removals[j].Fix.removes
+		})
+
+		applied := false
+		for _, finding := range fixable {
+			if finding.Fix.apply(doc) {
+				applied = true
+				changes = append(changes, RepairChange{
+					Code:        finding.Code,
+					Pointer:     finding.Pointer,
+					Description: finding.Fix.Description,
+				})
+			}
+		}
+		if !applied {
+			break
+		}
+	}
+
+	return changes
+}
+
+// bookIDFix proposes to replace a malformed book ID by its normalized ISBN-13
+func bookIDFix(i int, id string) *Fix {
+	newID, isbn10, ok := normalizeBookID(id)
+	if !ok || newID == id {
+		return nil
+	}
+
+	description := fmt.Sprintf("normalized book ID %q to %s", id, newID)
+	if isbn10 != "" {
+		description = fmt.Sprintf("converted ISBN-10 book ID %q to ISBN-13 %s", id, newID)
+	}
+
+	return &Fix{
+		Description: description,
+		removes:     -1,
+		apply: func(doc *BLEFDocument) bool {
+			book := &doc.Books[i]
+			if book.ID != id || doc.GetBookByID(newID) != nil {
+				return false
+			}
+			book.ID = newID
+			if book.Identifiers.ISBN13 == "" || book.Identifiers.ISBN13 == id {
+				book.Identifiers.ISBN13 = newID
+			}
+			if isbn10 != "" && (book.Identifiers.ISBN10 == "" || book.Identifiers.ISBN10 == id) {
+				book.Identifiers.ISBN10 = isbn10
+			}
+			for j := range doc.Entries {
+				if doc.Entries[j].BookID == id {
+					doc.Entries[j].BookID = newID
+				}
+			}
+			return true
+		},
+	}
+}
+
+// normalizeBookID cleans an ISBN used as a book ID and converts ISBN-10 to
+// ISBN-13. isbn10 is set when the ID was an ISBN-10.
+func normalizeBookID(id string) (isbn13, isbn10 string, ok bool) {
+	cleaned, err := isbn.Parse(id)
+	if err != nil {
+		return "", "", false
+	}
+	if len(cleaned) == 13 {
+		return cleaned, "", true
+	}
+	isbn13, err = isbn.To13(cleaned)
+	return isbn13, cleaned, err == nil
+}
+
+// missingCollectionFix proposes to create a collection referenced by an entry
+func missingCollectionFix(collID string) *Fix {
+	collType := "custom"
+	if validCollectionTypes[collID] {
+		collType = collID
+	}
+
+	return &Fix{
+		Description: fmt.Sprintf("created missing collection %q (type %s)", collID, collType),
+		removes:     -1,
+		apply: func(doc *BLEFDocument) bool {
+			if doc.GetCollectionByID(collID) != nil {
+				return false
+			}
+			doc.Collections = append(doc.Collections, Collection{ID: collID, Name: collID, Type: collType})
+			return true
+		},
+	}
+}
+
+// duplicateEntryFix proposes to merge entry i into the first entry of the same book
+func duplicateEntryFix(i int, bookID string) *Fix {
+	return &Fix{
+		Description: fmt.Sprintf("merged duplicate entry for book %s into the first one", bookID),
+		removes:     i,
+		apply: func(doc *BLEFDocument) bool {
+			if i >= len(doc.Entries) || doc.Entries[i].BookID != bookID {
+				return false
+			}
+			for j := 0; j < i; j++ {
+				if doc.Entries[j].BookID == bookID {
+					mergeDuplicateEntry(&doc.Entries[j], &doc.Entries[i])
+					doc.Entries = append(doc.Entries[:i], doc.Entries[i+1:]...)
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
+
+// mergeDuplicateEntry folds src into dst. Lists are merged, other fields
+// keep the value of dst unless it is empty.
+func mergeDuplicateEntry(dst, src *Entry) {
+	for _, id := range src.CollectionIDs {
+		if !containsString(dst.CollectionIDs, id) {
+			dst.CollectionIDs = append(dst.CollectionIDs, id)
+		}
+	}
+
+	data, other := &dst.UserData, &src.UserData
+	if data.Status == "" {
+		data.Status = other.Status
+	}
+	if data.Rating == 0 {
+		data.Rating = other.Rating
+	}
+	if data.Review == "" {
+		data.Review = other.Review
+	}
+	if data.PrivateNotes == "" {
+		data.PrivateNotes = other.PrivateNotes
+	}
+	data.Favorite = data.Favorite || other.Favorite
+	for _, tag := range other.Tags {
+		if !containsString(data.Tags, tag) {
+			data.Tags = append(data.Tags, tag)
+		}
+	}
+	for _, readDate := range other.ReadDates {
+		duplicate := false
+		for _, existing := range data.ReadDates {
+			if reflect.DeepEqual(existing, readDate) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			data.ReadDates = append(data.ReadDates, readDate)
+		}
+	}
+	if other.AddedAt != nil && (data.AddedAt == nil || other.AddedAt.Before(*data.AddedAt)) {
+		data.AddedAt = other.AddedAt
+	}
+
+	if dst.Ownership == nil {
+		dst.Ownership = src.Ownership
+	}
+	for key, value := range src.Metadata {
+		if dst.Metadata == nil {
+			dst.Metadata = make(map[string]interface{})
+		}
+		if _, exists := dst.Metadata[key]; !exists {
+			dst.Metadata[key] = value
+		}
+	}
+}
+
+// ratingFix proposes to clamp an out-of-range rating
+func ratingFix(i int, rating float64) *Fix {
+	clamped := rating
+	if clamped < 0 {
+		clamped = 0
+	}
+	if clamped > 5 {
+		clamped = 5
+	}
+
+	return &Fix{
+		Description: fmt.Sprintf("clamped rating %g to %g", rating, clamped),
+		removes:     -1,
+		apply: func(doc *BLEFDocument) bool {
+			data := &doc.Entries[i].UserData
+			if data.Rating != rating {
+				return false
+			}
+			data.Rating = clamped
+			return true
+		},
+	}
+}
+
+// dateFix proposes to rewrite a date in ISO 8601 form, truncated to the
+// day. field locates the date in the document; nil is returned if value is
+// not recoverable or less precise than minimum.
+func dateFix(value PartialDate, minimum DatePrecision, field func(doc *BLEFDocument) *PartialDate) *Fix {
+	date, ok := recoverDate(value.String())
+	if !ok || date.Precision() < minimum {
+		return nil
+	}
+
+	return &Fix{
+		Description: fmt.Sprintf("rewrote date %q as %s", value, date),
+		removes:     -1,
+		apply: func(doc *BLEFDocument) bool {
+			target := field(doc)
+			if target == nil || *target != value {
+				return false
+			}
+			*target = date
+			return true
+		},
+	}
+}
+
+// recoverDate parses a malformed date and returns it truncated to the day.
+// Numeric dates whose day and month could be swapped are not recovered.
+func recoverDate(value string) (PartialDate, bool) {
+	date, err := DateParser{}.Parse(value)
+	if err != nil {
+		return PartialDate{}, false
+	}
+	return date.Truncate(PrecisionDay), true
+}
@@ -0,0 +1,104 @@
+package blef
+
+import "testing"
+
+func TestRepair(t *testing.T) {
+	doc := NewDocument()
+	doc.Books = []Book{
+		{ID: "978-0-15-601398-7", Title: "The Little Prince", Authors: []Author{{Name: "Antoine de Saint-Exupéry"}}},
+		{ID: "0-451-52493-4", Title: "1984", Authors: []Author{{Name: "George Orwell"}}},
+	}
+	doc.Collections = []Collection{{ID: "read", Name: "Read", Type: "read"}}
+	doc.Entries = []Entry{
+		{BookID: "978-0-15-601398-7", CollectionIDs: []string{"read"}, UserData: UserData{Status: "read", Tags: []string{"classic"}}},
+		{BookID: "0-451-52493-4", CollectionIDs: []string{"reading", "favorites"}, UserData: UserData{
+			Status:    "reading",
+			Rating:    7,
+			ReadDates: []ReadDate{{Started: invalidDate("2024/03/01")}},
+		}},
+		// Becomes a duplicate of the first entry once its book ID is normalized
+		{BookID: "9780156013987", CollectionIDs: []string{"read"}, UserData: UserData{Status: "read", Tags: []string{"french"}}},
+	}
+
+	changes := Repair(doc)
+	if errors := ValidateDocument(doc); len(errors) > 0 {
+		t.Fatalf("Repaired document still has errors: %v (changes: %v)", errors, changes)
+	}
+
+	codes := make(map[string]int)
+	for _, change := range changes {
+		codes[change.Code]++
+	}
+	expected := map[string]int{
+		RuleBookIDFormat:      2,
+		RuleMissingCollection: 2,
+		RuleEntryRating:       1,
+		RuleDate:              1,
+		RuleDuplicateEntry:    1,
+	}
+	for code, count := range expected {
+		if codes[code] != count {
+			t.Errorf("Expected %d %s change(s), got %d (%v)", count, code, codes[code], changes)
+		}
+	}
+
+	if book := doc.GetBookByID("9780451524935"); book == nil || book.Identifiers.ISBN10 != "0451524934" {
+		t.Errorf("Expected ISBN-10 book ID converted to 13 with ISBN-10 kept, got %+v", book)
+	}
+	if coll := doc.GetCollectionByID("reading"); coll == nil || coll.Type != "reading" {
+		t.Errorf("Expected 'reading' collection of type reading, got %+v", coll)
+	}
+	if coll := doc.GetCollectionByID("favorites"); coll == nil || coll.Type != "custom" {
+		t.Errorf("Expected 'favorites' collection of type custom, got %+v", coll)
+	}
+	if len(doc.Entries) != 2 {
+		t.Fatalf("Expected duplicate entries to be merged, got %d entries", len(doc.Entries))
+	}
+	if tags := doc.Entries[0].UserData.Tags; len(tags) != 2 {
+		t.Errorf("Expected tags of the duplicate entry to be merged, got %v", tags)
+	}
+	if data := doc.Entries[1].UserData; data.Rating != 5 || data.ReadDates[0].Started.String() != "2024-03-01" {
+		t.Errorf("Expected clamped rating and rewritten date, got %+v", data)
+	}
+}
+
+func TestRepairLeavesUnfixableFindings(t *testing.T) {
+	doc := NewDocument()
+	doc.Books = []Book{{ID: "not-an-isbn", Title: "Book", Authors: []Author{{Name: "Author"}}}}
+	doc.Collections = []Collection{{ID: "read", Name: "Read", Type: "read"}}
+	doc.Entries = []Entry{{BookID: "not-an-isbn", CollectionIDs: []string{"read"}, UserData: UserData{
+		Status:    "read",
+		ReadDates: []ReadDate{{Finished: invalidDate("03/04/2024")}},
+	}}}
+
+	if changes := Repair(doc); len(changes) != 0 {
+		t.Errorf("Expected no change, got %v", changes)
+	}
+	if errors := ValidateDocument(doc); len(errors) != 2 {
+		t.Errorf("Expected the 2 findings to remain, got %v", errors)
+	}
+}
+
+func TestRecoverDate(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		ok       bool
+	}{
+		{"2024-03-09T10:00:00Z", "2024-03-09", true},
+		{"2024.03.09", "2024-03-09", true},
+		{"20240309", "2024-03-09", true},
+		{"9 March 2024", "2024-03-09", true},
+		{"Mar 9, 2024", "2024-03-09", true},
+		{" 2024-03-09 ", "2024-03-09", true},
+		{"03/09/2024", "", false},
+		{"soon", "", false},
+	}
+
+	for _, tt := range tests {
+		date, ok := recoverDate(tt.input)
+		if ok != tt.ok || date.String() != tt.expected {
+			t.Errorf("recoverDate(%q) = %q, %v, want %q, %v", tt.input, date, ok, tt.expected, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,477 @@
+package blef
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Severity is the level of a validation finding
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// Rule codes. Codes are stable: never renumber or reuse a code, add new
+// rules at the end of their range.
+const (
+	RuleParse                  = "BLEF-E000"
+	RuleFormat                 = "BLEF-E001"
+	RuleVersionRequired        = "BLEF-E002"
+	RuleCollectionsEmpty       = "BLEF-E003"
+	RuleDuplicateBookID        = "BLEF-E004"
+	RuleBookIDFormat           = "BLEF-E005"
+	RuleISBN13CheckDigit       = "BLEF-E006"
+	RuleBookTitleRequired      = "BLEF-E007"
+	RuleBookAuthorsRequired    = "BLEF-E008"
+	RuleDuplicateCollectionID  = "BLEF-E009"
+	RuleCollectionNameRequired = "BLEF-E010"
+	RuleCollectionTypeRequired = "BLEF-E011"
+	RuleMissingBook            = "BLEF-E012"
+	RuleMissingCollection      = "BLEF-E013"
+	RuleEntryCollectionsEmpty  = "BLEF-E014"
+	RuleEntryStatus            = "BLEF-E015"
+	RuleEntryRating            = "BLEF-E016"
+	RuleDuplicateEntry         = "BLEF-E017"
+	RuleAuthorNameRequired     = "BLEF-E018"
+	RuleAuthorRole             = "BLEF-E019"
+	RuleEditionFormat          = "BLEF-E023"
+	RulePages                  = "BLEF-E024"
+	RuleSeriesNameRequired     = "BLEF-E025"
+	RuleCollectionType         = "BLEF-E026"
+	RuleProgress               = "BLEF-E028"
+	RuleIdentifierISBN         = "BLEF-E029"
+	RuleSchema                 = "BLEF-E100"
+
+	// Lint rules
+	RuleReadWithoutFinishDate    = "BLEF-W001"
+	RuleFinishedBeforeStarted    = "BLEF-W002"
+	RuleLoanedNotOwned           = "BLEF-W003"
+	RuleRatingOnUnread           = "BLEF-W004"
+	RuleCompleteWhileReading     = "BLEF-W005"
+	RuleStatusCollectionMismatch = "BLEF-W006"
+
+	// Format recommendations, always checked. They were BLEF-E020,
+	// BLEF-E021, BLEF-E022 and BLEF-E027, which are retired.
+	RuleLanguageCode  = "BLEF-W007"
+	RuleCoverURL      = "BLEF-W008"
+	RulePublishedDate = "BLEF-W009"
+	RuleDate          = "BLEF-W010"
+)
+
+// Rule describes a validation check
+type Rule struct {
+	Code        string
+	Name        string
+	Severity    Severity
+	Description string
+}
+
+// rules is the catalog of every check, keyed by code
+var rules = map[string]Rule{}
+
+func registerRule(rule Rule) {
+	rules[rule.Code] = rule
+}
+
+func init() {
+	for _, rule := range []Rule{
+		{RuleParse, "parse", SeverityError, "The document must be valid JSON matching the BLEF structure"},
+		{RuleFormat, "format", SeverityError, "The format field must be 'BLEF'"},
+		{RuleVersionRequired, "version-required", SeverityError, "The version field is required"},
+		{RuleCollectionsEmpty, "collections-empty", SeverityError, "A document must contain at least one collection"},
+		{RuleDuplicateBookID, "duplicate-book-id", SeverityError, "Book IDs must be unique"},
+		{RuleBookIDFormat, "book-id-format", SeverityError, "Book IDs must be an ISBN-13 or a UUID v4"},
+		{RuleISBN13CheckDigit, "isbn13-check-digit", SeverityError, "ISBN-13 book IDs must have a valid check digit"},
+		{RuleBookTitleRequired, "book-title-required", SeverityError, "Books must have a title"},
+		{RuleBookAuthorsRequired, "book-authors-required", SeverityError, "Books must have at least one author"},
+		{RuleDuplicateCollectionID, "duplicate-collection-id", SeverityError, "Collection IDs must be unique"},
+		{RuleCollectionNameRequired, "collection-name-required", SeverityError, "Collections must have a name"},
+		{RuleCollectionTypeRequired, "collection-type-required", SeverityError, "Collections must have a type"},
+		{RuleMissingBook, "missing-book", SeverityError, "Entries must reference an existing book"},
+		{RuleMissingCollection, "missing-collection", SeverityError, "Entries must reference existing collections"},
+		{RuleEntryCollectionsEmpty, "entry-collections-empty", SeverityError, "Entries must belong to at least one collection"},
+		{RuleEntryStatus, "entry-status", SeverityError, "Entry status must be one of read, reading, to-read, abandoned, wishlist"},
+		{RuleEntryRating, "entry-rating", SeverityError, "Ratings must be between 0 and 5"},
+		{RuleDuplicateEntry, "duplicate-entry", SeverityError, "A book must not have more than one entry"},
+		{RuleAuthorNameRequired, "author-name-required", SeverityError, "Authors must have a name"},
+		{RuleAuthorRole, "author-role", SeverityError, "Author role must be one of author, editor, translator, illustrator, contributor"},
+		{RuleEditionFormat, "edition-format", SeverityError, "Edition format must be one of hardcover, paperback, ebook, audiobook, other"},
+		{RulePages, "pages", SeverityError, "Page counts must be positive"},
+		{RuleSeriesNameRequired, "series-name-required", SeverityError, "Series must have a name"},
+		{RuleCollectionType, "collection-type", SeverityError, "Collection type must be one of read, reading, to-read, wishlist, owned, custom"},
+		{RuleProgress, "progress", SeverityError, "Progress must be between 0 and 100"},
+		{RuleIdentifierISBN, "identifier-isbn", SeverityError, "ISBN-10 and ISBN-13 identifiers must have a valid check digit"},
+		{RuleSchema, "schema", SeverityError, "The document must match the BLEF JSON schema"},
+		{RuleReadWithoutFinishDate, "read-without-finish-date", SeverityWarning, "Books marked as read should have a finished date"},
+		{RuleFinishedBeforeStarted, "finished-before-started", SeverityWarning, "A finished date should not be earlier than its started date"},
+		{RuleLoanedNotOwned, "loaned-not-owned", SeverityWarning, "Loaned books should be marked as owned"},
+		{RuleRatingOnUnread, "rating-on-unread", SeverityWarning, "Books that are to-read or wishlisted should not be rated"},
+		{RuleCompleteWhileReading, "complete-while-reading", SeverityWarning, "Books at 100% progress should not be marked as reading"},
+		{RuleStatusCollectionMismatch, "status-collection-mismatch", SeverityWarning, "Entry status should match the type of its status collections"},
+		{RuleLanguageCode, "language-code", SeverityWarning, "Book language should be an ISO 639-1 code"},
+		{RuleCoverURL, "cover-url", SeverityWarning, "Cover URLs should be absolute URIs"},
+		{RulePublishedDate, "published-date", SeverityWarning, "Published dates should be an ISO 8601 date or year"},
+		{RuleDate, "date", SeverityWarning, "Read and loan dates should be ISO 8601 dates (YYYY-MM-DD)"},
+	} {
+		registerRule(rule)
+	}
+}
+
+// LookupRule returns the catalog entry for a code. Unknown codes are
+// reported as errors.
+func LookupRule(code string) Rule {
+	if rule, ok := rules[code]; ok {
+		return rule
+	}
+	return Rule{Code: code, Name: code, Severity: SeverityError}
+}
+
+// Rules returns every known rule sorted by code
+func Rules() []Rule {
+	list := make([]Rule, 0, len(rules))
+	for _, rule := range rules {
+		list = append(list, rule)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Code < list[j].Code
+	})
+	return list
+}
+
+// ValidationReport collects the findings of a validation run
+type ValidationReport struct {
+	SchemaVersion string
+	Findings      []ValidationError
+
+	// Document is the parsed document, nil if it could not be parsed
+	Document *BLEFDocument
+}
+
+// NewValidationReport creates an empty report
+func NewValidationReport() *ValidationReport {
+	return &ValidationReport{}
+}
+
+// Add appends findings. Errors that are not ValidationError values are
+// recorded as parse errors at the document root.
+func (r *ValidationReport) Add(errs ...error) {
+	for _, err := range errs {
+		finding, ok := err.(ValidationError)
+		if !ok {
+			finding = newValidationError(RuleParse, "", nil, err.Error())
+		}
+		r.Findings = append(r.Findings, finding)
+	}
+}
+
+// Count returns the number of findings with the given severity
+func (r *ValidationReport) Count(severity Severity) int {
+	count := 0
+	for _, finding := range r.Findings {
+		if finding.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
+
+// HasErrors reports whether any finding has error severity
+func (r *ValidationReport) HasErrors() bool {
+	return r.Count(SeverityError) > 0
+}
+
+// Valid reports whether the document has no error, warnings are allowed
+func (r *ValidationReport) Valid() bool {
+	return !r.HasErrors()
+}
+
+// jsonFinding is the JSON representation of a finding
+type jsonFinding struct {
+	Code     string      `json:"code"`
+	Rule     string      `json:"rule"`
+	Severity Severity    `json:"severity"`
+	Pointer  string      `json:"pointer"`
+	Message  string      `json:"message"`
+	Value    interface{} `json:"value,omitempty"`
+	Fix      string      `json:"fix,omitempty"`
+}
+
+// WriteJSON writes the report as a JSON object
+func (r *ValidationReport) WriteJSON(w io.Writer) error {
+	findings := make([]jsonFinding, 0, len(r.Findings))
+	for _, finding := range r.Findings {
+		findings = append(findings, jsonFinding{
+			Code:     finding.Code,
+			Rule:     LookupRule(finding.Code).Name,
+			Severity: finding.Severity,
+			Pointer:  finding.Pointer,
+			Message:  finding.Message,
+			Value:    finding.Value,
+			Fix:      fixDescription(finding.Fix),
+		})
+	}
+
+	output := struct {
+		Valid         bool          `json:"valid"`
+		SchemaVersion string        `json:"schema_version,omitempty"`
+		Errors        int           `json:"errors"`
+		Warnings      int           `json:"warnings"`
+		Infos         int           `json:"infos"`
+		Findings      []jsonFinding `json:"findings"`
+	}{
+		Valid:         r.Valid(),
+		SchemaVersion: r.SchemaVersion,
+		Errors:        r.Count(SeverityError),
+		Warnings:      r.Count(SeverityWarning),
+		Infos:         r.Count(SeverityInfo),
+		Findings:      findings,
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(output)
+}
+
+// WriteSARIF writes the report as a SARIF 2.1.0 log. uri is the path of the
+// validated file as it should appear in the log; when data holds its content,
+// results are given a line and column.
+func (r *ValidationReport) WriteSARIF(w io.Writer, uri string, data []byte) error {
+	type message struct {
+		Text string `json:"text"`
+	}
+	type region struct {
+		StartLine   int `json:"startLine"`
+		StartColumn int `json:"startColumn"`
+	}
+	type physicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+		Region *region `json:"region,omitempty"`
+	}
+	type logicalLocation struct {
+		FullyQualifiedName string `json:"fullyQualifiedName"`
+	}
+	type location struct {
+		PhysicalLocation physicalLocation  `json:"physicalLocation"`
+		LogicalLocations []logicalLocation `json:"logicalLocations,omitempty"`
+	}
+	type result struct {
+		RuleID    string     `json:"ruleId"`
+		Level     string     `json:"level"`
+		Message   message    `json:"message"`
+		Locations []location `json:"locations"`
+	}
+	type rule struct {
+		ID                   string  `json:"id"`
+		Name                 string  `json:"name"`
+		ShortDescription     message `json:"shortDescription"`
+		DefaultConfiguration struct {
+			Level string `json:"level"`
+		} `json:"defaultConfiguration"`
+	}
+
+	var sarifRules []rule
+	for _, catalogRule := range Rules() {
+		sr := rule{ID: catalogRule.Code, Name: catalogRule.Name, ShortDescription: message{catalogRule.Description}}
+		sr.DefaultConfiguration.Level = sarifLevel(catalogRule.Severity)
+		sarifRules = append(sarifRules, sr)
+	}
+
+	results := make([]result, 0, len(r.Findings))
+	for _, finding := range r.Findings {
+		loc := location{}
+		loc.PhysicalLocation.ArtifactLocation.URI = uri
+		if line, column, ok := LocatePointer(data, finding.Pointer); ok {
+			loc.PhysicalLocation.Region = &region{StartLine: line, StartColumn: column}
+		}
+		if finding.Pointer != "" {
+			loc.LogicalLocations = []logicalLocation{{FullyQualifiedName: finding.Pointer}}
+		}
+		results = append(results, result{
+			RuleID:    finding.Code,
+			Level:     sarifLevel(finding.Severity),
+			Message:   message{finding.Message},
+			Locations: []location{loc},
+		})
+	}
+
+	log := map[string]interface{}{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": []interface{}{
+			map[string]interface{}{
+				"tool": map[string]interface{}{
+					"driver": map[string]interface{}{
+						"name":           "blef-cli",
+						"informationUri": "https://github.com/yoanbernabeu/BLEF",
+						"rules":          sarifRules,
+					},
+				},
+				"results": results,
+			},
+		},
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(log)
+}
+
+func fixDescription(fix *Fix) string {
+	if fix == nil {
+		return ""
+	}
+	return fix.Description
+}
+
+func sarifLevel(severity Severity) string {
+	switch severity {
+	case SeverityWarning:
+		return "warning"
+	case SeverityInfo:
+		return "note"
+	default:
+		return "error"
+	}
+}
+
+// jsonPointer builds an RFC 6901 pointer from string and int tokens
+func jsonPointer(tokens ...interface{}) string {
+	var sb strings.Builder
+	for _, token := range tokens {
+		sb.WriteByte('/')
+		switch t := token.(type) {
+		case int:
+			sb.WriteString(strconv.Itoa(t))
+		default:
+			s := fmt.Sprint(t)
+			sb.WriteString(strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1"))
+		}
+	}
+	return sb.String()
+}
+
+// splitPointer returns the unescaped tokens of a JSON pointer
+func splitPointer(pointer string) []string {
+	if pointer == "" {
+		return nil
+	}
+	tokens := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens
+}
+
+// pointerToField converts a JSON pointer to the dotted notation used by
+// ValidationError.Field: /entries/0/user_data/status becomes
+// entries[0].user_data.status
+func pointerToField(pointer string) string {
+	var sb strings.Builder
+	for _, token := range splitPointer(pointer) {
+		if _, err := strconv.Atoi(token); err == nil && sb.Len() > 0 {
+			fmt.Fprintf(&sb, "[%s]", token)
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteByte('.')
+		}
+		sb.WriteString(token)
+	}
+	return sb.String()
+}
+
+// LocatePointer returns the 1-based line and column where the value at
+// pointer starts in a JSON document
+func LocatePointer(data []byte, pointer string) (line, column int, ok bool) {
+	if len(data) == 0 {
+		return 0, 0, false
+	}
+	target := splitPointer(pointer)
+
+	type frame struct {
+		object    bool
+		expectKey bool
+		key       string
+		index     int
+	}
+	var stack []*frame
+
+	atTarget := func() bool {
+		if len(stack) != len(target) {
+			return false
+		}
+		for i, f := range stack {
+			segment := f.key
+			if !f.object {
+				segment = strconv.Itoa(f.index)
+			}
+			if segment != target[i] {
+				return false
+			}
+		}
+		return true
+	}
+	// valueDone moves the enclosing container past a complete value
+	valueDone := func() {
+		if len(stack) == 0 {
+			return
+		}
+		top := stack[len(stack)-1]
+		if top.object {
+			top.expectKey = true
+		} else {
+			top.index++
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		start := int(dec.InputOffset())
+		tok, err := dec.Token()
+		if err != nil {
+			return 0, 0, false
+		}
+
+		if delim, isDelim := tok.(json.Delim); isDelim && (delim == '}' || delim == ']') {
+			stack = stack[:len(stack)-1]
+			valueDone()
+			continue
+		}
+		if len(stack) > 0 {
+			if top := stack[len(stack)-1]; top.object && top.expectKey {
+				top.key, _ = tok.(string)
+				top.expectKey = false
+				continue
+			}
+		}
+
+		if atTarget() {
+			// Skip separators between the previous token and this value
+			for start < len(data) && strings.IndexByte(" \t\r\n:,", data[start]) >= 0 {
+				start++
+			}
+			line = 1 + bytes.Count(data[:start], []byte("\n"))
+			column = start - bytes.LastIndexByte(data[:start], '\n')
+			return line, column, true
+		}
+
+		switch tok {
+		case json.Delim('{'):
+			stack = append(stack, &frame{object: true, expectKey: true})
+		case json.Delim('['):
+			stack = append(stack, &frame{})
+		default:
+			valueDone()
+		}
+	}
+}
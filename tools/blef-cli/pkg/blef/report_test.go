@@ -0,0 +1,162 @@
+package blef
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestValidationReportFindings(t *testing.T) {
+	doc := NewDocument()
+	doc.Books = []Book{{ID: "9780156013987", Title: "Book", Authors: []Author{{Name: "Author"}}}}
+	doc.Collections = []Collection{{ID: "read", Name: "Read", Type: "read"}}
+	doc.Entries = []Entry{
+		{BookID: "9780156013987", CollectionIDs: []string{"read", "missing/shelf"}, UserData: UserData{Status: "read"}},
+	}
+
+	report := ValidateDocumentReport(doc)
+	if len(report.Findings) != 1 {
+		t.Fatalf("Expected 1 finding, got %v", report.Findings)
+	}
+
+	finding := report.Findings[0]
+	if finding.Code != RuleMissingCollection || finding.Severity != SeverityError {
+		t.Errorf("Unexpected finding: %+v", finding)
+	}
+	if finding.Pointer != "/entries/0/collection_ids/1" {
+		t.Errorf("Expected pointer /entries/0/collection_ids/1, got %s", finding.Pointer)
+	}
+	if finding.Field != "entries[0].collection_ids[1]" {
+		t.Errorf("Expected field entries[0].collection_ids[1], got %s", finding.Field)
+	}
+	if finding.Value != "missing/shelf" {
+		t.Errorf("Expected offending value, got %v", finding.Value)
+	}
+	if report.Valid() {
+		t.Error("Report with an error should not be valid")
+	}
+}
+
+func TestRuleCodePrefixMatchesSeverity(t *testing.T) {
+	prefixes := map[Severity]string{SeverityError: "BLEF-E", SeverityWarning: "BLEF-W", SeverityInfo: "BLEF-I"}
+	for _, rule := range Rules() {
+		if !strings.HasPrefix(rule.Code, prefixes[rule.Severity]) {
+			t.Errorf("Rule %s has severity %s", rule.Code, rule.Severity)
+		}
+	}
+}
+
+func TestValidateJSONReportsSchemaFindings(t *testing.T) {
+	data := []byte(`{
+  "format": "BLEF",
+  "version": "0.1.0",
+  "exported_at": "2025-10-26T14:30:00Z",
+  "books": [],
+  "collections": [{"id": "read", "name": "Read", "type": "read", "is_public": "yes"}],
+  "entries": []
+}`)
+
+	report, err := ValidateJSON(data, "")
+	if err != nil {
+		t.Fatalf("ValidateJSON failed: %v", err)
+	}
+	if report.SchemaVersion != "0.1.0" {
+		t.Errorf("Expected schema version 0.1.0, got %s", report.SchemaVersion)
+	}
+	if len(report.Findings) != 1 || report.Findings[0].Code != RuleSchema {
+		t.Fatalf("Expected one schema finding, got %v", report.Findings)
+	}
+	if pointer := report.Findings[0].Pointer; pointer != "/collections/0/is_public" {
+		t.Errorf("Expected pointer /collections/0/is_public, got %s", pointer)
+	}
+
+	line, column, ok := LocatePointer(data, report.Findings[0].Pointer)
+	if !ok || line != 6 || column != 79 {
+		t.Errorf("LocatePointer = %d:%d (%v), want 6:79", line, column, ok)
+	}
+}
+
+func TestLocatePointer(t *testing.T) {
+	data := []byte("{\n  \"a\": [1, {\"b~c\": true}],\n  \"d/e\": null\n}")
+
+	tests := []struct {
+		pointer string
+		line    int
+		column  int
+		ok      bool
+	}{
+		{"", 1, 1, true},
+		{"/a", 2, 8, true},
+		{"/a/1", 2, 12, true},
+		{"/a/1/b~0c", 2, 20, true},
+		{"/d~1e", 3, 10, true},
+		{"/missing", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		line, column, ok := LocatePointer(data, tt.pointer)
+		if ok != tt.ok || line != tt.line || column != tt.column {
+			t.Errorf("LocatePointer(%q) = %d:%d (%v), want %d:%d (%v)", tt.pointer, line, column, ok, tt.line, tt.column, tt.ok)
+		}
+	}
+}
+
+func TestReportWriters(t *testing.T) {
+	report := NewValidationReport()
+	report.Add(missingBookError(2, "9780000000002"))
+
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	var output struct {
+		Valid    bool `json:"valid"`
+		Errors   int  `json:"errors"`
+		Findings []struct {
+			Code    string `json:"code"`
+			Pointer string `json:"pointer"`
+			Value   string `json:"value"`
+		} `json:"findings"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &output); err != nil {
+		t.Fatalf("Invalid JSON report: %v", err)
+	}
+	if output.Valid || output.Errors != 1 || output.Findings[0].Code != "BLEF-E012" ||
+		output.Findings[0].Pointer != "/entries/2/book_id" || output.Findings[0].Value != "9780000000002" {
+		t.Errorf("Unexpected JSON report: %s", buf.String())
+	}
+
+	buf.Reset()
+	if err := report.WriteSARIF(&buf, "library.blef.json", nil); err != nil {
+		t.Fatalf("WriteSARIF failed: %v", err)
+	}
+	var sarif struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Rules []struct {
+						ID string `json:"id"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID string `json:"ruleId"`
+				Level  string `json:"level"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &sarif); err != nil {
+		t.Fatalf("Invalid SARIF report: %v", err)
+	}
+	if sarif.Version != "2.1.0" || len(sarif.Runs) != 1 || len(sarif.Runs[0].Results) != 1 {
+		t.Fatalf("Unexpected SARIF report: %s", buf.String())
+	}
+	if result := sarif.Runs[0].Results[0]; result.RuleID != "BLEF-E012" || result.Level != "error" {
+		t.Errorf("Unexpected SARIF result: %+v", result)
+	}
+	if len(sarif.Runs[0].Tool.Driver.Rules) != len(Rules()) {
+		t.Errorf("Expected every rule in the SARIF driver")
+	}
+}
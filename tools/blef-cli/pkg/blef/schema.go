@@ -0,0 +1,141 @@
+package blef
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/blef/jsonschema"
+)
+
+// Every file in schemas/ named blef-schema-v<version>.json is registered
+// automatically, so supporting a new spec version only requires adding its schema.
+//
+//go:embed schemas/*.json
+var schemaFS embed.FS
+
+const (
+	schemaDir    = "schemas"
+	schemaPrefix = "blef-schema-v"
+	schemaSuffix = ".json"
+)
+
+var (
+	// schemaVersions lists the embedded schema versions in ascending order
+	schemaVersions []Version
+
+	compiledSchemas   = make(map[string]*jsonschema.Schema)
+	compiledSchemasMu sync.Mutex
+)
+
+func init() {
+	files, err := schemaFS.ReadDir(schemaDir)
+	if err != nil {
+		panic(fmt.Sprintf("failed to list embedded schemas: %v", err))
+	}
+
+	for _, file := range files {
+		name := file.Name()
+		if !strings.HasPrefix(name, schemaPrefix) || !strings.HasSuffix(name, schemaSuffix) {
+			continue
+		}
+		v, err := ParseVersion(strings.TrimSuffix(strings.TrimPrefix(name, schemaPrefix), schemaSuffix))
+		if err != nil {
+			panic(fmt.Sprintf("invalid embedded schema name %s: %v", name, err))
+		}
+		schemaVersions = append(schemaVersions, v)
+	}
+
+	sort.Slice(schemaVersions, func(i, j int) bool {
+		return schemaVersions[i].Compare(schemaVersions[j]) < 0
+	})
+}
+
+// SchemaVersions returns the versions of the embedded schemas in ascending order
+func SchemaVersions() []string {
+	versions := make([]string, len(schemaVersions))
+	for i, v := range schemaVersions {
+		versions[i] = v.String()
+	}
+	return versions
+}
+
+// ResolveSchemaVersion picks the embedded schema for a document version.
+// An exact match is preferred; otherwise the newest schema of the same major
+// version that is not newer than the document is used, falling back to the
+// oldest schema of that major version. Unknown major versions are rejected.
+func ResolveSchemaVersion(version string) (string, error) {
+	requested, err := ParseVersion(version)
+	if err != nil {
+		return "", err
+	}
+
+	var candidates []Version
+	for _, v := range schemaVersions {
+		if v.Major == requested.Major {
+			candidates = append(candidates, v)
+		}
+	}
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("unsupported BLEF major version %d (supported schema versions: %s)",
+			requested.Major, strings.Join(SchemaVersions(), ", "))
+	}
+
+	selected := candidates[0]
+	for _, v := range candidates {
+		if v.Compare(requested) <= 0 {
+			selected = v
+		}
+	}
+	return selected.String(), nil
+}
+
+// LoadSchema returns the embedded schema for an exact schema version
+func LoadSchema(version string) ([]byte, error) {
+	data, err := schemaFS.ReadFile(path.Join(schemaDir, schemaPrefix+version+schemaSuffix))
+	if err != nil {
+		return nil, fmt.Errorf("no embedded schema for version %s (supported: %s)", version, strings.Join(SchemaVersions(), ", "))
+	}
+	return data, nil
+}
+
+// CompiledSchema returns the compiled embedded schema for an exact schema
+// version. Schemas are compiled once and cached.
+func CompiledSchema(version string) (*jsonschema.Schema, error) {
+	compiledSchemasMu.Lock()
+	defer compiledSchemasMu.Unlock()
+
+	if schema, ok := compiledSchemas[version]; ok {
+		return schema, nil
+	}
+
+	data, err := LoadSchema(version)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := jsonschema.Compile(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile embedded schema %s: %w", version, err)
+	}
+	compiledSchemas[version] = schema
+	return schema, nil
+}
+
+// DetectVersion reads the "version" member of a JSON document without
+// decoding the rest of it
+func DetectVersion(jsonData []byte) (string, error) {
+	var header struct {
+		Version *string `json:"version"`
+	}
+	if err := json.Unmarshal(jsonData, &header); err != nil {
+		return "", fmt.Errorf("failed to read document version: %w", err)
+	}
+	if header.Version == nil || *header.Version == "" {
+		return "", fmt.Errorf("document has no version")
+	}
+	return *header.Version, nil
+}
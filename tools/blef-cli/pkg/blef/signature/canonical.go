@@ -0,0 +1,133 @@
+package signature
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"unicode/utf8"
+)
+
+// Canonicalization names the canonical serialization signed by this package
+const Canonicalization = "blef-c14n-v1"
+
+// ErrNotCanonicalizable is returned for JSON that cannot be canonicalized
+// without losing information, such as duplicate keys or invalid UTF-8
+var ErrNotCanonicalizable = errors.New("document cannot be canonicalized losslessly")
+
+// Canonicalize returns the canonical serialization of a JSON document: object
+// keys sorted by their UTF-8 bytes, no insignificant whitespace, no HTML
+// escaping of strings, and numbers as written in the input. Two files with
+// the same content always give the same bytes, whatever their whitespace and
+// key order. The document is canonicalized as written rather than as parsed
+// by the BLEF types, so no change is hidden by a lossy parse, such as two
+// integers beyond the float64 precision.
+func Canonicalize(data []byte) ([]byte, error) {
+	if !utf8.Valid(data) {
+		return nil, fmt.Errorf("%w: invalid UTF-8", ErrNotCanonicalizable)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var buf bytes.Buffer
+	if err := writeCanonical(&buf, decoder); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("failed to canonicalize document: unexpected data after the document")
+	}
+	return buf.Bytes(), nil
+}
+
+// writeCanonical writes the next JSON value of the decoder
+func writeCanonical(buf *bytes.Buffer, decoder *json.Decoder) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return fmt.Errorf("failed to canonicalize document: %w", err)
+	}
+
+	switch v := token.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		if v {
+			buf.WriteString("true")
+		} else {
+			buf.WriteString("false")
+		}
+	case json.Number:
+		buf.WriteString(v.String())
+	case string:
+		writeString(buf, v)
+	case json.Delim:
+		if v == '[' {
+			buf.WriteByte('[')
+			for i := 0; decoder.More(); i++ {
+				if i > 0 {
+					buf.WriteByte(',')
+				}
+				if err := writeCanonical(buf, decoder); err != nil {
+					return err
+				}
+			}
+			buf.WriteByte(']')
+		} else if err := writeObject(buf, decoder); err != nil {
+			return err
+		}
+		// Closing delimiter
+		if _, err := decoder.Token(); err != nil {
+			return fmt.Errorf("failed to canonicalize document: %w", err)
+		}
+	default:
+		return fmt.Errorf("unexpected JSON token %v", token)
+	}
+	return nil
+}
+
+// writeObject writes the members of an object sorted by key, the opening
+// brace being already read
+func writeObject(buf *bytes.Buffer, decoder *json.Decoder) error {
+	members := make(map[string][]byte)
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return fmt.Errorf("failed to canonicalize document: %w", err)
+		}
+		key := token.(string)
+		if _, exists := members[key]; exists {
+			return fmt.Errorf("%w: duplicate key %q", ErrNotCanonicalizable, key)
+		}
+		var value bytes.Buffer
+		if err := writeCanonical(&value, decoder); err != nil {
+			return err
+		}
+		members[key] = value.Bytes()
+	}
+
+	keys := make([]string, 0, len(members))
+	for key := range members {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	buf.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		writeString(buf, key)
+		buf.WriteByte(':')
+		buf.Write(members[key])
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+func writeString(buf *bytes.Buffer, s string) {
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(s)
+	// Encode terminates the value with a newline
+	buf.Truncate(buf.Len() - 1)
+}
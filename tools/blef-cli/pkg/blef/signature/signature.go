@@ -0,0 +1,223 @@
+// Package signature signs BLEF documents with Ed25519 and verifies them.
+//
+// Signatures are detached: they are stored next to the document, usually in a
+// ".sig" file, and cover the canonical serialization of the document (see
+// Canonicalize), so reformatting a file does not invalidate its signature.
+// Keys are stored as PEM files, PKCS #8 for private keys and PKIX for public
+// keys, which other tools such as OpenSSL can read.
+package signature
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Signature file constants
+const (
+	Version   = 1
+	Algorithm = "Ed25519"
+	Extension = ".sig"
+)
+
+var (
+	// ErrInvalidSignature is returned when a document does not match its
+	// signature
+	ErrInvalidSignature = errors.New("signature does not match the document")
+
+	// ErrUntrustedKey is returned when a signature was made with another key
+	// than the trusted one
+	ErrUntrustedKey = errors.New("signature was made with an untrusted key")
+)
+
+// Signature is a detached signature of a BLEF document. Signer and SignedAt
+// are informational and not covered by the signature.
+type Signature struct {
+	Version          int       `json:"version"`
+	Algorithm        string    `json:"algorithm"`
+	Canonicalization string    `json:"canonicalization"`
+	KeyID            string    `json:"key_id"`
+	PublicKey        []byte    `json:"public_key"`
+	Signer           string    `json:"signer,omitempty"`
+	SignedAt         time.Time `json:"signed_at"`
+	Digest           string    `json:"digest"` // SHA-256 of the canonical document, hex encoded
+	Signature        []byte    `json:"signature"`
+}
+
+// GenerateKey creates a new Ed25519 key pair
+func GenerateKey() (ed25519.PublicKey, ed25519.PrivateKey, error) {
+	return ed25519.GenerateKey(rand.Reader)
+}
+
+// KeyID returns a short fingerprint of a public key
+func KeyID(key ed25519.PublicKey) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Sign signs the canonical serialization of a JSON document
+func Sign(document []byte, key ed25519.PrivateKey, signer string) (*Signature, error) {
+	data, err := Canonicalize(document)
+	if err != nil {
+		return nil, err
+	}
+	public := key.Public().(ed25519.PublicKey)
+	digest := sha256.Sum256(data)
+
+	return &Signature{
+		Version:          Version,
+		Algorithm:        Algorithm,
+		Canonicalization: Canonicalization,
+		KeyID:            KeyID(public),
+		PublicKey:        public,
+		Signer:           signer,
+		SignedAt:         time.Now().UTC(),
+		Digest:           hex.EncodeToString(digest[:]),
+		Signature:        ed25519.Sign(key, data),
+	}, nil
+}
+
+// Verify checks that the signature matches a JSON document. With a trusted key,
+// the signature must also have been made with that key; without one, only
+// the integrity of the document is checked, against the key in the signature.
+// The key ID and digest of the signature are not signed, so they must match
+// the public key and the document.
+func Verify(document []byte, sig *Signature, trusted ed25519.PublicKey) error {
+	if sig.Version != Version || sig.Algorithm != Algorithm || sig.Canonicalization != Canonicalization {
+		return fmt.Errorf("unsupported signature (version %d, %s, %s)", sig.Version, sig.Algorithm, sig.Canonicalization)
+	}
+	if len(sig.PublicKey) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid public key in signature")
+	}
+	if sig.KeyID != KeyID(sig.PublicKey) {
+		return fmt.Errorf("key ID %s does not match the public key %s in signature", sig.KeyID, KeyID(sig.PublicKey))
+	}
+	if trusted != nil && !trusted.Equal(ed25519.PublicKey(sig.PublicKey)) {
+		return ErrUntrustedKey
+	}
+
+	data, err := Canonicalize(document)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(sig.PublicKey, data, sig.Signature) {
+		return ErrInvalidSignature
+	}
+	if digest := sha256.Sum256(data); sig.Digest != hex.EncodeToString(digest[:]) {
+		return fmt.Errorf("digest %s in signature does not match the document", sig.Digest)
+	}
+	return nil
+}
+
+// ParseSignature parses a signature file
+func ParseSignature(data []byte) (*Signature, error) {
+	var sig Signature
+	if err := json.Unmarshal(data, &sig); err != nil {
+		return nil, fmt.Errorf("failed to parse signature: %w", err)
+	}
+	return &sig, nil
+}
+
+// LoadSignature loads a signature from a file
+func LoadSignature(filename string) (*Signature, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return ParseSignature(data)
+}
+
+// ToJSON converts the signature to JSON bytes with indentation
+func (s *Signature) ToJSON() ([]byte, error) {
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// SaveToFile writes the signature to a file
+func (s *Signature) SaveToFile(filename string) error {
+	data, err := s.ToJSON()
+	if err != nil {
+		return fmt.Errorf("failed to serialize signature: %w", err)
+	}
+	if err := os.WriteFile(filename, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
+// MarshalPrivateKey encodes a private key as a PKCS #8 PEM block
+func MarshalPrivateKey(key ed25519.PrivateKey) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// MarshalPublicKey encodes a public key as a PKIX PEM block
+func MarshalPublicKey(key ed25519.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+// ParsePrivateKey decodes a PKCS #8 PEM private key
+func ParsePrivateKey(data []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, fmt.Errorf("no PEM private key found")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	private, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key is not an Ed25519 key")
+	}
+	return private, nil
+}
+
+// ParsePublicKey decodes a PKIX PEM public key
+func ParsePublicKey(data []byte) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, fmt.Errorf("no PEM public key found")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	public, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key is not an Ed25519 key")
+	}
+	return public, nil
+}
+
+// LoadPrivateKey loads a PEM private key from a file
+func LoadPrivateKey(filename string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return ParsePrivateKey(data)
+}
+
+// LoadPublicKey loads a PEM public key from a file
+func LoadPublicKey(filename string) (ed25519.PublicKey, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return ParsePublicKey(data)
+}
@@ -0,0 +1,175 @@
+package signature
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/blef"
+)
+
+func testDocument() []byte {
+	doc := blef.NewDocument()
+	doc.ExportedAt = time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	doc.Books = []blef.Book{{ID: "9780547928227", Title: "The Hobbit <illustrated> & more", Authors: []blef.Author{{Name: "J.R.R. Tolkien"}}}}
+	doc.Entries = []blef.Entry{{BookID: "9780547928227", UserData: blef.UserData{Status: "read", Rating: 4.5}}}
+	data, _ := doc.ToJSON()
+	return data
+}
+
+func TestCanonicalize(t *testing.T) {
+	data, err := Canonicalize(testDocument())
+	if err != nil {
+		t.Fatalf("Canonicalize failed: %v", err)
+	}
+
+	// Any layout of the same content gives the same bytes
+	doc, err := blef.FromJSON(testDocument())
+	if err != nil {
+		t.Fatalf("FromJSON failed: %v", err)
+	}
+	compact, _ := json.Marshal(doc)
+	again, err := Canonicalize(compact)
+	if err != nil {
+		t.Fatalf("Canonicalize failed: %v", err)
+	}
+	if string(again) != string(data) {
+		t.Errorf("Expected the same canonical form\ngot:  %s\nwant: %s", again, data)
+	}
+
+	for _, want := range []string{`{"books":[{"authors":[{"name":"J.R.R. Tolkien"}]`, `"The Hobbit <illustrated> & more"`, `"rating":4.5`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Expected %s in canonical form %s", want, data)
+		}
+	}
+
+	// Numbers are kept as written
+	data, err = Canonicalize([]byte(`{"n": 9007199254740993, "f": 1.50}`))
+	if err != nil {
+		t.Fatalf("Canonicalize failed: %v", err)
+	}
+	if string(data) != `{"f":1.50,"n":9007199254740993}` {
+		t.Errorf("Expected numbers as written, got %s", data)
+	}
+}
+
+func TestCanonicalizeRejectsLossyInput(t *testing.T) {
+	for _, input := range []string{
+		`{"a": 1, "a": 2}`,
+		"{\"a\": \"\xff\"}",
+		`{"a": 1} {"b": 2}`,
+		`{"a": }`,
+	} {
+		if _, err := Canonicalize([]byte(input)); err == nil {
+			t.Errorf("Expected an error for %q", input)
+		}
+	}
+	if _, err := Canonicalize([]byte(`{"a": {"b": 1, "b": 1}}`)); !errors.Is(err, ErrNotCanonicalizable) {
+		t.Errorf("Expected ErrNotCanonicalizable for a duplicate key, got %v", err)
+	}
+}
+
+func TestSignAndVerify(t *testing.T) {
+	public, private, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+
+	doc := testDocument()
+	sig, err := Sign(doc, private, "blef-cli test")
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if sig.KeyID != KeyID(public) {
+		t.Errorf("Expected key ID %s, got %s", KeyID(public), sig.KeyID)
+	}
+
+	data, _ := sig.ToJSON()
+	parsed, err := ParseSignature(data)
+	if err != nil {
+		t.Fatalf("ParseSignature failed: %v", err)
+	}
+	if err := Verify(doc, parsed, public); err != nil {
+		t.Errorf("Expected a valid signature, got %v", err)
+	}
+	if err := Verify(doc, parsed, nil); err != nil {
+		t.Errorf("Expected a valid signature without a trusted key, got %v", err)
+	}
+
+	modified := strings.Replace(string(doc), `"rating": 4.5`, `"rating": 5`, 1)
+	if modified == string(doc) {
+		t.Fatal("Expected the rating in the test document")
+	}
+	if err := Verify([]byte(modified), parsed, public); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Expected ErrInvalidSignature for a modified document, got %v", err)
+	}
+
+	other, _, _ := GenerateKey()
+	if err := Verify(doc, parsed, other); !errors.Is(err, ErrUntrustedKey) {
+		t.Errorf("Expected ErrUntrustedKey, got %v", err)
+	}
+
+	// The key ID and digest are not signed and must not be trusted as is
+	forged := *parsed
+	forged.KeyID = KeyID(other)
+	if err := Verify(doc, &forged, nil); err == nil {
+		t.Error("Expected an error for a key ID that does not match the public key")
+	}
+	forged = *parsed
+	forged.Digest = strings.Repeat("0", 64)
+	if err := Verify(doc, &forged, nil); err == nil {
+		t.Error("Expected an error for a digest that does not match the document")
+	}
+}
+
+func TestVerifyDetectsPrecisionTampering(t *testing.T) {
+	_, private, _ := GenerateKey()
+	signed := strings.Replace(string(testDocument()), `"title": "The Hobbit`, `"metadata": {"n": 9007199254740993}, "title": "The Hobbit`, 1)
+	tampered := strings.Replace(signed, "9007199254740993", "9007199254740992", 1)
+
+	// Both values are the same float64, so only the text tells them apart
+	for _, data := range []string{signed, tampered} {
+		if _, err := blef.FromJSON([]byte(data)); err != nil {
+			t.Fatalf("FromJSON failed: %v", err)
+		}
+	}
+
+	sig, err := Sign([]byte(signed), private, "")
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if err := Verify([]byte(signed), sig, nil); err != nil {
+		t.Errorf("Expected a valid signature, got %v", err)
+	}
+	if err := Verify([]byte(tampered), sig, nil); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Expected ErrInvalidSignature for a tampered number, got %v", err)
+	}
+}
+
+func TestKeyEncoding(t *testing.T) {
+	public, private, _ := GenerateKey()
+
+	data, err := MarshalPrivateKey(private)
+	if err != nil {
+		t.Fatalf("MarshalPrivateKey failed: %v", err)
+	}
+	parsedPrivate, err := ParsePrivateKey(data)
+	if err != nil || !parsedPrivate.Equal(private) {
+		t.Errorf("Expected the private key back, got %v", err)
+	}
+
+	data, err = MarshalPublicKey(public)
+	if err != nil {
+		t.Fatalf("MarshalPublicKey failed: %v", err)
+	}
+	parsedPublic, err := ParsePublicKey(data)
+	if err != nil || !parsedPublic.Equal(public) {
+		t.Errorf("Expected the public key back, got %v", err)
+	}
+
+	if _, err := ParsePrivateKey(data); err == nil {
+		t.Error("Expected an error when parsing a public key as a private key")
+	}
+}
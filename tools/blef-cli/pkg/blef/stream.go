@@ -0,0 +1,433 @@
+package blef
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+)
+
+// ElementKind identifies the top-level array an element was read from
+type ElementKind int
+
+const (
+	KindBook ElementKind = iota + 1
+	KindCollection
+	KindEntry
+	// KindUser is the user block of a document. Streams do not return it as
+	// an element; it identifies the user in merge conflicts.
+	KindUser
+	// KindDocument is the root object of a document, for merge conflicts on
+	// its own members
+	KindDocument
+)
+
+func (k ElementKind) String() string {
+	switch k {
+	case KindBook:
+		return "books"
+	case KindCollection:
+		return "collections"
+	case KindEntry:
+		return "entries"
+	case KindUser:
+		return "user"
+	case KindDocument:
+		return "document"
+	default:
+		return "unknown"
+	}
+}
+
+// Singular names one element of the kind, e.g. "book"
+func (k ElementKind) Singular() string {
+	switch k {
+	case KindBook:
+		return "book"
+	case KindCollection:
+		return "collection"
+	case KindEntry:
+		return "entry"
+	case KindUser:
+		return "user"
+	case KindDocument:
+		return "document"
+	default:
+		return "unknown"
+	}
+}
+
+// Element is a single book, collection or entry decoded from a stream.
+// Exactly one of Book, Collection or Entry is set, according to Kind.
+type Element struct {
+	Kind       ElementKind
+	Index      int   // position within its array
+	Offset     int64 // byte offset of the element in the input
+	Book       *Book
+	Collection *Collection
+	Entry      *Entry
+}
+
+type streamState int
+
+const (
+	stateStart streamState = iota
+	stateObject
+	stateArray
+	stateDone
+)
+
+// StreamReader decodes a BLEF document one array element at a time,
+// so that very large libraries can be processed without loading the whole
+// document.
+type StreamReader struct {
+	dec    *json.Decoder
+	header BLEFDocument
+	state  streamState
+	kind   ElementKind
+	index  int
+}
+
+// NewStreamReader creates a streaming reader over a BLEF JSON document
+func NewStreamReader(r io.Reader) *StreamReader {
+	return &StreamReader{dec: json.NewDecoder(r)}
+}
+
+// Header returns the root fields (format, version, exported_at, user and
+// unknown members) read so far. Its Books, Collections and Entries are always
+// empty. The header is only guaranteed to be complete once Next has returned
+// io.EOF, since JSON does not impose any member order.
+func (r *StreamReader) Header() *BLEFDocument {
+	return &r.header
+}
+
+// Next returns the next book, collection or entry in document order.
+// It returns io.EOF once the whole document has been consumed.
+func (r *StreamReader) Next() (*Element, error) {
+	for {
+		switch r.state {
+		case stateStart:
+			if err := r.expectDelim('{'); err != nil {
+				return nil, err
+			}
+			r.state = stateObject
+
+		case stateObject:
+			if !r.dec.More() {
+				if err := r.expectDelim('}'); err != nil {
+					return nil, err
+				}
+				r.state = stateDone
+				return nil, io.EOF
+			}
+			if err := r.readMember(); err != nil {
+				return nil, err
+			}
+
+		case stateArray:
+			if !r.dec.More() {
+				if err := r.expectDelim(']'); err != nil {
+					return nil, err
+				}
+				r.state = stateObject
+				continue
+			}
+			return r.readElement()
+
+		default:
+			return nil, io.EOF
+		}
+	}
+}
+
+// readMember reads a root member name and either enters an array or
+// decodes the value into the header
+func (r *StreamReader) readMember() error {
+	tok, err := r.dec.Token()
+	if err != nil {
+		return fmt.Errorf("failed to parse BLEF document: %w", err)
+	}
+	key, ok := tok.(string)
+	if !ok {
+		return fmt.Errorf("failed to parse BLEF document: unexpected token %v", tok)
+	}
+
+	var kind ElementKind
+	switch key {
+	case "books":
+		kind = KindBook
+	case "collections":
+		kind = KindCollection
+	case "entries":
+		kind = KindEntry
+	}
+
+	if kind == 0 {
+		var raw json.RawMessage
+		if err := r.dec.Decode(&raw); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", key, err)
+		}
+		type plain BLEFDocument
+		if !isKnownMember(reflect.TypeOf(plain{}), key) {
+			member, err := newMember(key, raw)
+			if err != nil {
+				return fmt.Errorf("failed to parse %s: %w", key, err)
+			}
+			r.header.Extensions = append(r.header.Extensions, member)
+			return nil
+		}
+		member, err := json.Marshal(map[string]json.RawMessage{key: raw})
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(member, (*plain)(&r.header)); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", key, err)
+		}
+		return nil
+	}
+
+	tok, err = r.dec.Token()
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", key, err)
+	}
+	if tok == nil {
+		return nil // null array, nothing to stream
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("failed to parse %s: expected an array", key)
+	}
+	r.kind = kind
+	r.index = 0
+	r.state = stateArray
+	return nil
+}
+
+func (r *StreamReader) readElement() (*Element, error) {
+	elem := &Element{Kind: r.kind, Index: r.index, Offset: r.dec.InputOffset()}
+
+	var err error
+	switch r.kind {
+	case KindBook:
+		elem.Book = &Book{}
+		err = r.dec.Decode(elem.Book)
+	case KindCollection:
+		elem.Collection = &Collection{}
+		err = r.dec.Decode(elem.Collection)
+	case KindEntry:
+		elem.Entry = &Entry{}
+		err = r.dec.Decode(elem.Entry)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s[%d]: %w", r.kind, r.index, err)
+	}
+
+	r.index++
+	return elem, nil
+}
+
+func (r *StreamReader) expectDelim(want json.Delim) error {
+	tok, err := r.dec.Token()
+	if err != nil {
+		return fmt.Errorf("failed to parse BLEF document: %w", err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != want {
+		return fmt.Errorf("failed to parse BLEF document: expected '%s', got %v", want, tok)
+	}
+	return nil
+}
+
+// ReadBookAt decodes the book found at an Element offset. Together with
+// StreamReader it allows a book to be fetched again without keeping it in memory.
+func ReadBookAt(r io.ReaderAt, offset int64) (*Book, error) {
+	br := bufio.NewReader(io.NewSectionReader(r, offset, math.MaxInt64-offset))
+
+	// The offset points right after the previous token, skip separators
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read book at offset %d: %w", offset, err)
+		}
+		if b != ' ' && b != '\t' && b != '\r' && b != '\n' && b != ',' {
+			_ = br.UnreadByte()
+			break
+		}
+	}
+
+	var book Book
+	if err := json.NewDecoder(br).Decode(&book); err != nil {
+		return nil, fmt.Errorf("failed to read book at offset %d: %w", offset, err)
+	}
+	return &book, nil
+}
+
+// StreamWriter writes a BLEF document incrementally. Books, collections and
+// entries must be written in that order; the output is formatted exactly like
+// BLEFDocument.ToJSON.
+type StreamWriter struct {
+	w       *bufio.Writer
+	header  *BLEFDocument
+	started bool
+	closed  bool
+	section ElementKind
+	count   int
+}
+
+// NewStreamWriter creates a streaming writer. Only the root fields of header
+// (format, version, exported_at, user) are written, and its unknown members
+// once the entries are.
+func NewStreamWriter(w io.Writer, header *BLEFDocument) *StreamWriter {
+	return &StreamWriter{w: bufio.NewWriter(w), header: header}
+}
+
+// WriteBook appends a book to the books array
+func (sw *StreamWriter) WriteBook(book *Book) error {
+	return sw.write(KindBook, book)
+}
+
+// WriteCollection appends a collection to the collections array
+func (sw *StreamWriter) WriteCollection(collection *Collection) error {
+	return sw.write(KindCollection, collection)
+}
+
+// WriteEntry appends an entry to the entries array
+func (sw *StreamWriter) WriteEntry(entry *Entry) error {
+	return sw.write(KindEntry, entry)
+}
+
+// Close terminates the document and flushes the underlying writer.
+// It does not close the underlying io.Writer.
+func (sw *StreamWriter) Close() error {
+	if sw.closed {
+		return nil
+	}
+	if err := sw.advance(KindEntry); err != nil {
+		return err
+	}
+	sw.closeSection()
+	for _, member := range sw.header.Extensions {
+		_ = sw.w.WriteByte(',')
+		if err := sw.writeMember(member.Name, member.Value); err != nil {
+			return err
+		}
+	}
+	_, _ = sw.w.WriteString("\n}")
+	sw.closed = true
+	return sw.w.Flush()
+}
+
+func (sw *StreamWriter) write(kind ElementKind, v interface{}) error {
+	if sw.closed {
+		return errors.New("stream writer is closed")
+	}
+	if kind < sw.section {
+		return fmt.Errorf("cannot write %s after %s: books, collections and entries must be written in order", kind, sw.section)
+	}
+	if err := sw.advance(kind); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(v, "    ", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s[%d]: %w", kind, sw.count, err)
+	}
+	if sw.count > 0 {
+		_ = sw.w.WriteByte(',')
+	}
+	_, _ = sw.w.WriteString("\n    ")
+	_, err = sw.w.Write(data)
+	sw.count++
+	return err
+}
+
+// advance writes the header if needed and opens every section up to kind
+func (sw *StreamWriter) advance(kind ElementKind) error {
+	if !sw.started {
+		if err := sw.writeHeader(); err != nil {
+			return err
+		}
+		sw.started = true
+	}
+	for sw.section < kind {
+		if sw.section != 0 {
+			sw.closeSection()
+		}
+		sw.section++
+		sw.count = 0
+		fmt.Fprintf(sw.w, ",\n  %q: [", sw.section.String())
+	}
+	return nil
+}
+
+func (sw *StreamWriter) closeSection() {
+	if sw.count > 0 {
+		_, _ = sw.w.WriteString("\n  ")
+	}
+	_ = sw.w.WriteByte(']')
+}
+
+func (sw *StreamWriter) writeHeader() error {
+	type member struct {
+		name  string
+		value interface{}
+	}
+
+	_, _ = sw.w.WriteString("{")
+	fields := []member{
+		{"format", sw.header.Format},
+		{"version", sw.header.Version},
+		{"exported_at", sw.header.ExportedAt},
+	}
+	if sw.header.User != nil {
+		fields = append(fields, member{"user", sw.header.User})
+	}
+
+	for i, field := range fields {
+		if i > 0 {
+			_ = sw.w.WriteByte(',')
+		}
+		if err := sw.writeMember(field.name, field.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeMember writes a root member on its own line
+func (sw *StreamWriter) writeMember(name string, value interface{}) error {
+	data, err := json.MarshalIndent(value, "  ", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+	key, err := json.Marshal(name)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(sw.w, "\n  %s: ", key)
+	_, err = sw.w.Write(data)
+	return err
+}
+
+// WriteDocument streams an in-memory document to w without building the
+// whole JSON output in memory first
+func WriteDocument(w io.Writer, doc *BLEFDocument) error {
+	sw := NewStreamWriter(w, doc)
+	for i := range doc.Books {
+		if err := sw.WriteBook(&doc.Books[i]); err != nil {
+			return err
+		}
+	}
+	for i := range doc.Collections {
+		if err := sw.WriteCollection(&doc.Collections[i]); err != nil {
+			return err
+		}
+	}
+	for i := range doc.Entries {
+		if err := sw.WriteEntry(&doc.Entries[i]); err != nil {
+			return err
+		}
+	}
+	return sw.Close()
+}
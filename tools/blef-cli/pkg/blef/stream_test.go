@@ -0,0 +1,156 @@
+package blef
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newStreamTestDocument() *BLEFDocument {
+	addedAt := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	return &BLEFDocument{
+		Format:     "BLEF",
+		Version:    "0.1.0",
+		ExportedAt: time.Date(2025, 10, 26, 14, 30, 0, 0, time.UTC),
+		User:       &User{Name: "Test User", Metadata: map[string]interface{}{"platform": "test"}},
+		Books: []Book{
+			{ID: "9780156013987", Title: "The Little Prince", Authors: []Author{{Name: "Antoine de Saint-Exupéry"}},
+				Identifiers: Identifiers{ISBN13: "9780156013987"}, Edition: &Edition{Pages: pages(96)}},
+			{ID: "9780451524935", Title: "1984", Authors: []Author{{Name: "George Orwell"}},
+				Identifiers: Identifiers{ISBN13: "9780451524935"}},
+		},
+		Collections: []Collection{
+			{ID: "read", Name: "Read", Type: "read", IsPublic: true},
+		},
+		Entries: []Entry{
+			{BookID: "9780156013987", CollectionIDs: []string{"read"},
+				UserData: UserData{Status: "read", Rating: 5, AddedAt: &addedAt, Tags: []string{"classic"}}},
+		},
+	}
+}
+
+func TestWriteDocumentMatchesToJSON(t *testing.T) {
+	docs := map[string]*BLEFDocument{
+		"full":  newStreamTestDocument(),
+		"empty": NewDocument(),
+	}
+
+	for name, doc := range docs {
+		expected, err := doc.ToJSON()
+		if err != nil {
+			t.Fatalf("%s: ToJSON failed: %v", name, err)
+		}
+
+		var buf bytes.Buffer
+		if err := WriteDocument(&buf, doc); err != nil {
+			t.Fatalf("%s: WriteDocument failed: %v", name, err)
+		}
+
+		if buf.String() != string(expected) {
+			t.Errorf("%s: streamed output differs from ToJSON:\n%s\nwant:\n%s", name, buf.String(), expected)
+		}
+	}
+}
+
+func TestStreamWriterOrder(t *testing.T) {
+	var buf bytes.Buffer
+	sw := NewStreamWriter(&buf, NewDocument())
+
+	if err := sw.WriteCollection(&Collection{ID: "read", Name: "Read", Type: "read"}); err != nil {
+		t.Fatalf("WriteCollection failed: %v", err)
+	}
+	if err := sw.WriteBook(&Book{ID: "9780156013987"}); err == nil {
+		t.Error("WriteBook after WriteCollection should fail")
+	}
+	if err := sw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	doc, err := FromJSON(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Streamed output is not valid BLEF JSON: %v", err)
+	}
+	if len(doc.Books) != 0 || len(doc.Collections) != 1 || len(doc.Entries) != 0 {
+		t.Errorf("Unexpected content: %d books, %d collections, %d entries",
+			len(doc.Books), len(doc.Collections), len(doc.Entries))
+	}
+}
+
+func TestStreamReader(t *testing.T) {
+	data, err := newStreamTestDocument().ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader := NewStreamReader(bytes.NewReader(data))
+	var kinds []ElementKind
+	var bookOffset int64 = -1
+	for {
+		elem, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next failed: %v", err)
+		}
+		kinds = append(kinds, elem.Kind)
+		if elem.Kind == KindBook && elem.Index == 1 {
+			bookOffset = elem.Offset
+		}
+	}
+
+	expected := []ElementKind{KindBook, KindBook, KindCollection, KindEntry}
+	if len(kinds) != len(expected) {
+		t.Fatalf("Expected %d elements, got %d", len(expected), len(kinds))
+	}
+	for i := range expected {
+		if kinds[i] != expected[i] {
+			t.Errorf("Element %d: expected %s, got %s", i, expected[i], kinds[i])
+		}
+	}
+
+	header := reader.Header()
+	if header.Format != "BLEF" || header.User == nil || header.User.Name != "Test User" {
+		t.Errorf("Unexpected header: %+v", header)
+	}
+
+	book, err := ReadBookAt(bytes.NewReader(data), bookOffset)
+	if err != nil {
+		t.Fatalf("ReadBookAt failed: %v", err)
+	}
+	if book.Title != "1984" {
+		t.Errorf("ReadBookAt returned %q, want 1984", book.Title)
+	}
+}
+
+func TestValidateStream(t *testing.T) {
+	// Entries before books and collections must still resolve
+	input := `{
+  "entries": [
+    {"book_id": "9780156013987", "collection_ids": ["read", "missing"], "user_data": {"status": "read"}}
+  ],
+  "books": [
+    {"id": "9780156013987", "title": "The Little Prince", "authors": [{"name": "Antoine de Saint-Exupéry"}], "identifiers": {}}
+  ],
+  "collections": [{"id": "read", "name": "Read", "type": "read"}],
+  "format": "BLEF",
+  "version": "0.1.0",
+  "exported_at": "2025-10-26T14:30:00Z"
+}`
+
+	header, errors, err := ValidateStream(strings.NewReader(input), nil)
+	if err != nil {
+		t.Fatalf("ValidateStream failed: %v", err)
+	}
+	if header.Version != "0.1.0" {
+		t.Errorf("Expected version 0.1.0, got %q", header.Version)
+	}
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 error for the missing collection, got %v", errors)
+	}
+	if !strings.Contains(errors[0].Error(), "entries[0].collection_ids[1]") {
+		t.Errorf("Unexpected error: %v", errors[0])
+	}
+}
@@ -0,0 +1,436 @@
+package blef
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ConflictMarkersKey is the metadata key under which ThreeWayMerge records
+// the conflicts of an element when ConflictMarkers is set
+const ConflictMarkersKey = "merge_conflicts"
+
+// ThreeWayOptions configures ThreeWayMerge
+type ThreeWayOptions struct {
+	// ConflictMarkers records every conflict in the metadata of the element
+	// it applies to, under ConflictMarkersKey, with the base, ours and theirs
+	// values
+	ConflictMarkers bool
+}
+
+// ThreeWayConflict is a change made differently on both sides. The merged
+// document keeps our side. Field is empty when a whole element was deleted
+// on one side and modified on the other. ID is empty for the user block
+// and the root of the document.
+type ThreeWayConflict struct {
+	Kind   ElementKind
+	ID     string
+	Field  string
+	Base   interface{}
+	Ours   interface{}
+	Theirs interface{}
+	Reason string
+}
+
+func (c ThreeWayConflict) String() string {
+	subject := c.Kind.Singular()
+	if c.ID != "" {
+		subject += " " + c.ID
+	}
+	if c.Field == "" {
+		return fmt.Sprintf("%s: %s", subject, c.Reason)
+	}
+	return fmt.Sprintf("%s %s: base %s, ours %s, theirs %s", subject, c.Field,
+		formatValue(c.Base), formatValue(c.Ours), formatValue(c.Theirs))
+}
+
+// ThreeWayResult is the outcome of ThreeWayMerge
+type ThreeWayResult struct {
+	Document  *BLEFDocument
+	Conflicts []ThreeWayConflict
+
+	// Merged counts the changes taken from theirs: fields, set items and
+	// whole elements added or deleted
+	Merged int
+}
+
+// threeWayElements describes how to find the elements of each array
+var threeWayElements = []struct {
+	kind  ElementKind
+	key   string // array in the document
+	idKey string // field identifying an element
+}{
+	{KindBook, "books", "id"},
+	{KindCollection, "collections", "id"},
+	{KindEntry, "entries", "book_id"},
+}
+
+// threeWayRootKeys are the root members merged on their own. The others
+// are unknown to this version and merged field by field.
+var threeWayRootKeys = map[string]bool{
+	"format": true, "version": true, "exported_at": true,
+	"user": true, "books": true, "collections": true, "entries": true,
+}
+
+// threeWayNested are the object fields merged key by key
+var threeWayNested = map[string]bool{"user_data": true, "metadata": true, "ownership": true}
+
+// threeWaySets are the string lists merged as sets: additions and removals
+// from both sides are combined
+var threeWaySets = map[string]bool{"tags": true, "collection_ids": true, "subjects": true}
+
+// ThreeWayMerge merges ours and theirs, two documents derived from base.
+// Elements are matched by ID and merged field by field, like the user block
+// and the root members unknown to this version: a change made on a single
+// side is kept, and so is a deletion. Tags,
+// collection IDs and subjects are merged as sets. Changes made differently on
+// both sides are conflicts: the merged document keeps our value and every
+// conflict is returned.
+func ThreeWayMerge(base, ours, theirs *BLEFDocument, opts ThreeWayOptions) (*ThreeWayResult, error) {
+	if base.Version != ours.Version || base.Version != theirs.Version {
+		return nil, fmt.Errorf("documents have different versions (%s, %s and %s), migrate them first",
+			base.Version, ours.Version, theirs.Version)
+	}
+
+	var docs [3]map[string]interface{}
+	for i, doc := range []*BLEFDocument{base, ours, theirs} {
+		fields, err := toJSONMap(doc)
+		if err != nil {
+			return nil, err
+		}
+		docs[i] = fields
+	}
+
+	m := &threeWayMerger{opts: opts, result: &ThreeWayResult{}}
+	merged := docs[1]
+	var users [3]map[string]interface{}
+	for i := range docs {
+		users[i], _ = docs[i]["user"].(map[string]interface{})
+	}
+	if user := m.mergeElement(KindUser, "", users[0], users[1], users[2]); user != nil {
+		merged["user"] = user
+	} else {
+		delete(merged, "user")
+	}
+
+	var roots [3]map[string]interface{}
+	for i := range docs {
+		roots[i] = make(map[string]interface{})
+		for key, value := range docs[i] {
+			if !threeWayRootKeys[key] {
+				roots[i][key] = value
+			}
+		}
+	}
+	for key := range roots[1] {
+		delete(merged, key)
+	}
+	// The root has no metadata to hold conflict markers
+	m.markers = nil
+	for key, value := range m.mergeObject(KindDocument, "", "", roots[0], roots[1], roots[2]) {
+		merged[key] = value
+	}
+	m.markers = nil
+
+	arrays := make(map[string][]map[string]interface{})
+	for _, spec := range threeWayElements {
+		var sides [3][]map[string]interface{}
+		for i := range docs {
+			sides[i] = objects(docs[i][spec.key])
+		}
+		arrays[spec.key] = m.mergeElements(spec.kind, spec.idKey, sides)
+	}
+	m.restoreReferences(arrays, docs)
+
+	for key, elements := range arrays {
+		list := make([]interface{}, len(elements))
+		for i, element := range elements {
+			list[i] = element
+		}
+		merged[key] = list
+	}
+
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+	var doc BLEFDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to build merged document: %w", err)
+	}
+	doc.ExportedAt = time.Now().UTC()
+
+	m.result.Document = &doc
+	return m.result, nil
+}
+
+type threeWayMerger struct {
+	opts   ThreeWayOptions
+	result *ThreeWayResult
+
+	// markers collects the conflicts of the element being merged
+	markers map[string]interface{}
+}
+
+// objects converts a JSON array to its objects
+func objects(v interface{}) []map[string]interface{} {
+	list, _ := v.([]interface{})
+	result := make([]map[string]interface{}, 0, len(list))
+	for _, item := range list {
+		if object, ok := item.(map[string]interface{}); ok {
+			result = append(result, object)
+		}
+	}
+	return result
+}
+
+// index maps element IDs to elements, keeping the first of duplicates
+func index(elements []map[string]interface{}, idKey string) map[string]map[string]interface{} {
+	byID := make(map[string]map[string]interface{}, len(elements))
+	for _, element := range elements {
+		id, _ := element[idKey].(string)
+		if _, exists := byID[id]; !exists {
+			byID[id] = element
+		}
+	}
+	return byID
+}
+
+// mergeElements merges the elements of one array, in our order followed by
+// the elements only theirs added
+func (m *threeWayMerger) mergeElements(kind ElementKind, idKey string, sides [3][]map[string]interface{}) []map[string]interface{} {
+	base, ours, theirs := index(sides[0], idKey), index(sides[1], idKey), index(sides[2], idKey)
+
+	var merged []map[string]interface{}
+	seen := make(map[string]bool)
+	for _, list := range [][]map[string]interface{}{sides[1], sides[2]} {
+		for _, element := range list {
+			id, _ := element[idKey].(string)
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			if result := m.mergeElement(kind, id, base[id], ours[id], theirs[id]); result != nil {
+				merged = append(merged, result)
+			}
+		}
+	}
+	return merged
+}
+
+// mergeElement merges one element, any of the three versions being nil when
+// the element does not exist on that side. It returns nil when the element
+// is deleted.
+func (m *threeWayMerger) mergeElement(kind ElementKind, id string, base, ours, theirs map[string]interface{}) map[string]interface{} {
+	switch {
+	case ours == nil && theirs == nil:
+		return nil
+	case base != nil && theirs == nil:
+		if reflect.DeepEqual(base, ours) {
+			m.result.Merged++
+			return nil
+		}
+		m.elementConflict(kind, id, base, ours, nil, "modified in ours, deleted in theirs")
+		return ours
+	case base != nil && ours == nil:
+		if reflect.DeepEqual(base, theirs) {
+			return nil
+		}
+		m.elementConflict(kind, id, base, nil, theirs, "deleted in ours, modified in theirs")
+		return nil
+	case theirs == nil:
+		return ours
+	case ours == nil:
+		m.result.Merged++
+		return theirs
+	}
+
+	m.markers = nil
+	merged := m.mergeObject(kind, id, "", base, ours, theirs)
+	if m.markers != nil {
+		metadata, _ := merged["metadata"].(map[string]interface{})
+		if metadata == nil {
+			metadata = make(map[string]interface{})
+			merged["metadata"] = metadata
+		}
+		metadata[ConflictMarkersKey] = m.markers
+	}
+	return merged
+}
+
+func (m *threeWayMerger) elementConflict(kind ElementKind, id string, base, ours, theirs map[string]interface{}, reason string) {
+	conflict := ThreeWayConflict{Kind: kind, ID: id, Reason: reason}
+	// Keep typed nils out of the interface fields
+	if base != nil {
+		conflict.Base = base
+	}
+	if ours != nil {
+		conflict.Ours = ours
+	}
+	if theirs != nil {
+		conflict.Theirs = theirs
+	}
+	m.result.Conflicts = append(m.result.Conflicts, conflict)
+}
+
+// mergeObject merges the fields of three versions of an object
+func (m *threeWayMerger) mergeObject(kind ElementKind, id, prefix string, base, ours, theirs map[string]interface{}) map[string]interface{} {
+	keys := make(map[string]bool)
+	for _, object := range []map[string]interface{}{base, ours, theirs} {
+		for key := range object {
+			keys[key] = true
+		}
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	merged := make(map[string]interface{}, len(keys))
+	for _, key := range sorted {
+		// Markers from a previous merge are not merged
+		if prefix == "metadata." && key == ConflictMarkersKey {
+			continue
+		}
+		if value := m.mergeValue(kind, id, prefix+key, base[key], ours[key], theirs[key], threeWaySets); value != nil {
+			merged[key] = value
+		}
+	}
+	return merged
+}
+
+// mergeValue merges one field. Nested objects are merged key by key and
+// string lists named in sets are merged as sets.
+func (m *threeWayMerger) mergeValue(kind ElementKind, id, field string, base, ours, theirs interface{}, sets map[string]bool) interface{} {
+	switch {
+	case reflect.DeepEqual(ours, theirs):
+		return ours
+	case reflect.DeepEqual(base, ours):
+		m.result.Merged++
+		return theirs
+	case reflect.DeepEqual(base, theirs):
+		return ours
+	}
+
+	name := field[strings.LastIndex(field, ".")+1:]
+	if threeWayNested[field] {
+		baseObject, _ := base.(map[string]interface{})
+		oursObject, okOurs := ours.(map[string]interface{})
+		theirsObject, okTheirs := theirs.(map[string]interface{})
+		if (okOurs || ours == nil) && (okTheirs || theirs == nil) {
+			merged := m.mergeObject(kind, id, field+".", baseObject, oursObject, theirsObject)
+			if len(merged) == 0 {
+				return nil
+			}
+			return merged
+		}
+	}
+	if sets[name] {
+		if merged, ok := mergeStringSets(base, ours, theirs); ok {
+			if !reflect.DeepEqual(merged, ours) {
+				m.result.Merged++
+			}
+			return merged
+		}
+	}
+
+	m.result.Conflicts = append(m.result.Conflicts, ThreeWayConflict{
+		Kind: kind, ID: id, Field: field, Base: base, Ours: ours, Theirs: theirs,
+	})
+	if m.opts.ConflictMarkers {
+		if m.markers == nil {
+			m.markers = make(map[string]interface{})
+		}
+		m.markers[field] = map[string]interface{}{"base": base, "ours": ours, "theirs": theirs}
+	}
+	return ours
+}
+
+// mergeStringSets applies the additions and removals of theirs to ours.
+// ok is false if a value is not a list of strings.
+func mergeStringSets(base, ours, theirs interface{}) ([]interface{}, bool) {
+	var lists [3][]string
+	for i, value := range []interface{}{base, ours, theirs} {
+		list, ok := value.([]interface{})
+		if !ok && value != nil {
+			return nil, false
+		}
+		for _, item := range list {
+			s, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			lists[i] = append(lists[i], s)
+		}
+	}
+
+	var merged []interface{}
+	for _, s := range lists[1] {
+		removed := containsString(lists[0], s) && !containsString(lists[2], s)
+		if !removed {
+			merged = append(merged, s)
+		}
+	}
+	for _, s := range lists[2] {
+		if !containsString(lists[0], s) && !containsString(lists[1], s) {
+			merged = append(merged, s)
+		}
+	}
+	if merged == nil {
+		merged = []interface{}{}
+	}
+	return merged, true
+}
+
+// restoreReferences brings back the books and collections deleted on one
+// side but still referenced by an entry kept from the other side, and
+// records a conflict for each
+func (m *threeWayMerger) restoreReferences(arrays map[string][]map[string]interface{}, docs [3]map[string]interface{}) {
+	for _, ref := range []struct {
+		kind ElementKind
+		key  string
+	}{
+		{KindBook, "books"},
+		{KindCollection, "collections"},
+	} {
+		present := index(arrays[ref.key], "id")
+		for _, entry := range arrays["entries"] {
+			var ids []string
+			if ref.kind == KindBook {
+				id, _ := entry["book_id"].(string)
+				ids = []string{id}
+			} else {
+				list, _ := entry["collection_ids"].([]interface{})
+				for _, item := range list {
+					if id, ok := item.(string); ok {
+						ids = append(ids, id)
+					}
+				}
+			}
+
+			for _, id := range ids {
+				if present[id] != nil {
+					continue
+				}
+				// Prefer our version, then theirs, then the base
+				for _, i := range []int{1, 2, 0} {
+					if element := index(objects(docs[i][ref.key]), "id")[id]; element != nil {
+						arrays[ref.key] = append(arrays[ref.key], element)
+						present[id] = element
+						break
+					}
+				}
+				if present[id] != nil {
+					m.result.Conflicts = append(m.result.Conflicts, ThreeWayConflict{
+						Kind: ref.kind, ID: id, Reason: "deleted on one side but still used by an entry, kept",
+					})
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package blef
+
+import "testing"
+
+func threeWayBase() *BLEFDocument {
+	doc, _ := diffTestDocuments()
+	doc.Entries[0].UserData.Tags = []string{"classic"}
+	return doc
+}
+
+func TestThreeWayMergeNonOverlapping(t *testing.T) {
+	base := threeWayBase()
+	ours, _ := cloneDocument(base)
+	theirs, _ := cloneDocument(base)
+
+	// Ours: finish The Little Prince, tag it, delete 1984
+	ours.Entries[0].UserData.Status = "read"
+	ours.Entries[0].UserData.Tags = append(ours.Entries[0].UserData.Tags, "french")
+	ours.Books = ours.Books[:1]
+	ours.Entries = ours.Entries[:1]
+
+	// Theirs: rate it, tag it, add The Hobbit
+	theirs.Entries[0].UserData.Rating = 5
+	theirs.Entries[0].UserData.Tags = []string{"favorite"}
+	theirs.Books = append(theirs.Books, Book{ID: "9780547928227", Title: "The Hobbit", Authors: []Author{{Name: "J.R.R. Tolkien"}}})
+	theirs.Entries = append(theirs.Entries, Entry{BookID: "9780547928227", CollectionIDs: []string{"to-read"}, UserData: UserData{Status: "to-read"}})
+
+	result, err := ThreeWayMerge(base, ours, theirs, ThreeWayOptions{})
+	if err != nil {
+		t.Fatalf("ThreeWayMerge failed: %v", err)
+	}
+	if len(result.Conflicts) > 0 {
+		t.Fatalf("Expected no conflicts, got %v", result.Conflicts)
+	}
+	doc := result.Document
+	if errors := ValidateDocument(doc); len(errors) > 0 {
+		t.Fatalf("Merged document is invalid: %v", errors)
+	}
+
+	if doc.GetBookByID("9780451524935") != nil || len(doc.GetEntriesForBook("9780451524935")) > 0 {
+		t.Error("Expected our deletion of 1984 to be kept")
+	}
+	if doc.GetBookByID("9780547928227") == nil || len(doc.GetEntriesForBook("9780547928227")) != 1 {
+		t.Error("Expected their addition of The Hobbit to be kept")
+	}
+	data := doc.GetEntriesForBook("9780156013987")[0].UserData
+	if data.Status != "read" || data.Rating != 5 {
+		t.Errorf("Expected status from ours and rating from theirs, got %s and %g", data.Status, data.Rating)
+	}
+	// "classic" was removed by theirs, "french" added by ours, "favorite" by theirs
+	if len(data.Tags) != 2 || data.Tags[0] != "french" || data.Tags[1] != "favorite" {
+		t.Errorf("Expected tags to be merged as sets, got %v", data.Tags)
+	}
+	if result.Merged == 0 {
+		t.Error("Expected changes from theirs to be counted")
+	}
+}
+
+func TestThreeWayMergeConflicts(t *testing.T) {
+	base := threeWayBase()
+	ours, _ := cloneDocument(base)
+	theirs, _ := cloneDocument(base)
+
+	ours.Entries[0].UserData.Status = "read"
+	theirs.Entries[0].UserData.Status = "abandoned"
+	ours.Books[1].Title = "Nineteen Eighty-Four"
+	theirs.Books = theirs.Books[:1]
+	theirs.Entries = theirs.Entries[:1]
+
+	result, err := ThreeWayMerge(base, ours, theirs, ThreeWayOptions{ConflictMarkers: true})
+	if err != nil {
+		t.Fatalf("ThreeWayMerge failed: %v", err)
+	}
+
+	var fieldConflict, deleteConflict bool
+	for _, conflict := range result.Conflicts {
+		switch {
+		case conflict.Kind == KindEntry && conflict.Field == "user_data.status":
+			fieldConflict = conflict.Base == "to-read" && conflict.Ours == "read" && conflict.Theirs == "abandoned"
+		case conflict.Kind == KindBook && conflict.ID == "9780451524935" && conflict.Field == "":
+			deleteConflict = true
+		}
+	}
+	if !fieldConflict || !deleteConflict {
+		t.Fatalf("Expected a status conflict and a modify/delete conflict, got %v", result.Conflicts)
+	}
+
+	doc := result.Document
+	entry := doc.GetEntriesForBook("9780156013987")[0]
+	if entry.UserData.Status != "read" {
+		t.Errorf("Expected our status to be kept, got %s", entry.UserData.Status)
+	}
+	markers, ok := entry.Metadata[ConflictMarkersKey].(map[string]interface{})
+	if !ok || markers["user_data.status"] == nil {
+		t.Errorf("Expected conflict markers in entry metadata, got %v", entry.Metadata)
+	}
+	if book := doc.GetBookByID("9780451524935"); book == nil || book.Title != "Nineteen Eighty-Four" {
+		t.Errorf("Expected the book modified in ours to be kept, got %+v", book)
+	}
+	if errors := ValidateDocument(doc); len(errors) > 0 {
+		t.Errorf("Merged document is invalid: %v", errors)
+	}
+}
+
+func TestThreeWayMergeUser(t *testing.T) {
+	base := threeWayBase()
+	base.User = &User{ID: "reader", Name: "Base", Metadata: map[string]interface{}{"theme": "light"}}
+	ours, _ := cloneDocument(base)
+	theirs, _ := cloneDocument(base)
+
+	ours.User.Name = "Ours"
+	theirs.User.Name = "Theirs"
+	theirs.User.Email = "reader@example.com"
+	theirs.User.Metadata["theme"] = "dark"
+
+	result, err := ThreeWayMerge(base, ours, theirs, ThreeWayOptions{ConflictMarkers: true})
+	if err != nil {
+		t.Fatalf("ThreeWayMerge failed: %v", err)
+	}
+	if len(result.Conflicts) != 1 || result.Conflicts[0].Kind != KindUser || result.Conflicts[0].Field != "name" {
+		t.Fatalf("Expected a conflict on the user name, got %v", result.Conflicts)
+	}
+
+	user := result.Document.User
+	if user.Name != "Ours" || user.Email != "reader@example.com" || user.Metadata["theme"] != "dark" {
+		t.Errorf("Expected our name and their other changes, got %+v", user)
+	}
+	if markers, ok := user.Metadata[ConflictMarkersKey].(map[string]interface{}); !ok || markers["name"] == nil {
+		t.Errorf("Expected conflict markers in user metadata, got %v", user.Metadata)
+	}
+}
+
+func TestThreeWayMergeRestoresReferences(t *testing.T) {
+	base := threeWayBase()
+	ours, _ := cloneDocument(base)
+	theirs, _ := cloneDocument(base)
+
+	// Theirs removes the to-read collection and 1984, ours files 1984 in to-read
+	theirs.Collections = theirs.Collections[1:]
+	theirs.Entries[0].CollectionIDs = []string{"read"}
+	theirs.Books = theirs.Books[:1]
+	theirs.Entries = theirs.Entries[:1]
+	ours.Entries[1].CollectionIDs = []string{"read", "to-read"}
+
+	result, err := ThreeWayMerge(base, ours, theirs, ThreeWayOptions{})
+	if err != nil {
+		t.Fatalf("ThreeWayMerge failed: %v", err)
+	}
+	if errors := ValidateDocument(result.Document); len(errors) > 0 {
+		t.Errorf("Merged document has dangling references: %v", errors)
+	}
+	if result.Document.GetCollectionByID("to-read") == nil || result.Document.GetBookByID("9780451524935") == nil {
+		t.Errorf("Expected the referenced book and collection to be restored")
+	}
+	if len(result.Conflicts) != 3 {
+		t.Errorf("Expected a modify/delete conflict and two restorations, got %v", result.Conflicts)
+	}
+}
+
+func TestThreeWayMergeUnknownRootMembers(t *testing.T) {
+	base := threeWayBase()
+	base.Extensions = Extensions{{Name: "x_shared", Value: []byte(`"base"`)}, {Name: "x_removed", Value: []byte(`true`)}}
+	ours, _ := cloneDocument(base)
+	theirs, _ := cloneDocument(base)
+
+	ours.Extensions = Extensions{{Name: "x_shared", Value: []byte(`"ours"`)}, {Name: "x_removed", Value: []byte(`true`)}}
+	theirs.Extensions = Extensions{{Name: "x_shared", Value: []byte(`"theirs"`)}, {Name: "x_theirs", Value: []byte(`1`)}}
+
+	result, err := ThreeWayMerge(base, ours, theirs, ThreeWayOptions{ConflictMarkers: true})
+	if err != nil {
+		t.Fatalf("ThreeWayMerge failed: %v", err)
+	}
+	if len(result.Conflicts) != 1 || result.Conflicts[0].Kind != KindDocument || result.Conflicts[0].Field != "x_shared" {
+		t.Fatalf("Expected a conflict on x_shared, got %v", result.Conflicts)
+	}
+
+	extensions := result.Document.Extensions
+	if string(extensions.Get("x_theirs")) != "1" {
+		t.Errorf("Expected their new member to be kept, got %v", extensions)
+	}
+	if extensions.Get("x_removed") != nil {
+		t.Errorf("Expected their deletion to be kept, got %v", extensions)
+	}
+	if string(extensions.Get("x_shared")) != `"ours"` {
+		t.Errorf("Expected our value on conflict, got %v", extensions)
+	}
+	if result.Merged != 2 {
+		t.Errorf("Expected 2 changes merged from theirs, got %d", result.Merged)
+	}
+}
@@ -11,6 +11,8 @@ type BLEFDocument struct {
 	Books       []Book       `json:"books"`
 	Collections []Collection `json:"collections"`
 	Entries     []Entry      `json:"entries"`
+
+	Extensions Extensions `json:"-"` // members unknown to this version
 }
 
 // User represents optional user information
@@ -19,6 +21,8 @@ type User struct {
 	Name     string                 `json:"name,omitempty"`
 	Email    string                 `json:"email,omitempty"`
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
+
+	Extensions Extensions `json:"-"` // members unknown to this version
 }
 
 // Book represents a unique bibliographic work
@@ -35,6 +39,8 @@ type Book struct {
 	Series      *Series                `json:"series,omitempty"`
 	Subjects    []string               `json:"subjects,omitempty"`
 	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+
+	Extensions Extensions `json:"-"` // members unknown to this version
 }
 
 // Author represents a book author
@@ -42,6 +48,8 @@ type Author struct {
 	Name        string            `json:"name"`
 	Role        string            `json:"role,omitempty"`
 	Identifiers map[string]string `json:"identifiers,omitempty"`
+
+	Extensions Extensions `json:"-"` // members unknown to this version
 }
 
 // Identifiers holds various book identifiers
@@ -53,21 +61,36 @@ type Identifiers struct {
 	Wikidata    string                 `json:"wikidata,omitempty"`
 	Goodreads   string                 `json:"goodreads,omitempty"`
 	Other       map[string]interface{} `json:"other,omitempty"`
+
+	Extensions Extensions `json:"-"` // members unknown to this version
 }
 
 // Edition represents edition information
 type Edition struct {
-	Publisher     string `json:"publisher,omitempty"`
-	PublishedDate string `json:"published_date,omitempty"`
-	Format        string `json:"format,omitempty"`
-	Pages         int    `json:"pages,omitempty"`
-	EditionNumber string `json:"edition_number,omitempty"`
+	Publisher     string      `json:"publisher,omitempty"`
+	PublishedDate PartialDate `json:"published_date,omitzero"`
+	Format        string      `json:"format,omitempty"`
+	Pages         *int        `json:"pages,omitempty"` // nil when unknown
+	EditionNumber string      `json:"edition_number,omitempty"`
+
+	Extensions Extensions `json:"-"` // members unknown to this version
+}
+
+// PageCount returns the number of pages of the edition, 0 if unknown. It
+// may be called on a nil edition.
+func (e *Edition) PageCount() int {
+	if e == nil || e.Pages == nil {
+		return 0
+	}
+	return *e.Pages
 }
 
 // Series represents book series information
 type Series struct {
 	Name   string      `json:"name"`
 	Volume interface{} `json:"volume,omitempty"` // can be number or string
+
+	Extensions Extensions `json:"-"` // members unknown to this version
 }
 
 // Collection represents a user's shelf/list
@@ -76,9 +99,11 @@ type Collection struct {
 	Name        string                 `json:"name"`
 	Type        string                 `json:"type"`
 	Description string                 `json:"description,omitempty"`
-	IsPublic    bool                   `json:"is_public"`
+	IsPublic    bool                   `json:"is_public"` // true when missing from the JSON
 	CreatedAt   *time.Time             `json:"created_at,omitempty"`
 	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+
+	Extensions Extensions `json:"-"` // members unknown to this version
 }
 
 // Entry links a book to user-specific data
@@ -88,6 +113,8 @@ type Entry struct {
 	UserData      UserData               `json:"user_data"`
 	Ownership     *Ownership             `json:"ownership,omitempty"`
 	Metadata      map[string]interface{} `json:"metadata,omitempty"`
+
+	Extensions Extensions `json:"-"` // members unknown to this version
 }
 
 // UserData contains user-specific book information
@@ -100,25 +127,35 @@ type UserData struct {
 	Favorite     bool       `json:"favorite,omitempty"`
 	ReadDates    []ReadDate `json:"read_dates,omitempty"`
 	AddedAt      *time.Time `json:"added_at,omitempty"`
+
+	Extensions Extensions `json:"-"` // members unknown to this version
 }
 
-// ReadDate represents reading history
+// ReadDate represents reading history. Started and Finished must be known to
+// the day (YYYY-MM-DD): validation reports any other precision as BLEF-W010,
+// though such dates are still read.
 type ReadDate struct {
-	Started  string `json:"started,omitempty"`
-	Finished string `json:"finished,omitempty"`
-	Progress int    `json:"progress,omitempty"`
+	Started  PartialDate `json:"started,omitzero"`
+	Finished PartialDate `json:"finished,omitzero"`
+	Progress int         `json:"progress,omitempty"`
+
+	Extensions Extensions `json:"-"` // members unknown to this version
 }
 
 // Ownership represents book ownership and lending
 type Ownership struct {
 	Owned  bool    `json:"owned,omitempty"`
 	Loaned *Loaned `json:"loaned,omitempty"`
+
+	Extensions Extensions `json:"-"` // members unknown to this version
 }
 
 // Loaned represents lending information
 type Loaned struct {
-	Status bool   `json:"status"`
-	To     string `json:"to,omitempty"`
-	Date   string `json:"date,omitempty"`
-	Notes  string `json:"notes,omitempty"`
+	Status bool        `json:"status"`
+	To     string      `json:"to,omitempty"`
+	Date   PartialDate `json:"date,omitzero"` // known to the day, as read dates
+	Notes  string      `json:"notes,omitempty"`
+
+	Extensions Extensions `json:"-"` // members unknown to this version
 }
@@ -1,27 +1,52 @@
 package blef
 
 import (
-	"embed"
 	"fmt"
+	"io"
 	"regexp"
-	"strconv"
 	"strings"
 
-	"github.com/xeipuuv/gojsonschema"
+	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/blef/jsonschema"
+	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/isbn"
 )
 
-//go:embed schema.json
-var schemaFS embed.FS
-
 var (
 	isbn13Regex = regexp.MustCompile(`^97[89]\d{10}$`)
 	uuidV4Regex = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
 )
 
-// ValidationError represents a validation error
+// Enumerated values from the specification
+var (
+	validStatuses = map[string]bool{
+		"read": true, "reading": true, "to-read": true,
+		"abandoned": true, "wishlist": true,
+	}
+	validCollectionTypes = map[string]bool{
+		"read": true, "reading": true, "to-read": true,
+		"wishlist": true, "owned": true, "custom": true,
+	}
+	validAuthorRoles = map[string]bool{
+		"author": true, "editor": true, "translator": true,
+		"illustrator": true, "contributor": true,
+	}
+	validEditionFormats = map[string]bool{
+		"hardcover": true, "paperback": true, "ebook": true,
+		"audiobook": true, "other": true,
+	}
+)
+
+// ValidationError is a single validation finding. Field is the legacy dotted
+// path (books[0].id), Pointer the RFC 6901 JSON pointer of the same location.
 type ValidationError struct {
-	Field   string
-	Message string
+	Code     string
+	Severity Severity
+	Pointer  string
+	Field    string
+	Message  string
+	Value    interface{}
+
+	// Fix is the automatic repair proposed for this finding, if any
+	Fix *Fix
 }
 
 func (e ValidationError) Error() string {
@@ -31,98 +56,222 @@ func (e ValidationError) Error() string {
 	return e.Message
 }
 
+// newValidationError builds a finding for a catalog rule at pointer
+func newValidationError(code, pointer string, value interface{}, message string) ValidationError {
+	return ValidationError{
+		Code:     code,
+		Severity: LookupRule(code).Severity,
+		Pointer:  pointer,
+		Field:    pointerToField(pointer),
+		Message:  message,
+		Value:    value,
+	}
+}
+
+// withFix attaches a fix proposal to a finding. A nil fix is ignored.
+func (e ValidationError) withFix(fix *Fix) ValidationError {
+	e.Fix = fix
+	return e
+}
+
 // ValidateDocument performs comprehensive validation on a BLEF document
 func ValidateDocument(doc *BLEFDocument) []error {
 	var errors []error
 
 	// Basic field validation
+	errors = append(errors, validateHeader(doc)...)
+
+	if len(doc.Collections) == 0 {
+		errors = append(errors, newValidationError(RuleCollectionsEmpty, "/collections", nil, "must contain at least one collection"))
+	}
+
+	// Validate book IDs
+	bookIDs := make(map[string]bool)
+	for i := range doc.Books {
+		errors = append(errors, validateBook(i, &doc.Books[i], bookIDs)...)
+	}
+
+	// Validate collection IDs
+	collectionIDs := make(map[string]bool)
+	for i := range doc.Collections {
+		errors = append(errors, validateCollection(i, &doc.Collections[i], collectionIDs)...)
+	}
+
+	// Validate referential integrity
+	refErrors := CheckReferentialIntegrity(doc)
+	errors = append(errors, refErrors...)
+
+	return errors
+}
+
+// ValidateDocumentReport runs ValidateDocument and collects its findings in a report
+func ValidateDocumentReport(doc *BLEFDocument) *ValidationReport {
+	report := NewValidationReport()
+	report.Add(ValidateDocument(doc)...)
+	return report
+}
+
+// validateHeader checks the root fields of a document
+func validateHeader(doc *BLEFDocument) []error {
+	var errors []error
+
 	if doc.Format != "BLEF" {
-		errors = append(errors, ValidationError{Field: "format", Message: "must be 'BLEF'"})
+		errors = append(errors, newValidationError(RuleFormat, "/format", doc.Format, "must be 'BLEF'"))
 	}
 
 	if doc.Version == "" {
-		errors = append(errors, ValidationError{Field: "version", Message: "is required"})
+		errors = append(errors, newValidationError(RuleVersionRequired, "/version", nil, "is required"))
 	}
 
-	if len(doc.Collections) == 0 {
-		errors = append(errors, ValidationError{Field: "collections", Message: "must contain at least one collection"})
+	return errors
+}
+
+// validateBook checks a single book and records its ID in seen
+func validateBook(i int, book *Book, seen map[string]bool) []error {
+	var errors []error
+
+	// Check for duplicate IDs
+	if seen[book.ID] {
+		errors = append(errors, newValidationError(RuleDuplicateBookID, jsonPointer("books", i, "id"), book.ID,
+			fmt.Sprintf("duplicate book ID: %s", book.ID)))
 	}
+	seen[book.ID] = true
 
-	// Validate book IDs
-	bookIDs := make(map[string]bool)
-	for i, book := range doc.Books {
-		// Check for duplicate IDs
-		if bookIDs[book.ID] {
-			errors = append(errors, ValidationError{
-				Field:   fmt.Sprintf("books[%d].id", i),
-				Message: fmt.Sprintf("duplicate book ID: %s", book.ID),
-			})
-		}
-		bookIDs[book.ID] = true
+	// Validate ID format
+	if !isbn13Regex.MatchString(book.ID) && !uuidV4Regex.MatchString(book.ID) {
+		errors = append(errors, newValidationError(RuleBookIDFormat, jsonPointer("books", i, "id"), book.ID,
+			"must be valid ISBN-13 or UUID v4").withFix(bookIDFix(i, book.ID)))
+	}
 
-		// Validate ID format
-		if !isbn13Regex.MatchString(book.ID) && !uuidV4Regex.MatchString(book.ID) {
-			errors = append(errors, ValidationError{
-				Field:   fmt.Sprintf("books[%d].id", i),
-				Message: "must be valid ISBN-13 or UUID v4",
-			})
-		}
-
-		// Validate ISBN-13 check digit if applicable
-		if isbn13Regex.MatchString(book.ID) {
-			if !validateISBN13(book.ID) {
-				errors = append(errors, ValidationError{
-					Field:   fmt.Sprintf("books[%d].id", i),
-					Message: "invalid ISBN-13 check digit",
-				})
-			}
+	// Validate ISBN-13 check digit if applicable
+	if isbn13Regex.MatchString(book.ID) {
+		if !validateISBN13(book.ID) {
+			errors = append(errors, newValidationError(RuleISBN13CheckDigit, jsonPointer("books", i, "id"), book.ID,
+				"invalid ISBN-13 check digit"))
 		}
+	}
 
-		// Validate required fields
-		if book.Title == "" {
-			errors = append(errors, ValidationError{
-				Field:   fmt.Sprintf("books[%d].title", i),
-				Message: "is required",
-			})
-		}
+	// Validate required fields
+	if book.Title == "" {
+		errors = append(errors, newValidationError(RuleBookTitleRequired, jsonPointer("books", i, "title"), nil, "is required"))
+	}
 
-		if len(book.Authors) == 0 {
-			errors = append(errors, ValidationError{
-				Field:   fmt.Sprintf("books[%d].authors", i),
-				Message: "must contain at least one author",
-			})
-		}
+	if len(book.Authors) == 0 {
+		errors = append(errors, newValidationError(RuleBookAuthorsRequired, jsonPointer("books", i, "authors"), nil,
+			"must contain at least one author"))
 	}
 
-	// Validate collection IDs
-	collectionIDs := make(map[string]bool)
-	for i, collection := range doc.Collections {
-		if collectionIDs[collection.ID] {
-			errors = append(errors, ValidationError{
-				Field:   fmt.Sprintf("collections[%d].id", i),
-				Message: fmt.Sprintf("duplicate collection ID: %s", collection.ID),
-			})
+	for j, author := range book.Authors {
+		if author.Name == "" {
+			errors = append(errors, newValidationError(RuleAuthorNameRequired, jsonPointer("books", i, "authors", j, "name"), nil, "is required"))
 		}
-		collectionIDs[collection.ID] = true
+		if author.Role != "" && !validAuthorRoles[author.Role] {
+			errors = append(errors, newValidationError(RuleAuthorRole, jsonPointer("books", i, "authors", j, "role"), author.Role,
+				fmt.Sprintf("invalid author role: %s", author.Role)))
+		}
+	}
 
-		if collection.Name == "" {
-			errors = append(errors, ValidationError{
-				Field:   fmt.Sprintf("collections[%d].name", i),
-				Message: "is required",
-			})
+	if book.Language != "" && !IsLanguageCode(book.Language) {
+		errors = append(errors, newValidationError(RuleLanguageCode, jsonPointer("books", i, "language"), book.Language,
+			fmt.Sprintf("not an ISO 639-1 language code: %s", book.Language)))
+	}
+
+	if book.CoverURL != "" && !jsonschema.IsURI(book.CoverURL) {
+		errors = append(errors, newValidationError(RuleCoverURL, jsonPointer("books", i, "cover_url"), book.CoverURL,
+			"must be an absolute URI"))
+	}
+
+	if edition := book.Edition; edition != nil {
+		if date := edition.PublishedDate; !date.IsZero() && (!date.IsValid() || date.Precision() > PrecisionDay) {
+			errors = append(errors, newValidationError(RulePublishedDate, jsonPointer("books", i, "edition", "published_date"), date.String(),
+				"must be an ISO 8601 date or year (YYYY, YYYY-MM or YYYY-MM-DD)").withFix(dateFix(date, PrecisionYear, func(doc *BLEFDocument) *PartialDate {
+				if edition := doc.Books[i].Edition; edition != nil {
+					return &edition.PublishedDate
+				}
+				return nil
+			})))
 		}
+		if edition.Format != "" && !validEditionFormats[edition.Format] {
+			errors = append(errors, newValidationError(RuleEditionFormat, jsonPointer("books", i, "edition", "format"), edition.Format,
+				fmt.Sprintf("invalid edition format: %s", edition.Format)))
+		}
+		if edition.Pages != nil && *edition.Pages < 1 {
+			errors = append(errors, newValidationError(RulePages, jsonPointer("books", i, "edition", "pages"), *edition.Pages,
+				"must be at least 1"))
+		}
+	}
 
-		if collection.Type == "" {
-			errors = append(errors, ValidationError{
-				Field:   fmt.Sprintf("collections[%d].type", i),
-				Message: "is required",
-			})
+	errors = append(errors, validateIdentifierISBN(jsonPointer("books", i, "identifiers", "isbn13"), book.Identifiers.ISBN13, 13,
+		func(doc *BLEFDocument) *string { return &doc.Books[i].Identifiers.ISBN13 })...)
+	errors = append(errors, validateIdentifierISBN(jsonPointer("books", i, "identifiers", "isbn10"), book.Identifiers.ISBN10, 10,
+		func(doc *BLEFDocument) *string { return &doc.Books[i].Identifiers.ISBN10 })...)
+
+	if book.Series != nil && book.Series.Name == "" {
+		errors = append(errors, newValidationError(RuleSeriesNameRequired, jsonPointer("books", i, "series", "name"), nil, "is required"))
+	}
+
+	return errors
+}
+
+// validateIdentifierISBN checks an ISBN of the identifiers object. When the
+// value is a valid ISBN once cleaned, a fix rewriting it is proposed.
+func validateIdentifierISBN(pointer, value string, length int, field func(doc *BLEFDocument) *string) []error {
+	if value == "" || len(value) == length && isbn.Validate(value) == nil {
+		return nil
+	}
+
+	cleaned, err := isbn.Parse(value)
+	if err != nil {
+		return []error{newValidationError(RuleIdentifierISBN, pointer, value, fmt.Sprintf("invalid ISBN-%d: %v", length, err))}
+	}
+
+	// The value is a valid ISBN in another form: hyphenated or of the other length
+	finding := newValidationError(RuleIdentifierISBN, pointer, value, fmt.Sprintf("not a normalized ISBN-%d: %s", length, value))
+	if len(cleaned) != length {
+		if length == 13 {
+			cleaned, err = isbn.To13(cleaned)
+		} else {
+			cleaned, err = isbn.To10(cleaned)
 		}
 	}
+	if err == nil {
+		finding = finding.withFix(&Fix{
+			Description: fmt.Sprintf("normalized ISBN-%d %q to %s", length, value, cleaned),
+			removes:     -1,
+			apply: func(doc *BLEFDocument) bool {
+				target := field(doc)
+				if *target != value {
+					return false
+				}
+				*target = cleaned
+				return true
+			},
+		})
+	}
 
-	// Validate referential integrity
-	refErrors := CheckReferentialIntegrity(doc)
-	errors = append(errors, refErrors...)
+	return []error{finding}
+}
+
+// validateCollection checks a single collection and records its ID in seen
+func validateCollection(i int, collection *Collection, seen map[string]bool) []error {
+	var errors []error
+
+	if seen[collection.ID] {
+		errors = append(errors, newValidationError(RuleDuplicateCollectionID, jsonPointer("collections", i, "id"), collection.ID,
+			fmt.Sprintf("duplicate collection ID: %s", collection.ID)))
+	}
+	seen[collection.ID] = true
+
+	if collection.Name == "" {
+		errors = append(errors, newValidationError(RuleCollectionNameRequired, jsonPointer("collections", i, "name"), nil, "is required"))
+	}
+
+	if collection.Type == "" {
+		errors = append(errors, newValidationError(RuleCollectionTypeRequired, jsonPointer("collections", i, "type"), nil, "is required"))
+	} else if !validCollectionTypes[collection.Type] {
+		errors = append(errors, newValidationError(RuleCollectionType, jsonPointer("collections", i, "type"), collection.Type,
+			fmt.Sprintf("invalid collection type: %s", collection.Type)))
+	}
 
 	return errors
 }
@@ -142,103 +291,318 @@ func CheckReferentialIntegrity(doc *BLEFDocument) []error {
 	}
 
 	// Check entry references
-	for i, entry := range doc.Entries {
-		// Check book_id reference
-		if !bookIDs[entry.BookID] {
-			errors = append(errors, ValidationError{
-				Field:   fmt.Sprintf("entries[%d].book_id", i),
-				Message: fmt.Sprintf("references non-existent book: %s", entry.BookID),
-			})
+	entryBookIDs := make(map[string]bool)
+	for i := range doc.Entries {
+		entry := &doc.Entries[i]
+
+		if entryBookIDs[entry.BookID] {
+			errors = append(errors, duplicateEntryError(i, entry.BookID))
 		}
+		entryBookIDs[entry.BookID] = true
 
-		// Check collection_ids references
-		if len(entry.CollectionIDs) == 0 {
-			errors = append(errors, ValidationError{
-				Field:   fmt.Sprintf("entries[%d].collection_ids", i),
-				Message: "must contain at least one collection",
-			})
+		// Check book_id reference
+		if !bookIDs[entry.BookID] {
+			errors = append(errors, missingBookError(i, entry.BookID))
 		}
 
 		for j, collID := range entry.CollectionIDs {
 			if !collectionIDs[collID] {
-				errors = append(errors, ValidationError{
-					Field:   fmt.Sprintf("entries[%d].collection_ids[%d]", i, j),
-					Message: fmt.Sprintf("references non-existent collection: %s", collID),
-				})
+				errors = append(errors, missingCollectionError(i, j, collID))
 			}
 		}
 
-		// Validate status
-		validStatuses := map[string]bool{
-			"read": true, "reading": true, "to-read": true,
-			"abandoned": true, "wishlist": true,
+		errors = append(errors, validateEntry(i, entry)...)
+	}
+
+	return errors
+}
+
+// validateEntry checks the fields of an entry that don't reference other elements
+func validateEntry(i int, entry *Entry) []error {
+	var errors []error
+
+	// Check collection_ids is not empty
+	if len(entry.CollectionIDs) == 0 {
+		errors = append(errors, newValidationError(RuleEntryCollectionsEmpty, jsonPointer("entries", i, "collection_ids"), nil,
+			"must contain at least one collection"))
+	}
+
+	// Validate status
+	if !validStatuses[entry.UserData.Status] {
+		errors = append(errors, newValidationError(RuleEntryStatus, jsonPointer("entries", i, "user_data", "status"), entry.UserData.Status,
+			fmt.Sprintf("invalid status: %s", entry.UserData.Status)))
+	}
+
+	// Validate rating range
+	if entry.UserData.Rating < 0 || entry.UserData.Rating > 5 {
+		errors = append(errors, newValidationError(RuleEntryRating, jsonPointer("entries", i, "user_data", "rating"), entry.UserData.Rating,
+			"must be between 0 and 5").withFix(ratingFix(i, entry.UserData.Rating)))
+	}
+
+	for j, readDate := range entry.UserData.ReadDates {
+		if !isDate(readDate.Started) {
+			errors = append(errors, newValidationError(RuleDate, jsonPointer("entries", i, "user_data", "read_dates", j, "started"), readDate.Started.String(),
+				"must be an ISO 8601 date (YYYY-MM-DD)").withFix(dateFix(readDate.Started, PrecisionDay, func(doc *BLEFDocument) *PartialDate {
+				return &doc.Entries[i].UserData.ReadDates[j].Started
+			})))
+		}
+		if !isDate(readDate.Finished) {
+			errors = append(errors, newValidationError(RuleDate, jsonPointer("entries", i, "user_data", "read_dates", j, "finished"), readDate.Finished.String(),
+				"must be an ISO 8601 date (YYYY-MM-DD)").withFix(dateFix(readDate.Finished, PrecisionDay, func(doc *BLEFDocument) *PartialDate {
+				return &doc.Entries[i].UserData.ReadDates[j].Finished
+			})))
 		}
-		if !validStatuses[entry.UserData.Status] {
-			errors = append(errors, ValidationError{
-				Field:   fmt.Sprintf("entries[%d].user_data.status", i),
-				Message: fmt.Sprintf("invalid status: %s", entry.UserData.Status),
-			})
+		if readDate.Progress < 0 || readDate.Progress > 100 {
+			errors = append(errors, newValidationError(RuleProgress, jsonPointer("entries", i, "user_data", "read_dates", j, "progress"), readDate.Progress,
+				"must be between 0 and 100"))
 		}
+	}
 
-		// Validate rating range
-		if entry.UserData.Rating < 0 || entry.UserData.Rating > 5 {
-			errors = append(errors, ValidationError{
-				Field:   fmt.Sprintf("entries[%d].user_data.rating", i),
-				Message: "must be between 0 and 5",
-			})
+	if entry.Ownership != nil && entry.Ownership.Loaned != nil {
+		if date := entry.Ownership.Loaned.Date; !isDate(date) {
+			errors = append(errors, newValidationError(RuleDate, jsonPointer("entries", i, "ownership", "loaned", "date"), date.String(),
+				"must be an ISO 8601 date (YYYY-MM-DD)").withFix(dateFix(date, PrecisionDay, func(doc *BLEFDocument) *PartialDate {
+				return &doc.Entries[i].Ownership.Loaned.Date
+			})))
 		}
 	}
 
 	return errors
 }
 
+func duplicateEntryError(i int, bookID string) ValidationError {
+	return newValidationError(RuleDuplicateEntry, jsonPointer("entries", i, "book_id"), bookID,
+		fmt.Sprintf("duplicate entry for book: %s", bookID)).withFix(duplicateEntryFix(i, bookID))
+}
+
+func missingBookError(i int, bookID string) ValidationError {
+	return newValidationError(RuleMissingBook, jsonPointer("entries", i, "book_id"), bookID,
+		fmt.Sprintf("references non-existent book: %s", bookID))
+}
+
+func missingCollectionError(i, j int, collID string) ValidationError {
+	return newValidationError(RuleMissingCollection, jsonPointer("entries", i, "collection_ids", j), collID,
+		fmt.Sprintf("references non-existent collection: %s", collID)).withFix(missingCollectionFix(collID))
+}
+
+// StreamValidator validates a document element by element, so that files too
+// large to load in memory can still be checked. Only IDs are retained.
+type StreamValidator struct {
+	bookIDs       map[string]bool
+	collectionIDs map[string]bool
+	entryBookIDs  map[string]bool
+	pending       []pendingRef
+	errors        []error
+}
+
+// pendingRef is an entry reference to an element not seen yet
+type pendingRef struct {
+	entry      int
+	collection int // -1 for book references
+	id         string
+}
+
+// NewStreamValidator creates an empty streaming validator
+func NewStreamValidator() *StreamValidator {
+	return &StreamValidator{
+		bookIDs:       make(map[string]bool),
+		collectionIDs: make(map[string]bool),
+		entryBookIDs:  make(map[string]bool),
+	}
+}
+
+// Add validates a single streamed element
+func (v *StreamValidator) Add(elem *Element) {
+	switch elem.Kind {
+	case KindBook:
+		v.errors = append(v.errors, validateBook(elem.Index, elem.Book, v.bookIDs)...)
+	case KindCollection:
+		v.errors = append(v.errors, validateCollection(elem.Index, elem.Collection, v.collectionIDs)...)
+	case KindEntry:
+		entry := elem.Entry
+		if v.entryBookIDs[entry.BookID] {
+			v.errors = append(v.errors, duplicateEntryError(elem.Index, entry.BookID))
+		}
+		v.entryBookIDs[entry.BookID] = true
+		if !v.bookIDs[entry.BookID] {
+			v.pending = append(v.pending, pendingRef{entry: elem.Index, collection: -1, id: entry.BookID})
+		}
+		for j, collID := range entry.CollectionIDs {
+			if !v.collectionIDs[collID] {
+				v.pending = append(v.pending, pendingRef{entry: elem.Index, collection: j, id: collID})
+			}
+		}
+		v.errors = append(v.errors, validateEntry(elem.Index, entry)...)
+	}
+}
+
+// Finish resolves references to elements that appeared after the entries
+// and returns every error found. header must be the stream's final header.
+func (v *StreamValidator) Finish(header *BLEFDocument) []error {
+	errors := validateHeader(header)
+
+	if len(v.collectionIDs) == 0 {
+		errors = append(errors, newValidationError(RuleCollectionsEmpty, "/collections", nil, "must contain at least one collection"))
+	}
+
+	errors = append(errors, v.errors...)
+
+	for _, ref := range v.pending {
+		if ref.collection < 0 {
+			if !v.bookIDs[ref.id] {
+				errors = append(errors, missingBookError(ref.entry, ref.id))
+			}
+		} else if !v.collectionIDs[ref.id] {
+			errors = append(errors, missingCollectionError(ref.entry, ref.collection, ref.id))
+		}
+	}
+
+	return errors
+}
+
+// ValidateStream validates a BLEF document read from r one element at a time.
+// Only the IDs and the unresolved references are kept in memory, and JSON
+// schema validation is not performed. visit, if not nil, is called with each
+// element once validated. It returns the document header and the validation
+// errors; err is only set if the input cannot be parsed.
+func ValidateStream(r io.Reader, visit func(*Element)) (*BLEFDocument, []error, error) {
+	reader := NewStreamReader(r)
+	validator := NewStreamValidator()
+
+	for {
+		elem, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		validator.Add(elem)
+		if visit != nil {
+			visit(elem)
+		}
+	}
+
+	return reader.Header(), validator.Finish(reader.Header()), nil
+}
+
 // ValidateAgainstSchema validates JSON data against the embedded JSON Schema
+// matching the document's version. Documents without a readable version are
+// checked against the schema of CurrentVersion.
 func ValidateAgainstSchema(jsonData []byte) error {
-	schemaData, err := schemaFS.ReadFile("schema.json")
+	_, err := ValidateAgainstSchemaVersion(jsonData, "")
+	return err
+}
+
+// ValidateAgainstSchemaVersion validates JSON data against the embedded schema
+// for schemaVersion, or the one matching the document's version when
+// schemaVersion is empty. It returns the schema version actually used.
+func ValidateAgainstSchemaVersion(jsonData []byte, schemaVersion string) (string, error) {
+	resolved, findings, err := SchemaFindings(jsonData, schemaVersion)
 	if err != nil {
-		return fmt.Errorf("failed to read embedded schema: %w", err)
+		return resolved, err
 	}
 
-	schemaLoader := gojsonschema.NewBytesLoader(schemaData)
-	documentLoader := gojsonschema.NewBytesLoader(jsonData)
+	if len(findings) > 0 {
+		var errorMessages []string
+		for _, finding := range findings {
+			location := finding.Pointer
+			if location == "" {
+				location = "(root)"
+			}
+			errorMessages = append(errorMessages, fmt.Sprintf("%s: %s", location, finding.Message))
+		}
+		return resolved, fmt.Errorf("schema validation errors:\n%s", strings.Join(errorMessages, "\n"))
+	}
+
+	return resolved, nil
+}
+
+// SchemaFindings validates JSON data against the embedded schema like
+// ValidateAgainstSchemaVersion, but returns one finding per schema violation.
+// err is only set if the schema cannot be resolved or the data is not JSON.
+func SchemaFindings(jsonData []byte, schemaVersion string) (string, []ValidationError, error) {
+	if schemaVersion == "" {
+		schemaVersion = CurrentVersion
+		if version, err := DetectVersion(jsonData); err == nil {
+			schemaVersion = version
+		}
+	}
 
-	result, err := gojsonschema.Validate(schemaLoader, documentLoader)
+	resolved, err := ResolveSchemaVersion(schemaVersion)
 	if err != nil {
-		return fmt.Errorf("validation failed: %w", err)
+		return "", nil, err
 	}
 
-	if !result.Valid() {
-		var errorMessages []string
-		for _, err := range result.Errors() {
-			errorMessages = append(errorMessages, fmt.Sprintf("%s: %s", err.Field(), err.Description()))
-		}
-		return fmt.Errorf("schema validation errors:\n%s", strings.Join(errorMessages, "\n"))
+	schema, err := CompiledSchema(resolved)
+	if err != nil {
+		return resolved, nil, err
+	}
+
+	result, err := schema.ValidateJSON(jsonData)
+	if err != nil {
+		return resolved, nil, fmt.Errorf("validation failed: %w", err)
 	}
 
-	return nil
+	var findings []ValidationError
+	for _, err := range result.Errors {
+		findings = append(findings, newValidationError(RuleSchema, err.InstanceLocation, err.Value,
+			fmt.Sprintf("%s (%s)", err.Message, err.Keyword)))
+	}
+	return resolved, findings, nil
 }
 
-// validateISBN13 validates the ISBN-13 check digit
-func validateISBN13(isbn string) bool {
-	if len(isbn) != 13 {
-		return false
+// ValidateJSON runs schema validation and, when the document can be parsed,
+// the semantic checks of ValidateDocument, collecting every finding in a
+// single report. err is only set if the schema version cannot be resolved.
+func ValidateJSON(jsonData []byte, schemaVersion string) (*ValidationReport, error) {
+	report := NewValidationReport()
+
+	resolved, findings, err := SchemaFindings(jsonData, schemaVersion)
+	if err != nil && resolved == "" {
+		return nil, err
+	}
+	report.SchemaVersion = resolved
+	if err != nil {
+		report.Add(newValidationError(RuleParse, "", nil, err.Error()))
+		return report, nil
+	}
+	for _, finding := range findings {
+		report.Add(finding)
 	}
 
-	sum := 0
-	for i, char := range isbn[:12] {
-		digit, err := strconv.Atoi(string(char))
-		if err != nil {
-			return false
+	doc, err := FromJSON(jsonData)
+	if err != nil {
+		// Type mismatches are already reported by the schema
+		if len(findings) == 0 {
+			report.Add(newValidationError(RuleParse, "", nil, err.Error()))
 		}
-		if i%2 == 0 {
-			sum += digit
-		} else {
-			sum += digit * 3
+		return report, nil
+	}
+	report.Document = doc
+
+	// Skip semantic findings on values the schema already rejected
+	rejected := make(map[string]bool, len(findings))
+	for _, finding := range findings {
+		rejected[finding.Pointer] = true
+	}
+	for _, err := range ValidateDocument(doc) {
+		if finding, ok := err.(ValidationError); ok && rejected[finding.Pointer] {
+			continue
 		}
+		report.Add(err)
 	}
 
-	checkDigit := (10 - (sum % 10)) % 10
-	lastDigit, _ := strconv.Atoi(string(isbn[12]))
+	return report, nil
+}
 
-	return checkDigit == lastDigit
+// isDate reports whether d is unknown or an ISO 8601 calendar date
+// (YYYY-MM-DD), the precision required of read and loan dates
+func isDate(d PartialDate) bool {
+	return d.IsZero() || d.Precision() == PrecisionDay
+}
+
+// validateISBN13 validates the ISBN-13 check digit
+func validateISBN13(value string) bool {
+	return isbn.IsValid13(value)
 }
@@ -140,3 +140,129 @@ func TestCheckReferentialIntegrity(t *testing.T) {
 		t.Error("CheckReferentialIntegrity should return error for invalid status")
 	}
 }
+
+func TestResolveSchemaVersion(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected string
+		wantErr  bool
+	}{
+		{"0.1.0", "0.1.0", false},
+		{"0.1.5", "0.1.0", false},
+		{"0.0.9", "0.1.0", false},
+		{"1.0.0", "", true},
+		{"not-a-version", "", true},
+	}
+
+	for _, tt := range tests {
+		resolved, err := ResolveSchemaVersion(tt.version)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ResolveSchemaVersion(%s) error = %v, wantErr %v", tt.version, err, tt.wantErr)
+			continue
+		}
+		if resolved != tt.expected {
+			t.Errorf("ResolveSchemaVersion(%s) = %s, want %s", tt.version, resolved, tt.expected)
+		}
+	}
+}
+
+func TestValidateAgainstSchemaVersion(t *testing.T) {
+	doc := []byte(`{
+		"format": "BLEF",
+		"version": "0.1.0",
+		"exported_at": "2025-10-26T14:30:00Z",
+		"books": [],
+		"collections": [{"id": "read", "name": "Read", "type": "read"}],
+		"entries": []
+	}`)
+
+	used, err := ValidateAgainstSchemaVersion(doc, "")
+	if err != nil {
+		t.Fatalf("ValidateAgainstSchemaVersion failed: %v", err)
+	}
+	if used != "0.1.0" {
+		t.Errorf("Expected schema 0.1.0, got %s", used)
+	}
+
+	if _, err := ValidateAgainstSchemaVersion(doc, "2.0.0"); err == nil {
+		t.Error("ValidateAgainstSchemaVersion should reject unknown major versions")
+	}
+
+	if version, err := DetectVersion(doc); err != nil || version != "0.1.0" {
+		t.Errorf("DetectVersion = %q, %v", version, err)
+	}
+}
+
+// pages returns a page count for an edition literal
+func pages(n int) *int {
+	return &n
+}
+
+func TestSemanticValidation(t *testing.T) {
+	newDoc := func() *BLEFDocument {
+		doc := NewDocument()
+		doc.Books = []Book{{
+			ID:       "9780156013987",
+			Title:    "The Little Prince",
+			Authors:  []Author{{Name: "Antoine de Saint-Exupéry", Role: "author"}},
+			Language: "en-US",
+			CoverURL: "https://covers.openlibrary.org/b/isbn/9780156013987-L.jpg",
+			Edition:  &Edition{PublishedDate: MustParseDate("2000-06"), Format: "paperback", Pages: pages(96)},
+		}}
+		doc.Collections = []Collection{{ID: "read", Name: "Read", Type: "read"}}
+		doc.Entries = []Entry{{
+			BookID:        "9780156013987",
+			CollectionIDs: []string{"read"},
+			UserData: UserData{
+				Status:    "read",
+				ReadDates: []ReadDate{{Started: MustParseDate("2024-01-02"), Finished: MustParseDate("2024-01-10"), Progress: 100}},
+			},
+			Ownership: &Ownership{Owned: true, Loaned: &Loaned{Status: true, Date: MustParseDate("2024-02-01")}},
+		}}
+		return doc
+	}
+
+	if errors := ValidateDocument(newDoc()); len(errors) > 0 {
+		t.Fatalf("ValidateDocument returned errors for valid document: %v", errors)
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(doc *BLEFDocument)
+		code    string
+		pointer string
+	}{
+		{"author role", func(d *BLEFDocument) { d.Books[0].Authors[0].Role = "writer" }, RuleAuthorRole, "/books/0/authors/0/role"},
+		{"author name", func(d *BLEFDocument) { d.Books[0].Authors[0].Name = "" }, RuleAuthorNameRequired, "/books/0/authors/0/name"},
+		{"language", func(d *BLEFDocument) { d.Books[0].Language = "english" }, RuleLanguageCode, "/books/0/language"},
+		{"language region", func(d *BLEFDocument) { d.Books[0].Language = "xx-US" }, RuleLanguageCode, "/books/0/language"},
+		{"cover url", func(d *BLEFDocument) { d.Books[0].CoverURL = "covers/little-prince.jpg" }, RuleCoverURL, "/books/0/cover_url"},
+		{"published date", func(d *BLEFDocument) { d.Books[0].Edition.PublishedDate = invalidDate("June 2000") }, RulePublishedDate, "/books/0/edition/published_date"},
+		{"edition format", func(d *BLEFDocument) { d.Books[0].Edition.Format = "pocket" }, RuleEditionFormat, "/books/0/edition/format"},
+		{"pages", func(d *BLEFDocument) { d.Books[0].Edition.Pages = pages(-3) }, RulePages, "/books/0/edition/pages"},
+		{"zero pages", func(d *BLEFDocument) { d.Books[0].Edition.Pages = pages(0) }, RulePages, "/books/0/edition/pages"},
+		{"series name", func(d *BLEFDocument) { d.Books[0].Series = &Series{Volume: 1} }, RuleSeriesNameRequired, "/books/0/series/name"},
+		{"collection type", func(d *BLEFDocument) { d.Collections[0].Type = "shelf" }, RuleCollectionType, "/collections/0/type"},
+		{"started date", func(d *BLEFDocument) { d.Entries[0].UserData.ReadDates[0].Started = invalidDate("02/01/2024") }, RuleDate, "/entries/0/user_data/read_dates/0/started"},
+		{"finished date", func(d *BLEFDocument) { d.Entries[0].UserData.ReadDates[0].Finished = invalidDate("2024-02-30") }, RuleDate, "/entries/0/user_data/read_dates/0/finished"},
+		{"progress", func(d *BLEFDocument) { d.Entries[0].UserData.ReadDates[0].Progress = 120 }, RuleProgress, "/entries/0/user_data/read_dates/0/progress"},
+		{"loan date", func(d *BLEFDocument) { d.Entries[0].Ownership.Loaned.Date = invalidDate("yesterday") }, RuleDate, "/entries/0/ownership/loaned/date"},
+		{"duplicate entry", func(d *BLEFDocument) { d.Entries = append(d.Entries, d.Entries[0]) }, RuleDuplicateEntry, "/entries/1/book_id"},
+		{"isbn10 check digit", func(d *BLEFDocument) { d.Books[0].Identifiers.ISBN10 = "0156013984" }, RuleIdentifierISBN, "/books/0/identifiers/isbn10"},
+		{"hyphenated isbn13", func(d *BLEFDocument) { d.Books[0].Identifiers.ISBN13 = "978-0-15-601398-7" }, RuleIdentifierISBN, "/books/0/identifiers/isbn13"},
+	}
+
+	for _, tt := range tests {
+		doc := newDoc()
+		tt.mutate(doc)
+		errors := ValidateDocument(doc)
+		if len(errors) != 1 {
+			t.Errorf("%s: expected 1 error, got %v", tt.name, errors)
+			continue
+		}
+		finding := errors[0].(ValidationError)
+		if finding.Code != tt.code || finding.Pointer != tt.pointer {
+			t.Errorf("%s: got %s at %s, want %s at %s", tt.name, finding.Code, finding.Pointer, tt.code, tt.pointer)
+		}
+	}
+}
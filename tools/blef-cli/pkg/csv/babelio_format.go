@@ -18,6 +18,11 @@ func (f *BabelioFormat) Description() string {
 	return "Babelio library export"
 }
 
+// Locale returns fr-FR: Babelio writes dates day first, with French month names
+func (f *BabelioFormat) Locale() string {
+	return "fr-FR"
+}
+
 func (f *BabelioFormat) Detect(data *CSVData) bool {
 	// Check for Babelio-specific columns
 	// Real Babelio exports use "ISBN", "Titre", "Auteur", "Statut"
@@ -105,8 +110,8 @@ func (f *BabelioFormat) ExportBook(book *blef.Book, entry *blef.Entry) []string
 	row := make([]string, len(f.GetExportHeaders()))
 
 	// ISBN (ISBN13 or use book ID if not an ISBN)
-	if book.Identifiers.ISBN13 != "" {
-		row[0] = book.Identifiers.ISBN13
+	if isbn13, _ := exportISBNs(book); isbn13 != "" {
+		row[0] = isbn13
 	} else {
 		row[0] = book.ID // Fallback to book ID (might be custom ID like "SI19412249693")
 	}
@@ -127,7 +132,7 @@ func (f *BabelioFormat) ExportBook(book *blef.Book, entry *blef.Entry) []string
 	if book.Edition != nil {
 		row[3] = book.Edition.Publisher
 		// Date de publication
-		row[4] = book.Edition.PublishedDate
+		row[4] = book.Edition.PublishedDate.String()
 	}
 
 	// Entry data
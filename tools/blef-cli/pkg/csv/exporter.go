@@ -3,9 +3,11 @@ package csv
 import (
 	"encoding/csv"
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/blef"
+	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/isbn"
 )
 
 // Exporter converts BLEF documents to CSV format
@@ -96,3 +98,113 @@ func (e *Exporter) GetExportStats() ExportStats {
 	return stats
 }
 
+// StreamExport exports a BLEF file to CSV without loading the whole document.
+// A first pass records the byte offset of every book, then each entry's book
+// is read back from the input while the entries are streamed.
+func StreamExport(inputFile, outputFile string, format CSVFormat) (ExportStats, error) {
+	var stats ExportStats
+
+	input, err := os.Open(inputFile)
+	if err != nil {
+		return stats, fmt.Errorf("failed to open input file: %w", err)
+	}
+	defer input.Close()
+
+	// First pass: index book offsets
+	bookOffsets := make(map[string]int64)
+	reader := blef.NewStreamReader(input)
+	for {
+		elem, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return stats, err
+		}
+		switch elem.Kind {
+		case blef.KindBook:
+			stats.TotalBooks++
+			if _, exists := bookOffsets[elem.Book.ID]; !exists {
+				bookOffsets[elem.Book.ID] = elem.Offset
+			}
+		case blef.KindEntry:
+			stats.TotalEntries++
+		}
+	}
+
+	if _, err := input.Seek(0, io.SeekStart); err != nil {
+		return stats, fmt.Errorf("failed to rewind input file: %w", err)
+	}
+
+	file, err := os.Create(outputFile)
+	if err != nil {
+		return stats, fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	if err := writer.Write(format.GetExportHeaders()); err != nil {
+		return stats, fmt.Errorf("failed to write headers: %w", err)
+	}
+
+	// Second pass: export entries
+	reader = blef.NewStreamReader(input)
+	for {
+		elem, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return stats, err
+		}
+		if elem.Kind != blef.KindEntry {
+			continue
+		}
+
+		offset, exists := bookOffsets[elem.Entry.BookID]
+		if !exists {
+			// Skip entries without corresponding books
+			stats.Skipped++
+			continue
+		}
+		book, err := blef.ReadBookAt(input, offset)
+		if err != nil {
+			return stats, err
+		}
+
+		if err := writer.Write(format.ExportBook(book, elem.Entry)); err != nil {
+			return stats, fmt.Errorf("failed to write row: %w", err)
+		}
+		stats.Exported++
+	}
+
+	writer.Flush()
+	return stats, writer.Error()
+}
+
+// exportISBNs returns the normalized ISBN-13 and ISBN-10 of a book. A missing
+// ISBN is derived from the other one, or from an ISBN-13 book ID. Values that
+// are not valid ISBNs are returned unchanged.
+func exportISBNs(book *blef.Book) (isbn13, isbn10 string) {
+	isbn13, isbn10 = book.Identifiers.ISBN13, book.Identifiers.ISBN10
+	if normalized, err := isbn.Parse13(isbn13); err == nil {
+		isbn13 = normalized
+	}
+	if normalized, err := isbn.Parse(isbn10); err == nil && len(normalized) == 10 {
+		isbn10 = normalized
+	}
+
+	if isbn13 == "" && isbn.IsValid13(book.ID) {
+		isbn13 = book.ID
+	}
+	if isbn13 == "" && isbn10 != "" {
+		isbn13, _ = isbn.To13(isbn10)
+	}
+	if isbn10 == "" && isbn13 != "" {
+		isbn10, _ = isbn.To10(isbn13)
+	}
+
+	return isbn13, isbn10
+}
@@ -2,6 +2,7 @@ package csv
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/blef"
@@ -231,6 +232,44 @@ func TestExportBabelioFormat(t *testing.T) {
 	}
 }
 
+func TestStreamExportMatchesExportToFile(t *testing.T) {
+	doc := blef.NewDocument()
+	_ = doc.AddBook(blef.Book{ID: "9780123456789", Title: "Test Book 1", Authors: []blef.Author{{Name: "Test Author"}}})
+	_ = doc.AddBook(blef.Book{ID: "9780987654321", Title: "Test Book 2", Authors: []blef.Author{{Name: "Another Author"}}})
+	_ = doc.AddCollection(blef.Collection{ID: "test", Name: "Test Collection", Type: "custom"})
+	_ = doc.AddEntry(blef.Entry{BookID: "9780987654321", CollectionIDs: []string{"test"}, UserData: blef.UserData{Status: "reading"}})
+	_ = doc.AddEntry(blef.Entry{BookID: "9780123456789", CollectionIDs: []string{"test"}, UserData: blef.UserData{Status: "read", Rating: 4}})
+	doc.Entries = append(doc.Entries, blef.Entry{BookID: "missing", CollectionIDs: []string{"test"}, UserData: blef.UserData{Status: "read"}})
+
+	tmpDir := t.TempDir()
+	inputFile := filepath.Join(tmpDir, "library.blef.json")
+	if err := doc.SaveToFile(inputFile); err != nil {
+		t.Fatalf("SaveToFile failed: %v", err)
+	}
+
+	format := &GoodreadsFormat{}
+	expectedFile := filepath.Join(tmpDir, "expected.csv")
+	if err := NewExporter(doc, format).ExportToFile(expectedFile); err != nil {
+		t.Fatalf("ExportToFile failed: %v", err)
+	}
+
+	streamedFile := filepath.Join(tmpDir, "streamed.csv")
+	stats, err := StreamExport(inputFile, streamedFile, format)
+	if err != nil {
+		t.Fatalf("StreamExport failed: %v", err)
+	}
+
+	if stats.TotalBooks != 2 || stats.TotalEntries != 3 || stats.Exported != 2 || stats.Skipped != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	expected, _ := os.ReadFile(expectedFile)
+	streamed, _ := os.ReadFile(streamedFile)
+	if string(expected) != string(streamed) {
+		t.Errorf("Streamed export differs:\n%s\nwant:\n%s", streamed, expected)
+	}
+}
+
 // Helper function
 func containsSubstring(haystack, needle string) bool {
 	return len(haystack) >= len(needle) && 
@@ -240,3 +279,24 @@ func containsSubstring(haystack, needle string) bool {
 		  containsSubstring(haystack[1:], needle)))
 }
 
+
+func TestExportISBNs(t *testing.T) {
+	tests := []struct {
+		book   blef.Book
+		isbn13 string
+		isbn10 string
+	}{
+		{blef.Book{ID: "9780156013987"}, "9780156013987", "0156013983"},
+		{blef.Book{ID: "x", Identifiers: blef.Identifiers{ISBN13: "978-0-451-52493-5"}}, "9780451524935", "0451524934"},
+		{blef.Book{ID: "x", Identifiers: blef.Identifiers{ISBN10: "0-15-601398-3"}}, "9780156013987", "0156013983"},
+		{blef.Book{ID: "x", Identifiers: blef.Identifiers{ISBN13: "9791032305690"}}, "9791032305690", ""},
+		{blef.Book{ID: "x", Identifiers: blef.Identifiers{ISBN13: "9780123456789"}}, "9780123456789", ""},
+	}
+
+	for _, tt := range tests {
+		isbn13, isbn10 := exportISBNs(&tt.book)
+		if isbn13 != tt.isbn13 || isbn10 != tt.isbn10 {
+			t.Errorf("exportISBNs(%+v) = %q, %q, want %q, %q", tt.book, isbn13, isbn10, tt.isbn13, tt.isbn10)
+		}
+	}
+}
@@ -33,6 +33,14 @@ type CSVFormat interface {
 	ExportBook(book *blef.Book, entry *blef.Entry) []string
 }
 
+// LocalizedFormat is implemented by formats whose exports write dates the
+// way a given locale does. Its numeric date order and month names are used
+// to read dates on import.
+type LocalizedFormat interface {
+	// Locale returns the locale of the dates, e.g. "en-US" or "fr-FR"
+	Locale() string
+}
+
 // FormatRegistry manages available CSV formats
 type FormatRegistry struct {
 	formats []CSVFormat
@@ -3,7 +3,6 @@ package csv
 import (
 	"fmt"
 	"strings"
-	"time"
 
 	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/blef"
 )
@@ -19,6 +18,11 @@ func (f *GoodreadsFormat) Description() string {
 	return "Goodreads library export"
 }
 
+// Locale returns en-US: Goodreads writes dates month first
+func (f *GoodreadsFormat) Locale() string {
+	return "en-US"
+}
+
 func (f *GoodreadsFormat) Detect(data *CSVData) bool {
 	// Check for Goodreads-specific columns
 	requiredColumns := []string{"Book Id", "Title", "Author", "ISBN13", "My Rating"}
@@ -147,7 +151,7 @@ func (f *GoodreadsFormat) ExportBook(book *blef.Book, entry *blef.Entry) []strin
 	if len(book.Authors) > 0 {
 		row[2] = book.Authors[0].Name
 		// Author l-f (Last, First)
-		row[3] = formatAuthorLastFirst(book.Authors[0].Name)
+		row[3] = blef.ParseAuthorName(book.Authors[0].Name).SortName()
 
 		// Additional Authors
 		if len(book.Authors) > 1 {
@@ -160,11 +164,12 @@ func (f *GoodreadsFormat) ExportBook(book *blef.Book, entry *blef.Entry) []strin
 	}
 
 	// ISBNs - wrap in Excel formula to preserve leading zeros
-	if book.Identifiers.ISBN10 != "" {
-		row[5] = fmt.Sprintf(`="%s"`, book.Identifiers.ISBN10)
+	isbn13, isbn10 := exportISBNs(book)
+	if isbn10 != "" {
+		row[5] = fmt.Sprintf(`="%s"`, isbn10)
 	}
-	if book.Identifiers.ISBN13 != "" {
-		row[6] = fmt.Sprintf(`=""%s""`, book.Identifiers.ISBN13)
+	if isbn13 != "" {
+		row[6] = fmt.Sprintf(`=""%s""`, isbn13)
 	}
 
 	// My Rating
@@ -180,8 +185,10 @@ func (f *GoodreadsFormat) ExportBook(book *blef.Book, entry *blef.Entry) []strin
 	// Edition info
 	if book.Edition != nil {
 		row[9] = book.Edition.Publisher
-		row[11] = fmt.Sprintf("%d", book.Edition.Pages)
-		row[12] = book.Edition.PublishedDate
+		if book.Edition.Pages != nil {
+			row[11] = fmt.Sprintf("%d", *book.Edition.Pages)
+		}
+		row[12] = book.Edition.PublishedDate.String()
 	}
 
 	// Binding - leave empty
@@ -194,8 +201,8 @@ func (f *GoodreadsFormat) ExportBook(book *blef.Book, entry *blef.Entry) []strin
 	if entry != nil {
 		// Date Read
 		if len(entry.UserData.ReadDates) > 0 {
-			if parsed, err := time.Parse("2006-01-02", entry.UserData.ReadDates[0].Finished); err == nil {
-				row[14] = parsed.Format("2006/01/02")
+			if finished := entry.UserData.ReadDates[0].Finished; finished.Precision() >= blef.PrecisionDay {
+				row[14] = finished.Time().Format("2006/01/02")
 			}
 		}
 
@@ -226,18 +233,6 @@ func (f *GoodreadsFormat) ExportBook(book *blef.Book, entry *blef.Entry) []strin
 	return row
 }
 
-// formatAuthorLastFirst converts "First Last" to "Last, First"
-func formatAuthorLastFirst(name string) string {
-	parts := strings.Fields(name)
-	if len(parts) < 2 {
-		return name
-	}
-	// Simple heuristic: last word is last name
-	lastName := parts[len(parts)-1]
-	firstName := strings.Join(parts[:len(parts)-1], " ")
-	return fmt.Sprintf("%s, %s", lastName, firstName)
-}
-
 // mapStatusToGoodreads converts BLEF status to Goodreads shelf
 func mapStatusToGoodreads(status string) string {
 	switch status {
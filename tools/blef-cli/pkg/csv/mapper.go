@@ -3,11 +3,11 @@ package csv
 import (
 	"fmt"
 	"strings"
-	"time"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/google/uuid"
 	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/blef"
+	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/isbn"
 )
 
 // Mapper converts CSV data to BLEF format
@@ -15,6 +15,22 @@ type Mapper struct {
 	Data    *CSVData
 	Mapping ColumnMapping
 	Format  CSVFormat
+	Dates   blef.DateParser
+
+	// Dropped lists the values left out by the last ConvertToBLEF
+	Dropped []DroppedValue
+}
+
+// DroppedValue is a CSV value that could not be converted
+type DroppedValue struct {
+	Row    int // 1-based, the header being row 1
+	Column string
+	Value  string
+	Err    error
+}
+
+func (d DroppedValue) String() string {
+	return fmt.Sprintf("row %d, %s %q: %v", d.Row, d.Column, d.Value, d.Err)
 }
 
 // NewMapper creates a new CSV to BLEF mapper
@@ -24,10 +40,17 @@ func NewMapper(data *CSVData, format CSVFormat) *Mapper {
 		mapping = format.GetImportMapping()
 	}
 
+	// Without a known locale, ambiguous numeric dates are rejected
+	var dates blef.DateParser
+	if localized, ok := format.(LocalizedFormat); ok {
+		dates = blef.NewDateParser(localized.Locale())
+	}
+
 	return &Mapper{
 		Data:    data,
 		Mapping: mapping,
 		Format:  format,
+		Dates:   dates,
 	}
 }
 
@@ -162,7 +185,8 @@ func (m *Mapper) updateMapping(columnName, selectedField string) {
 
 // ConvertToBLEF converts CSV data to a BLEF document
 func (m *Mapper) ConvertToBLEF() (*blef.BLEFDocument, error) {
-	doc := blef.NewDocument()
+	lib := blef.NewLibrary(blef.NewDocument())
+	m.Dropped = nil
 
 	// Track collections
 	collections := make(map[string]*blef.Collection)
@@ -180,7 +204,7 @@ func (m *Mapper) ConvertToBLEF() (*blef.BLEFDocument, error) {
 		}
 
 		// Add book
-		if err := doc.AddBook(*book); err != nil {
+		if err := lib.AddBook(*book); err != nil {
 			// Book might already exist, that's ok
 			if !strings.Contains(err.Error(), "already exists") {
 				fmt.Printf("Warning: failed to add book at row %d: %v\n", rowIdx+2, err)
@@ -188,25 +212,26 @@ func (m *Mapper) ConvertToBLEF() (*blef.BLEFDocument, error) {
 		}
 
 		// Build entry
-		entry := m.buildEntry(row, book.ID, &collections)
+		entry := m.buildEntry(row, rowIdx, book.ID, &collections)
 		if entry != nil {
-			// Ensure collections exist in document
-			for collID, coll := range collections {
-				existing := doc.GetCollectionByID(collID)
-				if existing == nil {
-					_ = doc.AddCollection(*coll)
+			// Ensure the entry's collections exist in document
+			for _, collID := range entry.CollectionIDs {
+				if lib.GetCollectionByID(collID) == nil {
+					_ = lib.AddCollection(*collections[collID])
 				}
 			}
 
-			if err := doc.AddEntry(*entry); err != nil {
+			if err := lib.AddEntry(*entry); err != nil {
 				fmt.Printf("Warning: failed to add entry at row %d: %v\n", rowIdx+2, err)
 			}
 		}
 	}
 
+	doc := lib.Document()
+
 	// Ensure at least one collection exists
 	if len(doc.Collections) == 0 {
-		_ = doc.AddCollection(blef.Collection{
+		_ = lib.AddCollection(blef.Collection{
 			ID:       "default",
 			Name:     "My Library",
 			Type:     "custom",
@@ -233,14 +258,29 @@ func (m *Mapper) buildBook(row []string, rowIdx int) *blef.Book {
 		isbn10 = m.Format.CleanValue(isbn10)
 	}
 
+	// Normalize ISBNs (hyphens, "ISBN:" labels...). Invalid values are kept
+	// as-is so that validation reports them.
+	if normalized, err := isbn.Parse13(isbn13); err == nil {
+		isbn13 = normalized
+	}
+	if normalized, err := isbn.Parse(isbn10); err == nil && len(normalized) == 10 {
+		isbn10 = normalized
+	}
+
+	// ISBN-10 is NOT valid as book ID in BLEF, derive the ISBN-13 from it
+	if isbn13 == "" && isbn10 != "" {
+		if converted, err := isbn.To13(isbn10); err == nil {
+			isbn13 = converted
+		}
+	}
+
 	// Determine book ID - prioritize ISBN13, then generate UUID
-	// Note: ISBN-10 is NOT valid as book ID in BLEF (only ISBN-13 or UUID)
 	bookID := m.getValue(row, m.Mapping.BookID)
 	if bookID == "" {
 		bookID = isbn13
 	}
 	if bookID == "" {
-		// Generate UUID if no ISBN-13 available (even if we have ISBN-10)
+		// Generate UUID if no ISBN is available
 		bookID = uuid.New().String()
 	}
 
@@ -281,14 +321,17 @@ func (m *Mapper) buildBook(row []string, rowIdx int) *blef.Book {
 		m.getValue(row, m.Mapping.Pages) != "" {
 
 		edition := &blef.Edition{
-			Publisher:     m.getValue(row, m.Mapping.Publisher),
-			PublishedDate: m.getValue(row, m.Mapping.PublishedDate),
+			Publisher: m.getValue(row, m.Mapping.Publisher),
+		}
+
+		if date, ok := m.parseDate(row, rowIdx, m.Mapping.PublishedDate); ok {
+			edition.PublishedDate = date.Truncate(blef.PrecisionDay)
 		}
 
 		if pagesStr := m.getValue(row, m.Mapping.Pages); pagesStr != "" {
 			var pages int
-			if _, err := fmt.Sscanf(pagesStr, "%d", &pages); err == nil {
-				edition.Pages = pages
+			if _, err := fmt.Sscanf(pagesStr, "%d", &pages); err == nil && pages > 0 {
+				edition.Pages = &pages
 			}
 		}
 
@@ -299,7 +342,7 @@ func (m *Mapper) buildBook(row []string, rowIdx int) *blef.Book {
 }
 
 // buildEntry creates an entry from a CSV row
-func (m *Mapper) buildEntry(row []string, bookID string, collections *map[string]*blef.Collection) *blef.Entry {
+func (m *Mapper) buildEntry(row []string, rowIdx int, bookID string, collections *map[string]*blef.Collection) *blef.Entry {
 	// Determine status
 	statusStr := m.getValue(row, m.Mapping.Status)
 	status := "to-read" // default
@@ -355,17 +398,19 @@ func (m *Mapper) buildEntry(row []string, bookID string, collections *map[string
 		userData.Tags = strings.Split(tags, ",")
 	}
 
-	if dateAdded := m.getValue(row, m.Mapping.DateAdded); dateAdded != "" {
-		if t, err := parseDate(dateAdded); err == nil {
-			userData.AddedAt = &t
-		}
+	if date, ok := m.parseDate(row, rowIdx, m.Mapping.DateAdded); ok {
+		t := date.Time()
+		userData.AddedAt = &t
 	}
 
-	if dateRead := m.getValue(row, m.Mapping.DateRead); dateRead != "" {
-		if t, err := parseDate(dateRead); err == nil {
+	// Read dates must be known to the day
+	if date, ok := m.parseDate(row, rowIdx, m.Mapping.DateRead); ok {
+		if date.Precision() >= blef.PrecisionDay {
 			userData.ReadDates = []blef.ReadDate{
-				{Finished: t.Format("2006-01-02")},
+				{Finished: date.Truncate(blef.PrecisionDay)},
 			}
+		} else {
+			m.drop(rowIdx, m.Mapping.DateRead, date.String(), fmt.Errorf("read dates must be known to the day"))
 		}
 	}
 
@@ -376,6 +421,25 @@ func (m *Mapper) buildEntry(row []string, bookID string, collections *map[string
 	}
 }
 
+// parseDate parses the date of a column, recording it in Dropped when it
+// cannot be read
+func (m *Mapper) parseDate(row []string, rowIdx int, columnName string) (blef.PartialDate, bool) {
+	value := m.getValue(row, columnName)
+	if value == "" {
+		return blef.PartialDate{}, false
+	}
+	date, err := m.Dates.Parse(value)
+	if err != nil {
+		m.drop(rowIdx, columnName, value, err)
+		return blef.PartialDate{}, false
+	}
+	return date, true
+}
+
+func (m *Mapper) drop(rowIdx int, columnName, value string, err error) {
+	m.Dropped = append(m.Dropped, DroppedValue{Row: rowIdx + 2, Column: columnName, Value: value, Err: err})
+}
+
 // getValue retrieves a value from the row using the mapping
 func (m *Mapper) getValue(row []string, columnName string) string {
 	if columnName == "" {
@@ -384,25 +448,6 @@ func (m *Mapper) getValue(row []string, columnName string) string {
 	return m.Data.GetValue(row, columnName)
 }
 
-// parseDate attempts to parse various date formats
-func parseDate(dateStr string) (time.Time, error) {
-	formats := []string{
-		"2006-01-02",
-		"2006/01/02",
-		"01/02/2006",
-		"02/01/2006",
-		time.RFC3339,
-	}
-
-	for _, format := range formats {
-		if t, err := time.Parse(format, dateStr); err == nil {
-			return t, nil
-		}
-	}
-
-	return time.Time{}, fmt.Errorf("unable to parse date: %s", dateStr)
-}
-
 // normalizeStatus attempts to normalize any status string
 func normalizeStatus(value string) string {
 	value = strings.TrimSpace(strings.ToLower(value))
@@ -0,0 +1,72 @@
+package csv
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/blef"
+)
+
+func TestMapperDates(t *testing.T) {
+	goodreadsData := &CSVData{
+		Headers: []string{"Book Id", "Title", "Author", "ISBN13", "My Rating", "Year Published", "Date Read", "Date Added", "Exclusive Shelf"},
+		Rows: [][]string{
+			{"1", "The Hobbit", "J.R.R. Tolkien", "9780547928227", "5", "1937", "05/03/2024", "2024/01/15", "read"},
+		},
+	}
+	doc, err := NewMapper(goodreadsData, &GoodreadsFormat{}).ConvertToBLEF()
+	if err != nil {
+		t.Fatalf("ConvertToBLEF failed: %v", err)
+	}
+	if got := doc.Books[0].Edition.PublishedDate; got != blef.NewYear(1937) {
+		t.Errorf("Expected published year 1937, got %v", got)
+	}
+	if got := doc.Entries[0].UserData.ReadDates[0].Finished.String(); got != "2024-05-03" {
+		t.Errorf("Expected Goodreads dates to be read month first, got %s", got)
+	}
+	if got := doc.Entries[0].UserData.AddedAt; got == nil || got.Format("2006-01-02") != "2024-01-15" {
+		t.Errorf("Expected date added 2024-01-15, got %v", got)
+	}
+
+	babelioData := &CSVData{
+		Headers: []string{"ISBN", "Titre", "Auteur", "Statut", "Date de publication"},
+		Rows: [][]string{
+			{"9782070612758", "Le Petit Prince", "Antoine de Saint-Exupéry", "Lu", "05/03/1943"},
+			{"9782070360024", "L'Étranger", "Albert Camus", "Lu", "avril 1942"},
+		},
+	}
+	doc, err = NewMapper(babelioData, &BabelioFormat{}).ConvertToBLEF()
+	if err != nil {
+		t.Fatalf("ConvertToBLEF failed: %v", err)
+	}
+	if got := doc.Books[0].Edition.PublishedDate.String(); got != "1943-03-05" {
+		t.Errorf("Expected Babelio dates to be read day first, got %s", got)
+	}
+	if got := doc.Books[1].Edition.PublishedDate.String(); got != "1942-04" {
+		t.Errorf("Expected French month names to be read, got %s", got)
+	}
+
+	// Without a locale, ambiguous dates are dropped rather than guessed
+	mapper := NewMapper(goodreadsData, &GoodreadsFormat{})
+	mapper.Dates.Order = blef.AmbiguousDates
+	doc, err = mapper.ConvertToBLEF()
+	if err != nil {
+		t.Fatalf("ConvertToBLEF failed: %v", err)
+	}
+	if readDates := doc.Entries[0].UserData.ReadDates; len(readDates) != 0 {
+		t.Errorf("Expected the ambiguous date read to be dropped, got %v", readDates)
+	}
+	if len(mapper.Dropped) != 1 || mapper.Dropped[0].Row != 2 || mapper.Dropped[0].Column != "Date Read" || !errors.Is(mapper.Dropped[0].Err, blef.ErrAmbiguousDate) {
+		t.Errorf("Expected the ambiguous date read to be reported, got %v", mapper.Dropped)
+	}
+
+	// Dates that cannot be read at all, or read dates known to the month only
+	goodreadsData.Rows[0][5], goodreadsData.Rows[0][6] = "someday", "March 2024"
+	mapper = NewMapper(goodreadsData, &GoodreadsFormat{})
+	if _, err := mapper.ConvertToBLEF(); err != nil {
+		t.Fatalf("ConvertToBLEF failed: %v", err)
+	}
+	if len(mapper.Dropped) != 2 || mapper.Dropped[0].Column != "Year Published" || mapper.Dropped[1].Value != "2024-03" {
+		t.Errorf("Expected the dropped dates to be reported, got %v", mapper.Dropped)
+	}
+}
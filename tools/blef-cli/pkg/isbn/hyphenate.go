@@ -0,0 +1,146 @@
+package isbn
+
+import "strings"
+
+// rangeRule assigns a length to the 7-digit values between low and high
+type rangeRule struct {
+	low, high string
+	length    int
+}
+
+// groupRules gives the registration group length for each GS1 prefix
+var groupRules = map[string][]rangeRule{
+	"978": {
+		{"0000000", "5999999", 1},
+		{"6000000", "6499999", 3},
+		{"6500000", "6599999", 2},
+		{"7000000", "7999999", 1},
+		{"8000000", "9499999", 2},
+		{"9500000", "9899999", 3},
+		{"9900000", "9989999", 4},
+		{"9990000", "9999999", 5},
+	},
+	"979": {
+		{"1000000", "1299999", 2},
+		{"8000000", "8999999", 1},
+	},
+}
+
+// registrantRules gives the registrant length within the main registration
+// groups, following the International ISBN Agency range message. Only the
+// English, French, German and Japanese groups (978-0 to 978-4) and 979-10 are
+// covered: ISBNs of other groups are left unhyphenated by Hyphenate.
+var registrantRules = map[string][]rangeRule{
+	// English language
+	"978-0": {
+		{"0000000", "1999999", 2},
+		{"2000000", "6999999", 3},
+		{"7000000", "8499999", 4},
+		{"8500000", "8999999", 5},
+		{"9000000", "9499999", 6},
+		{"9500000", "9999999", 7},
+	},
+	"978-1": {
+		{"0000000", "0999999", 2},
+		{"1000000", "3999999", 3},
+		{"4000000", "5499999", 4},
+		{"5500000", "8697999", 5},
+		{"8698000", "9989999", 6},
+		{"9990000", "9999999", 7},
+	},
+	// French language
+	"978-2": {
+		{"0000000", "1999999", 2},
+		{"2000000", "3499999", 3},
+		{"3500000", "3999999", 5},
+		{"4000000", "6999999", 3},
+		{"7000000", "8399999", 4},
+		{"8400000", "8999999", 5},
+		{"9000000", "9499999", 6},
+		{"9500000", "9999999", 7},
+	},
+	// German language
+	"978-3": {
+		{"0000000", "0299999", 2},
+		{"0300000", "0339999", 3},
+		{"0340000", "0369999", 4},
+		{"0370000", "0399999", 5},
+		{"0400000", "1999999", 2},
+		{"2000000", "6999999", 3},
+		{"7000000", "8499999", 4},
+		{"8500000", "8999999", 5},
+		{"9000000", "9499999", 6},
+		{"9500000", "9539999", 7},
+		{"9540000", "9699999", 5},
+		{"9700000", "9849999", 7},
+		{"9850000", "9999999", 5},
+	},
+	// Japan
+	"978-4": {
+		{"0000000", "1999999", 2},
+		{"2000000", "6999999", 3},
+		{"7000000", "8499999", 4},
+		{"8500000", "8999999", 5},
+		{"9000000", "9499999", 6},
+		{"9500000", "9999999", 7},
+	},
+	// France
+	"979-10": {
+		{"0000000", "1999999", 2},
+		{"2000000", "6999999", 3},
+		{"7000000", "8999999", 4},
+		{"9000000", "9759999", 5},
+		{"9760000", "9999999", 6},
+	},
+}
+
+// lookup returns the length assigned to the 7-digit window of digits
+func lookup(rules []rangeRule, digits string) int {
+	window := (digits + "0000000")[:7]
+	for _, rule := range rules {
+		if window >= rule.low && window <= rule.high {
+			return rule.length
+		}
+	}
+	return 0
+}
+
+// Hyphenate parses an ISBN and inserts hyphens between its elements
+// (prefix, registration group, registrant, publication, check digit).
+// The result has the same length as the input: an ISBN-10 stays an ISBN-10.
+// ISBNs outside the ranges of registrantRules are returned without hyphens,
+// as the position of their hyphens is unknown.
+func Hyphenate(value string) (string, error) {
+	isbn, err := Parse(value)
+	if err != nil {
+		return "", err
+	}
+
+	isbn13 := isbn
+	if len(isbn) == 10 {
+		if isbn13, err = To13(isbn); err != nil {
+			return "", err
+		}
+	}
+
+	prefix, rest := isbn13[:3], isbn13[3:12]
+
+	groupLength := lookup(groupRules[prefix], rest)
+	if groupLength == 0 {
+		return isbn, nil
+	}
+	group := rest[:groupLength]
+
+	registrantLength := lookup(registrantRules[prefix+"-"+group], rest[groupLength:])
+	if registrantLength == 0 {
+		return isbn, nil
+	}
+	registrant := rest[groupLength : groupLength+registrantLength]
+	publication := rest[groupLength+registrantLength:]
+
+	parts := []string{prefix, group, registrant, publication, isbn13[12:]}
+	if len(isbn) == 10 {
+		parts = []string{group, registrant, publication, isbn[9:]}
+	}
+	return strings.Join(parts, "-"), nil
+}
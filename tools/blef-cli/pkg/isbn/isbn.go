@@ -0,0 +1,169 @@
+// Package isbn parses, validates, converts and hyphenates ISBN-10 and ISBN-13 numbers.
+package isbn
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrInvalidLength     = errors.New("ISBN must have 10 or 13 digits")
+	ErrInvalidCharacter  = errors.New("ISBN contains an invalid character")
+	ErrInvalidCheckDigit = errors.New("invalid ISBN check digit")
+	ErrInvalidPrefix     = errors.New("ISBN-13 must start with 978 or 979")
+	ErrNotConvertible    = errors.New("only 978-prefixed ISBN-13 have an ISBN-10 equivalent")
+)
+
+// prefixRegex matches labels such as "ISBN", "ISBN:", "ISBN-13:" or "isbn10 "
+var prefixRegex = regexp.MustCompile(`(?i)^isbn(-?1[03])?\s*:?\s*`)
+
+// separators are removed from ISBNs, including the Unicode hyphens found in
+// copied text
+var separators = strings.NewReplacer("-", "", " ", "", "\u2010", "", "\u2011", "", "\u2013", "", "\u00a0", "")
+
+// Clean strips wrappers, labels and separators from raw input. It handles
+// Goodreads' Excel formulas (="0156013983" and =""9780156013987""), "ISBN:"
+// labels, hyphens and spaces. The result is not validated.
+func Clean(value string) string {
+	value = strings.TrimSpace(value)
+
+	// Excel formula wrapper used by Goodreads exports
+	value = strings.TrimPrefix(value, "=")
+	value = strings.Trim(value, `"`)
+
+	value = prefixRegex.ReplaceAllString(strings.TrimSpace(value), "")
+	value = separators.Replace(value)
+
+	return strings.ToUpper(value)
+}
+
+// Parse cleans raw input and validates it. It returns the 10 or 13 digit
+// form without separators.
+func Parse(value string) (string, error) {
+	isbn := Clean(value)
+	if err := Validate(isbn); err != nil {
+		return "", err
+	}
+	return isbn, nil
+}
+
+// Parse13 is like Parse but always returns an ISBN-13
+func Parse13(value string) (string, error) {
+	isbn, err := Parse(value)
+	if err != nil {
+		return "", err
+	}
+	if len(isbn) == 10 {
+		return To13(isbn)
+	}
+	return isbn, nil
+}
+
+// Validate checks a cleaned ISBN-10 or ISBN-13, including its check digit
+func Validate(isbn string) error {
+	switch len(isbn) {
+	case 10:
+		return validate10(isbn)
+	case 13:
+		return validate13(isbn)
+	default:
+		return ErrInvalidLength
+	}
+}
+
+// IsValid10 reports whether isbn is a valid ISBN-10 without separators
+func IsValid10(isbn string) bool {
+	return len(isbn) == 10 && validate10(isbn) == nil
+}
+
+// IsValid13 reports whether isbn is a valid ISBN-13 without separators
+func IsValid13(isbn string) bool {
+	return len(isbn) == 13 && validate13(isbn) == nil
+}
+
+// IsValid reports whether isbn is a valid ISBN-10 or ISBN-13 without separators
+func IsValid(isbn string) bool {
+	return Validate(isbn) == nil
+}
+
+func validate10(isbn string) error {
+	for i, char := range isbn {
+		if (char < '0' || char > '9') && !(char == 'X' && i == 9) {
+			return ErrInvalidCharacter
+		}
+	}
+	if checkDigit10(isbn[:9]) != isbn[9] {
+		return ErrInvalidCheckDigit
+	}
+	return nil
+}
+
+func validate13(isbn string) error {
+	for _, char := range isbn {
+		if char < '0' || char > '9' {
+			return ErrInvalidCharacter
+		}
+	}
+	if !strings.HasPrefix(isbn, "978") && !strings.HasPrefix(isbn, "979") {
+		return ErrInvalidPrefix
+	}
+	if checkDigit13(isbn[:12]) != isbn[12] {
+		return ErrInvalidCheckDigit
+	}
+	return nil
+}
+
+// checkDigit10 computes the modulo 11 check digit of the first 9 digits
+func checkDigit10(digits string) byte {
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += int(digits[i]-'0') * (10 - i)
+	}
+	check := (11 - sum%11) % 11
+	if check == 10 {
+		return 'X'
+	}
+	return byte('0' + check)
+}
+
+// checkDigit13 computes the modulo 10 check digit of the first 12 digits
+func checkDigit13(digits string) byte {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		digit := int(digits[i] - '0')
+		if i%2 == 0 {
+			sum += digit
+		} else {
+			sum += digit * 3
+		}
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+// To13 converts a valid ISBN-10 to ISBN-13
+func To13(isbn10 string) (string, error) {
+	if len(isbn10) != 10 {
+		return "", ErrInvalidLength
+	}
+	if err := validate10(isbn10); err != nil {
+		return "", err
+	}
+	base := "978" + isbn10[:9]
+	return base + string(checkDigit13(base)), nil
+}
+
+// To10 converts a valid 978-prefixed ISBN-13 to ISBN-10
+func To10(isbn13 string) (string, error) {
+	if len(isbn13) != 13 {
+		return "", ErrInvalidLength
+	}
+	if err := validate13(isbn13); err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(isbn13, "978") {
+		return "", ErrNotConvertible
+	}
+	base := isbn13[3:12]
+	return base + string(checkDigit10(base)), nil
+}
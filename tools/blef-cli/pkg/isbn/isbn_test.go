@@ -0,0 +1,87 @@
+package isbn
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		err      error
+	}{
+		{"9780156013987", "9780156013987", nil},
+		{"978-0-15-601398-7", "9780156013987", nil},
+		{"ISBN: 0-15-601398-3", "0156013983", nil},
+		{"ISBN-13: 978 0 15 601398 7", "9780156013987", nil},
+		{"isbn10 080442957x", "080442957X", nil},
+		{`="0156013983"`, "0156013983", nil},
+		{`=""9780156013987""`, "9780156013987", nil},
+		{"978‐0‐15‐601398‐7", "9780156013987", nil},
+		{"9780156013988", "", ErrInvalidCheckDigit},
+		{"0156013984", "", ErrInvalidCheckDigit},
+		{"9770156013987", "", ErrInvalidPrefix},
+		{"01560X3983", "", ErrInvalidCharacter},
+		{"12345", "", ErrInvalidLength},
+		{"", "", ErrInvalidLength},
+	}
+
+	for _, tt := range tests {
+		isbn, err := Parse(tt.input)
+		if isbn != tt.expected || err != tt.err {
+			t.Errorf("Parse(%q) = %q, %v, want %q, %v", tt.input, isbn, err, tt.expected, tt.err)
+		}
+	}
+}
+
+func TestConversion(t *testing.T) {
+	tests := []struct {
+		isbn10 string
+		isbn13 string
+	}{
+		{"0156013983", "9780156013987"},
+		{"0451524934", "9780451524935"},
+		{"080442957X", "9780804429573"},
+		{"207036822X", "9782070368228"},
+	}
+
+	for _, tt := range tests {
+		if isbn13, err := To13(tt.isbn10); err != nil || isbn13 != tt.isbn13 {
+			t.Errorf("To13(%s) = %s, %v, want %s", tt.isbn10, isbn13, err, tt.isbn13)
+		}
+		if isbn10, err := To10(tt.isbn13); err != nil || isbn10 != tt.isbn10 {
+			t.Errorf("To10(%s) = %s, %v, want %s", tt.isbn13, isbn10, err, tt.isbn10)
+		}
+	}
+
+	if _, err := To10("9791032305690"); err != ErrNotConvertible {
+		t.Errorf("To10 of a 979 ISBN should fail with ErrNotConvertible, got %v", err)
+	}
+	if isbn13, err := Parse13("ISBN 0-15-601398-3"); err != nil || isbn13 != "9780156013987" {
+		t.Errorf("Parse13 = %s, %v", isbn13, err)
+	}
+}
+
+func TestHyphenate(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		err      error
+	}{
+		{"9780156013987", "978-0-15-601398-7", nil},
+		{"0156013983", "0-15-601398-3", nil},
+		{"9780451524935", "978-0-451-52493-5", nil},
+		{"9781400079988", "978-1-4000-7998-8", nil},
+		{"9782070368228", "978-2-07-036822-8", nil},
+		{"9783161484100", "978-3-16-148410-0", nil},
+		{"9791032305690", "979-10-323-0569-0", nil},
+		{"9788845292613", "9788845292613", nil},
+		{"8845292614", "8845292614", nil},
+		{"not an isbn", "", ErrInvalidLength},
+	}
+
+	for _, tt := range tests {
+		hyphenated, err := Hyphenate(tt.input)
+		if hyphenated != tt.expected || err != tt.err {
+			t.Errorf("Hyphenate(%q) = %q, %v, want %q, %v", tt.input, hyphenated, err, tt.expected, tt.err)
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/blef"
+)
+
+// GoalsKey is the user metadata key holding the reading goals
+const GoalsKey = "goals"
+
+// Goal periods
+const (
+	Yearly  = "yearly"
+	Monthly = "monthly"
+)
+
+// Goal metrics
+const (
+	MetricBooks = "books"
+	MetricPages = "pages"
+)
+
+// Goal statuses
+const (
+	GoalNotStarted = "not-started"
+	GoalOnTrack    = "on-track"
+	GoalBehind     = "behind"
+	GoalCompleted  = "completed"
+	GoalMissed     = "missed"
+)
+
+// Goal is a reading goal: a number of books or pages to finish in a year or
+// a month, optionally in a language or a subject. A zero year or month is
+// the current one.
+type Goal struct {
+	Name     string `json:"name,omitempty"`
+	Period   string `json:"period"`
+	Year     int    `json:"year,omitempty"`
+	Month    int    `json:"month,omitempty"`
+	Metric   string `json:"metric"`
+	Target   int    `json:"target"`
+	Language string `json:"language,omitempty"`
+	Subject  string `json:"subject,omitempty"`
+}
+
+// String returns the goal in the form read by ParseGoal
+func (g Goal) String() string {
+	parts := []string{strconv.Itoa(g.Target), g.Metric}
+	switch {
+	case g.Period == Monthly && g.Year != 0 && g.Month != 0:
+		parts = append(parts, fmt.Sprintf("%04d-%02d", g.Year, g.Month))
+	case g.Period == Yearly && g.Year != 0:
+		parts = append(parts, strconv.Itoa(g.Year))
+	default:
+		parts = append(parts, g.Period)
+	}
+	if g.Language != "" {
+		parts = append(parts, "language="+g.Language)
+	}
+	if g.Subject != "" {
+		parts = append(parts, "subject="+strings.ReplaceAll(g.Subject, " ", "_"))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Validate checks that the goal can be evaluated
+func (g Goal) Validate() error {
+	if g.Period != Yearly && g.Period != Monthly {
+		return fmt.Errorf("goal period must be %s or %s, got %q", Yearly, Monthly, g.Period)
+	}
+	if g.Metric != MetricBooks && g.Metric != MetricPages {
+		return fmt.Errorf("goal metric must be %s or %s, got %q", MetricBooks, MetricPages, g.Metric)
+	}
+	if g.Target <= 0 {
+		return fmt.Errorf("goal target must be positive, got %d", g.Target)
+	}
+	if g.Month < 0 || g.Month > 12 {
+		return fmt.Errorf("goal month must be between 1 and 12, got %d", g.Month)
+	}
+	return nil
+}
+
+// ParseGoal parses a goal written as "<target> books|pages [yearly|monthly|
+// YYYY|YYYY-MM] [language=<code>] [subject=<name>]", e.g. "50 books 2025",
+// "1000 pages monthly" or "10 books subject=Science_Fiction". The period
+// defaults to yearly.
+func ParseGoal(s string) (Goal, error) {
+	fields := strings.Fields(s)
+	if len(fields) < 2 {
+		return Goal{}, fmt.Errorf("invalid goal %q: expected \"<target> books|pages\"", s)
+	}
+	target, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return Goal{}, fmt.Errorf("invalid goal %q: target must be a number", s)
+	}
+	goal := Goal{Period: Yearly, Metric: strings.ToLower(fields[1]), Target: target}
+
+	for _, field := range fields[2:] {
+		key, value, hasValue := strings.Cut(field, "=")
+		switch {
+		case hasValue && key == "language":
+			goal.Language = value
+		case hasValue && key == "subject":
+			goal.Subject = value
+		case field == Yearly || field == Monthly:
+			goal.Period = field
+		default:
+			if month, err := time.Parse("2006-01", field); err == nil {
+				goal.Period, goal.Year, goal.Month = Monthly, month.Year(), int(month.Month())
+			} else if year, err := time.Parse("2006", field); err == nil {
+				goal.Period, goal.Year = Yearly, year.Year()
+			} else {
+				return Goal{}, fmt.Errorf("invalid goal %q: unknown term %q", s, field)
+			}
+		}
+	}
+	// Subjects of several words are written with underscores
+	goal.Subject = strings.ReplaceAll(goal.Subject, "_", " ")
+
+	return goal, goal.Validate()
+}
+
+// GoalsFile is the content of a goals sidecar file
+type GoalsFile struct {
+	Goals []Goal `json:"goals"`
+}
+
+// LoadGoals returns the goals declared in the user metadata of a document
+func LoadGoals(doc *blef.BLEFDocument) ([]Goal, error) {
+	if doc.User == nil || doc.User.Metadata[GoalsKey] == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(doc.User.Metadata[GoalsKey])
+	if err != nil {
+		return nil, err
+	}
+	var goals []Goal
+	if err := json.Unmarshal(data, &goals); err != nil {
+		return nil, fmt.Errorf("invalid goals in user metadata: %w", err)
+	}
+	return goals, validateGoals(goals)
+}
+
+// SaveGoals stores goals in the user metadata of a document
+func SaveGoals(doc *blef.BLEFDocument, goals []Goal) {
+	if doc.User == nil {
+		doc.User = &blef.User{}
+	}
+	if len(goals) == 0 {
+		delete(doc.User.Metadata, GoalsKey)
+		return
+	}
+	if doc.User.Metadata == nil {
+		doc.User.Metadata = make(map[string]interface{})
+	}
+	doc.User.Metadata[GoalsKey] = goals
+}
+
+// LoadGoalsFile loads goals from a sidecar file
+func LoadGoalsFile(filename string) ([]Goal, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	var file GoalsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse goals: %w", err)
+	}
+	return file.Goals, validateGoals(file.Goals)
+}
+
+func validateGoals(goals []Goal) error {
+	for i, goal := range goals {
+		if err := goal.Validate(); err != nil {
+			return fmt.Errorf("goal %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// Progress is the state of a goal at a given date
+type Progress struct {
+	Goal      Goal      `json:"goal"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"` // last day of the period
+	Done      int       `json:"done"`
+	Percent   float64   `json:"percent"`
+	Remaining int       `json:"remaining"`
+	Status    string    `json:"status"`
+
+	// Projected is the day the target is reached at the current pace, nil
+	// when nothing was read yet
+	Projected *time.Time `json:"projected,omitempty"`
+
+	// PaceNeeded is the number of books or pages to read per day, from
+	// today to the end of the period, to reach the target
+	PaceNeeded float64 `json:"pace_needed"`
+}
+
+// EvaluateGoals measures the progress of goals at the given date, with the
+// reads finished during their period
+func EvaluateGoals(doc *blef.BLEFDocument, goals []Goal, now time.Time) []Progress {
+	reads := collectReads(doc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	progress := make([]Progress, len(goals))
+	for i, goal := range goals {
+		p := Progress{Goal: goal}
+		p.Start, p.End = goal.bounds(today)
+		start, end := blef.DateOf(p.Start), blef.DateOf(p.End)
+
+		for _, r := range reads {
+			if r.finished.Precision() < goal.precision() || r.finished.Before(start) || r.finished.After(end) || !goal.matches(r.book) {
+				continue
+			}
+			if goal.Metric == MetricPages {
+				p.Done += r.book.Edition.PageCount()
+			} else {
+				p.Done++
+			}
+		}
+
+		p.Percent = math.Min(100, float64(p.Done)*100/float64(goal.Target))
+		p.Remaining = max(0, goal.Target-p.Done)
+		p.Status = p.status(today)
+
+		if p.Remaining > 0 && !today.After(p.End) {
+			p.PaceNeeded = float64(p.Remaining) / float64(days(maxTime(today, p.Start), p.End))
+			if p.Done > 0 && !today.Before(p.Start) {
+				elapsed := days(p.Start, today)
+				projected := p.Start.AddDate(0, 0, int(math.Ceil(float64(goal.Target)*float64(elapsed)/float64(p.Done)))-1)
+				p.Projected = &projected
+			}
+		}
+		progress[i] = p
+	}
+	return progress
+}
+
+// bounds returns the first and last day of the goal period
+func (g Goal) bounds(today time.Time) (time.Time, time.Time) {
+	year := g.Year
+	if year == 0 {
+		year = today.Year()
+	}
+	if g.Period == Monthly {
+		month := time.Month(g.Month)
+		if g.Month == 0 {
+			month = today.Month()
+		}
+		start := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, -1)
+	}
+	return time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(year, 12, 31, 0, 0, 0, 0, time.UTC)
+}
+
+// precision returns the precision a finished date needs to be counted: a
+// read only known to the year cannot count for a monthly goal
+func (g Goal) precision() blef.DatePrecision {
+	if g.Period == Monthly {
+		return blef.PrecisionMonth
+	}
+	return blef.PrecisionYear
+}
+
+// matches reports whether a book counts for the goal
+func (g Goal) matches(book *blef.Book) bool {
+	if g.Language != "" && !strings.EqualFold(book.Language, g.Language) {
+		return false
+	}
+	if g.Subject == "" {
+		return true
+	}
+	for _, subject := range book.Subjects {
+		if strings.EqualFold(subject, g.Subject) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Progress) status(today time.Time) string {
+	switch {
+	case p.Remaining == 0:
+		return GoalCompleted
+	case today.After(p.End):
+		return GoalMissed
+	case today.Before(p.Start):
+		return GoalNotStarted
+	}
+	// On track when the share of the target done is at least the share of
+	// the period elapsed
+	elapsed := float64(days(p.Start, today)) / float64(days(p.Start, p.End))
+	if float64(p.Done) >= elapsed*float64(p.Goal.Target) {
+		return GoalOnTrack
+	}
+	return GoalBehind
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
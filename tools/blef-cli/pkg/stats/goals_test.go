@@ -0,0 +1,96 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/blef"
+)
+
+func TestParseGoal(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Goal
+	}{
+		{"50 books", Goal{Period: Yearly, Metric: MetricBooks, Target: 50}},
+		{"50 books 2025", Goal{Period: Yearly, Year: 2025, Metric: MetricBooks, Target: 50}},
+		{"1000 pages monthly", Goal{Period: Monthly, Metric: MetricPages, Target: 1000}},
+		{"3 books 2024-02 language=fr", Goal{Period: Monthly, Year: 2024, Month: 2, Metric: MetricBooks, Target: 3, Language: "fr"}},
+		{"10 books subject=Science_Fiction", Goal{Period: Yearly, Metric: MetricBooks, Target: 10, Subject: "Science Fiction"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseGoal(tt.input)
+		if err != nil {
+			t.Errorf("ParseGoal(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseGoal(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+		if again, _ := ParseGoal(got.String()); again != got {
+			t.Errorf("Expected %q to parse back to %+v, got %+v", got.String(), got, again)
+		}
+	}
+
+	for _, input := range []string{"books", "ten books", "5 chapters", "0 books", "5 books weekly"} {
+		if _, err := ParseGoal(input); err == nil {
+			t.Errorf("Expected an error for %q", input)
+		}
+	}
+}
+
+func TestGoalsMetadata(t *testing.T) {
+	doc := testDocument()
+	goals := []Goal{{Period: Yearly, Metric: MetricBooks, Target: 12}}
+	SaveGoals(doc, goals)
+
+	data, _ := doc.ToJSON()
+	loaded, _ := blef.FromJSON(data)
+	got, err := LoadGoals(loaded)
+	if err != nil {
+		t.Fatalf("LoadGoals failed: %v", err)
+	}
+	if len(got) != 1 || got[0] != goals[0] {
+		t.Errorf("Expected the goals back, got %+v", got)
+	}
+}
+
+func TestEvaluateGoals(t *testing.T) {
+	doc := testDocument()
+	goals := []Goal{
+		{Period: Yearly, Year: 2024, Metric: MetricBooks, Target: 4},
+		{Period: Yearly, Year: 2024, Metric: MetricPages, Target: 1000},
+		{Period: Yearly, Year: 2024, Metric: MetricBooks, Target: 1, Language: "fr"},
+		{Period: Monthly, Metric: MetricBooks, Target: 2},
+	}
+	// 60 days into 2024 (a leap year)
+	now := time.Date(2024, 2, 29, 18, 0, 0, 0, time.UTC)
+	progress := EvaluateGoals(doc, goals, now)
+
+	books := progress[0]
+	if books.Done != 2 || books.Percent != 50 || books.Remaining != 2 || books.Status != GoalOnTrack {
+		t.Errorf("Unexpected books progress: %+v", books)
+	}
+	// 2 books in 60 days: 4 books after 120 days
+	if books.Projected == nil || books.Projected.Format("2006-01-02") != "2024-04-29" {
+		t.Errorf("Unexpected projection: %v", books.Projected)
+	}
+	// 2 books in the 307 days left
+	if books.PaceNeeded < 0.0065 || books.PaceNeeded > 0.0066 {
+		t.Errorf("Unexpected pace needed: %f", books.PaceNeeded)
+	}
+
+	if pages := progress[1]; pages.Done != 1500 || pages.Percent != 100 || pages.Status != GoalCompleted || pages.Projected != nil {
+		t.Errorf("Unexpected pages progress: %+v", pages)
+	}
+	if french := progress[2]; french.Done != 0 || french.Status != GoalBehind || french.Projected != nil {
+		t.Errorf("Unexpected progress for the language goal: %+v", french)
+	}
+	if monthly := progress[3]; monthly.Start.Format("2006-01-02") != "2024-02-01" || monthly.End.Format("2006-01-02") != "2024-02-29" || monthly.Done != 1 {
+		t.Errorf("Unexpected progress for the current month: %+v", monthly)
+	}
+
+	if missed := EvaluateGoals(doc, goals[:1], now.AddDate(1, 0, 0))[0]; missed.Status != GoalMissed || missed.PaceNeeded != 0 {
+		t.Errorf("Expected a missed goal after its year, got %+v", missed)
+	}
+}
@@ -0,0 +1,130 @@
+package stats
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/blef"
+)
+
+// Review is a year in review: the books finished during a year and what
+// stands out among them
+type Review struct {
+	Year     int
+	UserName string
+
+	// Books are the reads finished during the year, in order. A book read
+	// twice appears twice.
+	Books []ReviewBook
+
+	Pages        int
+	Shortest     *ReviewBook
+	Longest      *ReviewBook
+	HighestRated *ReviewBook
+	Months       [12]int // books finished per month
+
+	// Favorites are the books of the year marked as favorite, once each
+	Favorites []ReviewBook
+
+	// NewAuthors are the authors first read during the year
+	NewAuthors []string
+}
+
+// ReviewBook is a book finished during the year
+type ReviewBook struct {
+	Title    string
+	Authors  string
+	CoverURL string
+	Finished blef.PartialDate
+	Pages    int
+	Rating   float64
+	Favorite bool
+	Reread   bool
+}
+
+// YearInReview builds the review of a year from the read dates of the
+// document
+func YearInReview(doc *blef.BLEFDocument, year int) *Review {
+	review := &Review{Year: year}
+	if doc.User != nil {
+		review.UserName = doc.User.Name
+	}
+	authorName := canonicalAuthors(doc)
+
+	// The first read of each author, to tell the new ones
+	firstRead := make(map[string]blef.PartialDate)
+	reads := collectReads(doc)
+	for _, r := range reads {
+		for _, author := range r.book.Authors {
+			if !isAuthor(author) {
+				continue
+			}
+			name := authorName(author.Name)
+			if first, ok := firstRead[name]; !ok || r.finished.Time().Before(first.Time()) {
+				firstRead[name] = r.finished
+			}
+		}
+	}
+	for name, first := range firstRead {
+		if first.Year() == year {
+			review.NewAuthors = append(review.NewAuthors, name)
+		}
+	}
+	sort.Strings(review.NewAuthors)
+
+	var yearReads []read
+	for _, r := range reads {
+		if r.finished.Year() == year {
+			yearReads = append(yearReads, r)
+		}
+	}
+	sort.SliceStable(yearReads, func(i, j int) bool { return yearReads[i].finished.Time().Before(yearReads[j].finished.Time()) })
+
+	favorites := make(map[*blef.Book]bool)
+	for _, r := range yearReads {
+		var names []string
+		for _, author := range r.book.Authors {
+			if isAuthor(author) {
+				names = append(names, authorName(author.Name))
+			}
+		}
+		book := ReviewBook{
+			Title:    r.book.Title,
+			Authors:  strings.Join(names, ", "),
+			CoverURL: r.book.CoverURL,
+			Finished: r.finished,
+			Rating:   r.entry.UserData.Rating,
+			Favorite: r.entry.UserData.Favorite,
+			Reread:   r.reread,
+		}
+		book.Pages = r.book.Edition.PageCount()
+		review.Books = append(review.Books, book)
+		review.Pages += book.Pages
+		if r.finished.Precision() >= blef.PrecisionMonth {
+			review.Months[r.finished.Month()-1]++
+		}
+
+		if book.Favorite && !favorites[r.book] {
+			favorites[r.book] = true
+			review.Favorites = append(review.Favorites, book)
+		}
+	}
+
+	// Ties go to the book finished first
+	for i := range review.Books {
+		book := &review.Books[i]
+		if book.Pages > 0 {
+			if review.Shortest == nil || book.Pages < review.Shortest.Pages {
+				review.Shortest = book
+			}
+			if review.Longest == nil || book.Pages > review.Longest.Pages {
+				review.Longest = book
+			}
+		}
+		if book.Rating > 0 && (review.HighestRated == nil || book.Rating > review.HighestRated.Rating) {
+			review.HighestRated = book
+		}
+	}
+
+	return review
+}
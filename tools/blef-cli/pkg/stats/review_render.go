@@ -0,0 +1,106 @@
+package stats
+
+import (
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"math"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/blef"
+)
+
+// Review output formats
+const (
+	ReviewHTML     = "html"
+	ReviewMarkdown = "markdown"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+var reviewFuncs = map[string]interface{}{
+	"stars":     stars,
+	"monthName": func(i int) string { return time.Month(i + 1).String() },
+	"date":      reviewDate,
+	"cell":      func(s string) string { return strings.ReplaceAll(s, "|", "\\|") },
+}
+
+var (
+	htmlReview = htmltemplate.Must(htmltemplate.New("review.html.tmpl").
+			Funcs(reviewFuncs).
+			Funcs(htmltemplate.FuncMap{"chart": func(months [12]int) htmltemplate.HTML { return htmltemplate.HTML(MonthlyChartSVG(months)) }}).
+			ParseFS(templateFS, "templates/review.html.tmpl"))
+	markdownReview = template.Must(template.New("review.md.tmpl").
+			Funcs(reviewFuncs).
+			Funcs(template.FuncMap{"chart": MonthlyChartSVG}).
+			ParseFS(templateFS, "templates/review.md.tmpl"))
+)
+
+// WriteReview renders a year in review as a self-contained HTML page or as
+// Markdown. Covers are linked from their cover_url.
+func WriteReview(w io.Writer, review *Review, format string) error {
+	switch format {
+	case ReviewHTML:
+		return htmlReview.Execute(w, review)
+	case ReviewMarkdown:
+		return markdownReview.Execute(w, review)
+	default:
+		return fmt.Errorf("unknown review format %q (supported: %s, %s)", format, ReviewHTML, ReviewMarkdown)
+	}
+}
+
+// reviewDate formats a finished date within its year, e.g. "March 5"
+func reviewDate(d blef.PartialDate) string {
+	switch {
+	case d.Precision() >= blef.PrecisionDay:
+		return d.Time().Format("January 2")
+	case d.Precision() == blef.PrecisionMonth:
+		return d.Month().String()
+	}
+	return ""
+}
+
+// MonthlyChartSVG draws the books finished per month as an SVG bar chart
+func MonthlyChartSVG(months [12]int) string {
+	const (
+		width, height = 600, 220
+		top, bottom   = 24, 24
+		barWidth      = 34
+		step          = width / 12
+	)
+	max := 0
+	for _, count := range months {
+		if count > max {
+			max = count
+		}
+	}
+
+	var svg strings.Builder
+	fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="%d" height="%d" role="img" aria-label="Books finished per month">`, width, height, width, height)
+	for i, count := range months {
+		x := i*step + (step-barWidth)/2
+		barHeight := 0
+		if max > 0 {
+			barHeight = int(math.Round(float64(count) / float64(max) * float64(height-top-bottom)))
+		}
+		y := height - bottom - barHeight
+		if count > 0 {
+			fmt.Fprintf(&svg, `<rect x="%d" y="%d" width="%d" height="%d" rx="3" fill="#d6336c"/>`, x, y, barWidth, barHeight)
+			fmt.Fprintf(&svg, `<text x="%d" y="%d" text-anchor="middle" font-size="12" fill="#333">%d</text>`, x+barWidth/2, y-6, count)
+		}
+		fmt.Fprintf(&svg, `<text x="%d" y="%d" text-anchor="middle" font-size="12" fill="#666">%s</text>`, x+barWidth/2, height-6, time.Month(i + 1).String()[:3])
+	}
+	svg.WriteString(`</svg>`)
+	return svg.String()
+}
+
+// stars renders a rating from 0 to 5, half stars rounded up. Ratings out of
+// range, which an unvalidated document can hold, are clamped.
+func stars(rating float64) string {
+	full := min(max(int(math.Ceil(rating)), 0), 5)
+	return strings.Repeat("★", full) + strings.Repeat("☆", 5-full)
+}
@@ -0,0 +1,79 @@
+package stats
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestYearInReview(t *testing.T) {
+	doc := testDocument()
+	doc.Books[1].CoverURL = "https://covers.example.com/lotr.jpg"
+	doc.Entries[1].UserData.Favorite = true
+
+	review := YearInReview(doc, 2024)
+	if len(review.Books) != 2 || review.Books[0].Title != "The Hobbit" || !review.Books[0].Reread {
+		t.Fatalf("Unexpected books: %+v", review.Books)
+	}
+	if review.Pages != 1500 || review.Months[0] != 1 || review.Months[1] != 1 {
+		t.Errorf("Unexpected pages %d or months %v", review.Pages, review.Months)
+	}
+	if review.Shortest.Title != "The Hobbit" || review.Longest.Title != "The Lord of the Rings" || review.HighestRated.Title != "The Hobbit" {
+		t.Errorf("Unexpected highlights: %s, %s, %s", review.Shortest.Title, review.Longest.Title, review.HighestRated.Title)
+	}
+	if len(review.Favorites) != 1 || review.Favorites[0].Title != "The Lord of the Rings" {
+		t.Errorf("Unexpected favorites: %+v", review.Favorites)
+	}
+	if len(review.NewAuthors) != 0 {
+		t.Errorf("Expected Tolkien to be discovered in 2022, not 2024, got %v", review.NewAuthors)
+	}
+	if authors := YearInReview(doc, 2022).NewAuthors; len(authors) != 1 {
+		t.Errorf("Expected Tolkien to be discovered in 2022, got %v", authors)
+	}
+}
+
+func TestWriteReview(t *testing.T) {
+	doc := testDocument()
+	doc.Books[1].CoverURL = "https://covers.example.com/lotr.jpg"
+	doc.Books[0].Title = "The Hobbit <There & Back Again>"
+	review := YearInReview(doc, 2024)
+
+	var html bytes.Buffer
+	if err := WriteReview(&html, review, ReviewHTML); err != nil {
+		t.Fatalf("WriteReview failed: %v", err)
+	}
+	for _, want := range []string{"<svg", `src="https://covers.example.com/lotr.jpg"`, "The Hobbit &lt;There &amp; Back Again&gt;", "1500"} {
+		if !strings.Contains(html.String(), want) {
+			t.Errorf("Expected %q in the HTML review", want)
+		}
+	}
+
+	var markdown bytes.Buffer
+	if err := WriteReview(&markdown, review, ReviewMarkdown); err != nil {
+		t.Fatalf("WriteReview failed: %v", err)
+	}
+	for _, want := range []string{"# 2024 in books", "<svg", "| February | 1 |", "**Longest:** The Lord of the Rings (1200 pages)"} {
+		if !strings.Contains(markdown.String(), want) {
+			t.Errorf("Expected %q in the Markdown review:\n%s", want, markdown.String())
+		}
+	}
+
+	if err := WriteReview(&markdown, review, "pdf"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
+
+func TestStars(t *testing.T) {
+	tests := map[float64]string{
+		0:   "☆☆☆☆☆",
+		3.5: "★★★★☆",
+		5:   "★★★★★",
+		10:  "★★★★★",
+		-1:  "☆☆☆☆☆",
+	}
+	for rating, want := range tests {
+		if got := stars(rating); got != want {
+			t.Errorf("stars(%v) = %q, want %q", rating, got, want)
+		}
+	}
+}
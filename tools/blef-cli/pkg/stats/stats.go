@@ -0,0 +1,409 @@
+// Package stats computes reading statistics from a BLEF document.
+package stats
+
+import (
+	"sort"
+	"time"
+
+	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/blef"
+)
+
+// DefaultTop is the length of the top lists when Options.Top is not set
+const DefaultTop = 10
+
+// maxStreaks is the number of reading streaks reported
+const maxStreaks = 5
+
+const dateLayout = "2006-01-02"
+
+// Options configures Compute
+type Options struct {
+	// Year restricts the statistics to the reads finished that year, and to
+	// the books of these reads. Zero means the whole library.
+	Year int
+
+	// Top is the length of the top lists (default: DefaultTop)
+	Top int
+}
+
+// Stats holds the reading statistics of a document
+type Stats struct {
+	Year         int            `json:"year,omitempty"`
+	Books        int            `json:"books"`
+	Entries      int            `json:"entries"`
+	Statuses     map[string]int `json:"statuses"`
+	Reads        int            `json:"reads"`
+	Rereads      int            `json:"rereads"`
+	PagesRead    int            `json:"pages_read"`
+	Years        []Period       `json:"years"`
+	Months       []Period       `json:"months"`
+	Ratings      Ratings        `json:"ratings"`
+	DaysToFinish Summary        `json:"days_to_finish"`
+	Streaks      []Streak       `json:"streaks"`
+	MostReread   []Count        `json:"most_reread"`
+	Authors      []Count        `json:"top_authors"`
+	Subjects     []Count        `json:"top_subjects"`
+	Languages    []Count        `json:"top_languages"`
+	Formats      []Count        `json:"formats"`
+}
+
+// Period counts the reads finished during a year ("2024") or a month
+// ("2024-03") and their pages
+type Period struct {
+	Period string `json:"period"`
+	Books  int    `json:"books"`
+	Pages  int    `json:"pages"`
+}
+
+// Ratings summarizes the ratings given to books
+type Ratings struct {
+	Count        int           `json:"count"`
+	Average      float64       `json:"average"`
+	Median       float64       `json:"median"`
+	Distribution []RatingCount `json:"distribution"`
+}
+
+// RatingCount is the number of books with a given rating
+type RatingCount struct {
+	Rating float64 `json:"rating"`
+	Count  int     `json:"count"`
+}
+
+// Summary summarizes a series of values
+type Summary struct {
+	Count   int     `json:"count"`
+	Average float64 `json:"average"`
+	Median  float64 `json:"median"`
+}
+
+// Streak is a run of consecutive days with at least one book being read
+type Streak struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Days int    `json:"days"`
+}
+
+// Count is an entry of a top list
+type Count struct {
+	ID    string `json:"id,omitempty"` // book ID, for lists of books
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// read is a finished read of a book
+type read struct {
+	book     *blef.Book
+	entry    *blef.Entry
+	started  blef.PartialDate // zero when unknown
+	finished blef.PartialDate // zero for books marked as read without a date
+	reread   bool
+}
+
+// Compute computes the statistics of a document. Reads are the read dates
+// with a finished date; a book with the "read" status but no finished date
+// counts as one read without a date. Reads only known to the year or month
+// count in the periods they are known to. Days to finish count both the
+// started and the finished day, and need both known to the day.
+func Compute(doc *blef.BLEFDocument, opts Options) *Stats {
+	if opts.Top <= 0 {
+		opts.Top = DefaultTop
+	}
+
+	reads := collectReads(doc)
+
+	// Restrict everything to the reads of the year and their books
+	inScope := func(*blef.Book) bool { return true }
+	if opts.Year != 0 {
+		scoped := reads[:0]
+		yearBooks := make(map[*blef.Book]bool)
+		for _, r := range reads {
+			if r.finished.Year() == opts.Year {
+				scoped = append(scoped, r)
+				yearBooks[r.book] = true
+			}
+		}
+		reads = scoped
+		inScope = func(book *blef.Book) bool { return yearBooks[book] }
+	}
+
+	stats := &Stats{Year: opts.Year, Statuses: make(map[string]int)}
+
+	lib := blef.NewLibrary(doc)
+	var ratings []float64
+	for i := range doc.Entries {
+		entry := &doc.Entries[i]
+		book := lib.GetBookByID(entry.BookID)
+		if book == nil || !inScope(book) {
+			continue
+		}
+		stats.Entries++
+		stats.Statuses[entry.UserData.Status]++
+		if entry.UserData.Rating > 0 {
+			ratings = append(ratings, entry.UserData.Rating)
+		}
+	}
+	stats.Ratings = rateSummary(ratings)
+
+	years := make(map[string]*Period)
+	months := make(map[string]*Period)
+	bookReads := make(map[*blef.Book]int)
+	reread := make(map[*blef.Book]bool)
+	var durations []float64
+	for _, r := range reads {
+		stats.Reads++
+		pages := r.book.Edition.PageCount()
+		stats.PagesRead += pages
+		bookReads[r.book]++
+		if r.reread {
+			stats.Rereads++
+			reread[r.book] = true
+		}
+		if r.finished.IsZero() {
+			continue
+		}
+		addPeriod(years, r.finished.Truncate(blef.PrecisionYear).String(), pages)
+		if r.finished.Precision() >= blef.PrecisionMonth {
+			addPeriod(months, r.finished.Truncate(blef.PrecisionMonth).String(), pages)
+		}
+		if knownDays(r) {
+			durations = append(durations, float64(days(r.started.Time(), r.finished.Time())))
+		}
+	}
+	stats.Years = sortedPeriods(years)
+	stats.Months = sortedPeriods(months)
+	stats.DaysToFinish = summarize(durations)
+	stats.Streaks = streaks(reads, opts.Year)
+
+	// Books with the same title are different books
+	mostReread := make([]Count, 0, len(reread))
+	for book := range reread {
+		mostReread = append(mostReread, Count{ID: book.ID, Name: book.Title, Count: bookReads[book]})
+	}
+	stats.MostReread = topCounts(mostReread, opts.Top)
+
+	// Library composition, over the books in scope
+	authorName := canonicalAuthors(doc)
+	authors := make(map[string]int)
+	subjects := make(map[string]int)
+	languages := make(map[string]int)
+	formats := make(map[string]int)
+	for i := range doc.Books {
+		book := &doc.Books[i]
+		if !inScope(book) {
+			continue
+		}
+		stats.Books++
+		for _, author := range book.Authors {
+			if isAuthor(author) {
+				authors[authorName(author.Name)]++
+			}
+		}
+		for _, subject := range book.Subjects {
+			subjects[subject]++
+		}
+		if book.Language != "" {
+			languages[book.Language]++
+		}
+		format := "unknown"
+		if book.Edition != nil && book.Edition.Format != "" {
+			format = book.Edition.Format
+		}
+		formats[format]++
+	}
+	stats.Authors = top(authors, opts.Top)
+	stats.Subjects = top(subjects, opts.Top)
+	stats.Languages = top(languages, opts.Top)
+	stats.Formats = top(formats, 0)
+
+	return stats
+}
+
+// collectReads returns the finished reads of every entry, in order for each
+// book so that re-reads are told apart. Documents are not validated first,
+// so read dates known to the year or the month only count where their
+// precision allows.
+func collectReads(doc *blef.BLEFDocument) []read {
+	books := make(map[string]*blef.Book, len(doc.Books))
+	for i := range doc.Books {
+		books[doc.Books[i].ID] = &doc.Books[i]
+	}
+
+	var reads []read
+	for i := range doc.Entries {
+		entry := &doc.Entries[i]
+		book := books[entry.BookID]
+		if book == nil {
+			continue
+		}
+		var bookReads []read
+		for _, readDate := range entry.UserData.ReadDates {
+			if !readDate.Finished.IsValid() {
+				continue
+			}
+			r := read{book: book, entry: entry, finished: readDate.Finished.Truncate(blef.PrecisionDay)}
+			if started := readDate.Started; started.IsValid() && !started.After(r.finished) {
+				r.started = started.Truncate(blef.PrecisionDay)
+			}
+			bookReads = append(bookReads, r)
+		}
+		if len(bookReads) == 0 && entry.UserData.Status == "read" {
+			bookReads = append(bookReads, read{book: book, entry: entry})
+		}
+		sort.SliceStable(bookReads, func(i, j int) bool { return bookReads[i].finished.Time().Before(bookReads[j].finished.Time()) })
+		for j := range bookReads {
+			bookReads[j].reread = j > 0
+		}
+		reads = append(reads, bookReads...)
+	}
+	return reads
+}
+
+// knownDays reports whether a read has its started and finished days
+func knownDays(r read) bool {
+	return r.started.Precision() == blef.PrecisionDay && r.finished.Precision() == blef.PrecisionDay
+}
+
+// canonicalAuthors returns a function giving the canonical spelling of an
+// author name, as resolved by blef.ResolveAuthors
+func canonicalAuthors(doc *blef.BLEFDocument) func(name string) string {
+	identities := blef.AuthorIdentities(blef.ResolveAuthors(doc))
+	return func(name string) string {
+		if identity, ok := blef.LookupAuthor(identities, name); ok {
+			return identity.Name
+		}
+		return name
+	}
+}
+
+// isAuthor reports whether a contributor counts as an author: translators,
+// illustrators and other roles do not
+func isAuthor(author blef.Author) bool {
+	return author.Role == "" || author.Role == "author"
+}
+
+// days returns the number of days from start to end, both included
+func days(start, end time.Time) int {
+	return int(end.Sub(start).Hours()/24) + 1
+}
+
+func addPeriod(periods map[string]*Period, key string, pages int) {
+	period, ok := periods[key]
+	if !ok {
+		period = &Period{Period: key}
+		periods[key] = period
+	}
+	period.Books++
+	period.Pages += pages
+}
+
+func sortedPeriods(periods map[string]*Period) []Period {
+	sorted := make([]Period, 0, len(periods))
+	for _, period := range periods {
+		sorted = append(sorted, *period)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Period < sorted[j].Period })
+	return sorted
+}
+
+func rateSummary(ratings []float64) Ratings {
+	summary := summarize(ratings)
+	result := Ratings{Count: summary.Count, Average: summary.Average, Median: summary.Median, Distribution: []RatingCount{}}
+
+	counts := make(map[float64]int)
+	for _, rating := range ratings {
+		counts[rating]++
+	}
+	for rating, count := range counts {
+		result.Distribution = append(result.Distribution, RatingCount{Rating: rating, Count: count})
+	}
+	sort.Slice(result.Distribution, func(i, j int) bool { return result.Distribution[i].Rating > result.Distribution[j].Rating })
+	return result
+}
+
+func summarize(values []float64) Summary {
+	if len(values) == 0 {
+		return Summary{}
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	sum := 0.0
+	for _, value := range sorted {
+		sum += value
+	}
+	median := sorted[len(sorted)/2]
+	if len(sorted)%2 == 0 {
+		median = (sorted[len(sorted)/2-1] + median) / 2
+	}
+	return Summary{Count: len(sorted), Average: sum / float64(len(sorted)), Median: median}
+}
+
+// streaks merges the reading intervals of the reads and returns the longest
+// runs of days, clipped to the year if any
+func streaks(reads []read, year int) []Streak {
+	type interval struct{ start, end time.Time }
+	var intervals []interval
+	for _, r := range reads {
+		if !knownDays(r) {
+			continue
+		}
+		start, end := r.started.Time(), r.finished.Time()
+		if year != 0 {
+			if first := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC); start.Before(first) {
+				start = first
+			}
+		}
+		intervals = append(intervals, interval{start, end})
+	}
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i].start.Before(intervals[j].start) })
+
+	var merged []interval
+	for _, current := range intervals {
+		last := len(merged) - 1
+		// Intervals touching on consecutive days are one streak
+		if last >= 0 && !current.start.After(merged[last].end.AddDate(0, 0, 1)) {
+			if current.end.After(merged[last].end) {
+				merged[last].end = current.end
+			}
+			continue
+		}
+		merged = append(merged, current)
+	}
+
+	result := make([]Streak, 0, len(merged))
+	for _, run := range merged {
+		result = append(result, Streak{From: run.start.Format(dateLayout), To: run.end.Format(dateLayout), Days: days(run.start, run.end)})
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Days > result[j].Days })
+	if len(result) > maxStreaks {
+		result = result[:maxStreaks]
+	}
+	return result
+}
+
+// top returns the n largest counts, all of them if n is zero
+func top(counts map[string]int, n int) []Count {
+	result := make([]Count, 0, len(counts))
+	for name, count := range counts {
+		result = append(result, Count{Name: name, Count: count})
+	}
+	return topCounts(result, n)
+}
+
+// topCounts sorts counts by decreasing count, then name and ID, and keeps
+// the n first
+func topCounts(result []Count, n int) []Count {
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].ID < result[j].ID
+	})
+	if n > 0 && len(result) > n {
+		result = result[:n]
+	}
+	return result
+}
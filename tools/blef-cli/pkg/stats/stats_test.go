@@ -0,0 +1,124 @@
+package stats
+
+import (
+	"testing"
+
+	"github.com/yoanbernabeu/BLEF/tools/blef-cli/pkg/blef"
+)
+
+// pages returns a page count for an edition literal
+func pages(n int) *int {
+	return &n
+}
+
+func testDocument() *blef.BLEFDocument {
+	doc := blef.NewDocument()
+	doc.Books = []blef.Book{
+		{ID: "9780547928227", Title: "The Hobbit", Authors: []blef.Author{{Name: "J.R.R. Tolkien"}}, Language: "en",
+			Subjects: []string{"Fantasy"}, Edition: &blef.Edition{Format: "paperback", Pages: pages(300)}},
+		{ID: "9780618640157", Title: "The Lord of the Rings", Authors: []blef.Author{{Name: "Tolkien, J. R. R."}}, Language: "en",
+			Subjects: []string{"Fantasy", "Classics"}, Edition: &blef.Edition{Format: "hardcover", Pages: pages(1200)}},
+		{ID: "9782070612758", Title: "Le Petit Prince", Authors: []blef.Author{{Name: "Antoine de Saint-Exupéry"}, {Name: "Anne Translator", Role: "translator"}},
+			Language: "fr", Edition: &blef.Edition{Pages: pages(100)}},
+	}
+	doc.Entries = []blef.Entry{
+		{BookID: "9780547928227", UserData: blef.UserData{Status: "read", Rating: 5, ReadDates: []blef.ReadDate{
+			{Started: blef.MustParseDate("2023-12-28"), Finished: blef.MustParseDate("2024-01-05")},
+			{Started: blef.MustParseDate("2022-03-01"), Finished: blef.MustParseDate("2022-03-10")},
+		}}},
+		{BookID: "9780618640157", UserData: blef.UserData{Status: "read", Rating: 4, ReadDates: []blef.ReadDate{
+			{Started: blef.MustParseDate("2024-01-06"), Finished: blef.MustParseDate("2024-02-20")},
+		}}},
+		{BookID: "9782070612758", UserData: blef.UserData{Status: "read", Rating: 4}},
+	}
+	return doc
+}
+
+func TestCompute(t *testing.T) {
+	stats := Compute(testDocument(), Options{})
+
+	if stats.Books != 3 || stats.Reads != 4 || stats.Rereads != 1 || stats.PagesRead != 1900 {
+		t.Errorf("Unexpected totals: %d books, %d reads, %d rereads, %d pages", stats.Books, stats.Reads, stats.Rereads, stats.PagesRead)
+	}
+	if len(stats.Years) != 2 || stats.Years[1] != (Period{Period: "2024", Books: 2, Pages: 1500}) {
+		t.Errorf("Unexpected years: %+v", stats.Years)
+	}
+	if len(stats.Months) != 3 || stats.Months[0].Period != "2022-03" {
+		t.Errorf("Unexpected months: %+v", stats.Months)
+	}
+
+	if stats.Ratings.Count != 3 || stats.Ratings.Median != 4 || stats.Ratings.Distribution[0] != (RatingCount{Rating: 5, Count: 1}) {
+		t.Errorf("Unexpected ratings: %+v", stats.Ratings)
+	}
+	// 10, 9 and 46 days
+	if stats.DaysToFinish.Count != 3 || stats.DaysToFinish.Median != 10 {
+		t.Errorf("Unexpected days to finish: %+v", stats.DaysToFinish)
+	}
+
+	// The Hobbit then The Lord of the Rings the next day
+	if len(stats.Streaks) != 2 || stats.Streaks[0] != (Streak{From: "2023-12-28", To: "2024-02-20", Days: 55}) {
+		t.Errorf("Unexpected streaks: %+v", stats.Streaks)
+	}
+	if len(stats.MostReread) != 1 || stats.MostReread[0] != (Count{ID: "9780547928227", Name: "The Hobbit", Count: 2}) {
+		t.Errorf("Unexpected most re-read: %+v", stats.MostReread)
+	}
+
+	if len(stats.Authors) != 2 || stats.Authors[0].Count != 2 {
+		t.Errorf("Expected both spellings of Tolkien to count as one author and translators to be skipped, got %+v", stats.Authors)
+	}
+	if stats.Subjects[0] != (Count{Name: "Fantasy", Count: 2}) || stats.Languages[0] != (Count{Name: "en", Count: 2}) {
+		t.Errorf("Unexpected subjects %+v or languages %+v", stats.Subjects, stats.Languages)
+	}
+	if len(stats.Formats) != 3 {
+		t.Errorf("Expected 3 formats including unknown, got %+v", stats.Formats)
+	}
+}
+
+func TestComputeYear(t *testing.T) {
+	stats := Compute(testDocument(), Options{Year: 2024, Top: 1})
+
+	if stats.Books != 2 || stats.Reads != 2 || stats.Rereads != 1 || stats.PagesRead != 1500 {
+		t.Errorf("Unexpected totals: %d books, %d reads, %d rereads, %d pages", stats.Books, stats.Reads, stats.Rereads, stats.PagesRead)
+	}
+	if len(stats.Years) != 1 || stats.Statuses["read"] != 2 {
+		t.Errorf("Expected only 2024, got %+v and %v", stats.Years, stats.Statuses)
+	}
+	if stats.Streaks[0].From != "2024-01-01" {
+		t.Errorf("Expected the streak to start with the year, got %+v", stats.Streaks[0])
+	}
+	if len(stats.Subjects) != 1 {
+		t.Errorf("Expected the top lists to be limited, got %+v", stats.Subjects)
+	}
+}
+
+func TestComputeMostRereadSameTitle(t *testing.T) {
+	doc := testDocument()
+	doc.Books[1].Title = "The Hobbit"
+	doc.Entries[1].UserData.ReadDates = append(doc.Entries[1].UserData.ReadDates,
+		blef.ReadDate{Finished: blef.MustParseDate("2023-05-01")})
+	stats := Compute(doc, Options{})
+
+	if len(stats.MostReread) != 2 || stats.MostReread[0].Count != 2 || stats.MostReread[1].Count != 2 {
+		t.Errorf("Expected books with the same title to be counted apart, got %+v", stats.MostReread)
+	}
+	if stats.MostReread[0].ID == stats.MostReread[1].ID {
+		t.Errorf("Expected the book IDs in the list, got %+v", stats.MostReread)
+	}
+}
+
+func TestComputePartialDates(t *testing.T) {
+	doc := testDocument()
+	doc.Entries[2].UserData.ReadDates = []blef.ReadDate{{Started: blef.MustParseDate("2023"), Finished: blef.MustParseDate("2024")}}
+	doc.Entries[1].UserData.ReadDates[0].Finished = blef.MustParseDate("2024-02")
+	stats := Compute(doc, Options{})
+
+	if len(stats.Years) != 2 || stats.Years[1] != (Period{Period: "2024", Books: 3, Pages: 1600}) {
+		t.Errorf("Expected reads known to the year to count in it, got %+v", stats.Years)
+	}
+	if len(stats.Months) != 3 || stats.Months[2] != (Period{Period: "2024-02", Books: 1, Pages: 1200}) {
+		t.Errorf("Expected reads known to the year to be left out of months, got %+v", stats.Months)
+	}
+	if stats.DaysToFinish.Count != 2 {
+		t.Errorf("Expected days to finish to need both days, got %+v", stats.DaysToFinish)
+	}
+}
@@ -21,6 +21,7 @@ const (
 // Model represents the bubbletea model for the viewer
 type Model struct {
 	doc          *blef.BLEFDocument
+	lib          *blef.Library
 	mode         viewMode
 	selectedIdx  int
 	filteredData []interface{}
@@ -34,6 +35,7 @@ type Model struct {
 func NewModel(doc *blef.BLEFDocument) Model {
 	model := Model{
 		doc:         doc,
+		lib:         blef.NewLibrary(doc),
 		mode:        booksView,
 		selectedIdx: 0,
 		width:       80,
@@ -368,7 +370,7 @@ func (m Model) renderDetail() string {
 	}
 
 	// Get entry data
-	entries := m.doc.GetEntriesForBook(book.ID)
+	entries := m.lib.GetEntriesForBook(book.ID)
 	if len(entries) > 0 {
 		entry := entries[0]
 		content.WriteString("\n")
@@ -398,25 +400,14 @@ func (m Model) renderDetail() string {
 // Helper functions
 
 func (m *Model) getBookStatus(bookID string) string {
-	for _, entry := range m.doc.Entries {
-		if entry.BookID == bookID {
-			return entry.UserData.Status
-		}
+	if entry := m.lib.GetEntryForBook(bookID); entry != nil {
+		return entry.UserData.Status
 	}
 	return ""
 }
 
 func (m *Model) getCollectionBookCount(collID string) int {
-	count := 0
-	for _, entry := range m.doc.Entries {
-		for _, cid := range entry.CollectionIDs {
-			if cid == collID {
-				count++
-				break
-			}
-		}
-	}
-	return count
+	return m.lib.CountEntriesInCollection(collID)
 }
 
 func getStatusEmoji(status string) string {